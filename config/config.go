@@ -0,0 +1,449 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"webservice/validate"
+)
+
+//Config holds every setting of the service.
+//Values are read from the defaults, then from the optional config file and finally from the environment.
+type Config struct {
+	Server       Server       `json:"server" yaml:"server"`
+	Storage      Storage      `json:"storage" yaml:"storage"`
+	Mongo        Mongo        `json:"mongo" yaml:"mongo"`
+	Hana         Hana         `json:"hana" yaml:"hana"`
+	Cache        Cache        `json:"cache" yaml:"cache"`
+	Pipeline     Pipeline     `json:"pipeline" yaml:"pipeline"`
+	Offers       Offers       `json:"offers" yaml:"offers"`
+	Requisitions Requisitions `json:"requisitions" yaml:"requisitions"`
+	Approvals    Approvals    `json:"approvals" yaml:"approvals"`
+	Attachments  Attachments  `json:"attachments" yaml:"attachments"`
+}
+
+type Server struct {
+	Addr         string   `json:"addr" yaml:"addr"`
+	ReadTimeout  Duration `json:"readTimeout" yaml:"readTimeout"`
+	WriteTimeout Duration `json:"writeTimeout" yaml:"writeTimeout"`
+	MaxBodyBytes int64    `json:"maxBodyBytes" yaml:"maxBodyBytes"`
+}
+
+type Storage struct {
+	//One of mongo, hana or memory.
+	Backend string `json:"backend" yaml:"backend"`
+}
+
+type Mongo struct {
+	URI                    string   `json:"uri" yaml:"uri"`
+	Database               string   `json:"database" yaml:"database"`
+	MaxPoolSize            uint64   `json:"maxPoolSize" yaml:"maxPoolSize"`
+	MinPoolSize            uint64   `json:"minPoolSize" yaml:"minPoolSize"`
+	MaxConnIdleTime        Duration `json:"maxConnIdleTime" yaml:"maxConnIdleTime"`
+	ConnectTimeout         Duration `json:"connectTimeout" yaml:"connectTimeout"`
+	ServerSelectionTimeout Duration `json:"serverSelectionTimeout" yaml:"serverSelectionTimeout"`
+	OperationTimeout       Duration `json:"operationTimeout" yaml:"operationTimeout"`
+}
+
+type Hana struct {
+	DSN string `json:"dsn" yaml:"dsn"`
+}
+
+type Cache struct {
+	//How often the stores are read again when they cannot push their changes.
+	PollInterval Duration `json:"pollInterval" yaml:"pollInterval"`
+}
+
+//Pipeline lists the hiring stages an Application goes through.
+type Pipeline struct {
+	//Stages in the order they are shown, new applications start on the first one.
+	Stages []string `json:"stages" yaml:"stages"`
+	//Stages each stage may move to, stages without transitions are final.
+	Transitions map[string][]string `json:"transitions" yaml:"transitions"`
+}
+
+type Offers struct {
+	//How often the offers past their expiry are moved to Expired.
+	SweepInterval Duration `json:"sweepInterval" yaml:"sweepInterval"`
+}
+
+type Requisitions struct {
+	//How often the requisitions are checked for a PostAt or UnpostAt that has passed.
+	ScheduleInterval Duration `json:"scheduleInterval" yaml:"scheduleInterval"`
+}
+
+//Approvals lists the steps a job requisition is approved on before it can be posted.
+type Approvals struct {
+	//Chain of the countries without a chain of their own, no approval is required when empty.
+	Default []ApprovalStep `json:"default" yaml:"default"`
+	//Chains by ISO 3166-1 alpha-2 code of the country of the requisition.
+	Countries map[string][]ApprovalStep `json:"countries" yaml:"countries"`
+}
+
+type ApprovalStep struct {
+	Name string `json:"name" yaml:"name"`
+	//Emails of who may approve the step, anyone may when empty.
+	Approvers []string `json:"approvers" yaml:"approvers"`
+}
+
+//Attachments configures where the files uploaded for the candidates are kept and which ones are accepted.
+type Attachments struct {
+	//One of filesystem or gridfs, gridfs requires storage.backend mongo.
+	Backend string `json:"backend" yaml:"backend"`
+	//Directory the filesystem backend keeps the files in.
+	Directory string `json:"directory" yaml:"directory"`
+	//Largest file accepted on an upload.
+	MaxBytes int64 `json:"maxBytes" yaml:"maxBytes"`
+	//MIME types accepted, as sniffed from the content of the file.
+	AllowedTypes []string `json:"allowedTypes" yaml:"allowedTypes"`
+}
+
+//Duration is a time.Duration read from strings such as "10s" or "5m".
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return d.set(s)
+}
+
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	return d.set(value.Value)
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) set(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("Invalid duration %q", s)
+	}
+	d.Duration = v
+	return nil
+}
+
+//Default returns the configuration used when nothing else is provided.
+func Default() Config {
+	return Config{
+		Server: Server{
+			Addr:         ":3000",
+			ReadTimeout:  Duration{15 * time.Second},
+			WriteTimeout: Duration{15 * time.Second},
+			MaxBodyBytes: 1 << 20,
+		},
+		Storage: Storage{
+			Backend: "mongo",
+		},
+		Mongo: Mongo{
+			Database:               "myFirstDatabase",
+			MaxPoolSize:            100,
+			MinPoolSize:            5,
+			MaxConnIdleTime:        Duration{5 * time.Minute},
+			ConnectTimeout:         Duration{10 * time.Second},
+			ServerSelectionTimeout: Duration{10 * time.Second},
+			OperationTimeout:       Duration{5 * time.Second},
+		},
+		Cache: Cache{
+			PollInterval: Duration{30 * time.Second},
+		},
+		Pipeline: Pipeline{
+			Stages: []string{"Applied", "Screening", "Interview", "Offer", "Hired", "Rejected"},
+			Transitions: map[string][]string{
+				"Applied":   {"Screening", "Rejected"},
+				"Screening": {"Interview", "Rejected"},
+				"Interview": {"Offer", "Rejected"},
+				"Offer":     {"Hired", "Rejected"},
+			},
+		},
+		Offers: Offers{
+			SweepInterval: Duration{time.Minute},
+		},
+		Requisitions: Requisitions{
+			ScheduleInterval: Duration{time.Minute},
+		},
+		Attachments: Attachments{
+			Backend:   "filesystem",
+			Directory: "attachments",
+			MaxBytes:  10 << 20,
+			AllowedTypes: []string{
+				"application/pdf",
+				"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+				"application/msword",
+				"text/plain",
+				"image/png",
+				"image/jpeg",
+				"application/zip",
+			},
+		},
+	}
+}
+
+//Load reads the configuration file at path, when path is not empty, and then applies the environment variables.
+//Returns the validated configuration or an error describing every invalid setting.
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := loadEnv(&cfg); err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+//Reads a YAML or JSON file, picked by its extension, on top of the values already in cfg.
+func loadFile(path string, cfg *Config) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Could not read config file: %v", err)
+	}
+
+	var unmarshal func([]byte, interface{}) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		unmarshal = yaml.Unmarshal
+	case ".json":
+		unmarshal = json.Unmarshal
+	default:
+		return fmt.Errorf("Unsupported config file %q, use .yaml, .yml or .json", path)
+	}
+
+	//The decoders merge maps into the defaults, a pipeline on the file must replace the default one instead
+	var file struct {
+		Pipeline *Pipeline `json:"pipeline" yaml:"pipeline"`
+	}
+	if err = unmarshal(b, cfg); err == nil {
+		err = unmarshal(b, &file)
+	}
+	if err != nil {
+		return fmt.Errorf("Could not parse config file %q: %v", path, err)
+	}
+	if file.Pipeline != nil {
+		cfg.Pipeline = *file.Pipeline
+	}
+	return nil
+}
+
+//Overrides the values in cfg with the environment variables that are set.
+func loadEnv(cfg *Config) error {
+	var errs []string
+	str := func(name string, dst *string) {
+		if v, ok := os.LookupEnv(name); ok {
+			*dst = v
+		}
+	}
+	integer := func(name string, dst *int64) {
+		if v, ok := os.LookupEnv(name); ok {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %q is not a number", name, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	unsigned := func(name string, dst *uint64) {
+		if v, ok := os.LookupEnv(name); ok {
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %q is not a positive number", name, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	duration := func(name string, dst *Duration) {
+		if v, ok := os.LookupEnv(name); ok {
+			if err := dst.set(v); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			}
+		}
+	}
+
+	str("SERVER_ADDR", &cfg.Server.Addr)
+	duration("SERVER_READ_TIMEOUT", &cfg.Server.ReadTimeout)
+	duration("SERVER_WRITE_TIMEOUT", &cfg.Server.WriteTimeout)
+	integer("SERVER_MAX_BODY_BYTES", &cfg.Server.MaxBodyBytes)
+
+	str("STORAGE_BACKEND", &cfg.Storage.Backend)
+
+	str("MONGO_URI", &cfg.Mongo.URI)
+	str("MONGO_DATABASE", &cfg.Mongo.Database)
+	unsigned("MONGO_MAX_POOL_SIZE", &cfg.Mongo.MaxPoolSize)
+	unsigned("MONGO_MIN_POOL_SIZE", &cfg.Mongo.MinPoolSize)
+	duration("MONGO_MAX_CONN_IDLE_TIME", &cfg.Mongo.MaxConnIdleTime)
+	duration("MONGO_CONNECT_TIMEOUT", &cfg.Mongo.ConnectTimeout)
+	duration("MONGO_SERVER_SELECTION_TIMEOUT", &cfg.Mongo.ServerSelectionTimeout)
+	duration("MONGO_OPERATION_TIMEOUT", &cfg.Mongo.OperationTimeout)
+
+	str("HANA_DSN", &cfg.Hana.DSN)
+
+	duration("CACHE_POLL_INTERVAL", &cfg.Cache.PollInterval)
+
+	duration("OFFER_SWEEP_INTERVAL", &cfg.Offers.SweepInterval)
+
+	duration("REQUISITION_SCHEDULE_INTERVAL", &cfg.Requisitions.ScheduleInterval)
+
+	str("ATTACHMENT_BACKEND", &cfg.Attachments.Backend)
+	str("ATTACHMENT_DIRECTORY", &cfg.Attachments.Directory)
+	integer("ATTACHMENT_MAX_BYTES", &cfg.Attachments.MaxBytes)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("Invalid environment: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+//Validate checks that the settings are consistent with each other.
+//Returns an error listing every problem found.
+func (c Config) Validate() error {
+	var errs []string
+
+	if c.Server.Addr == "" {
+		errs = append(errs, "server.addr must not be empty")
+	}
+	if c.Server.ReadTimeout.Duration <= 0 || c.Server.WriteTimeout.Duration <= 0 {
+		errs = append(errs, "server timeouts must be positive")
+	}
+	if c.Server.MaxBodyBytes <= 0 {
+		errs = append(errs, "server.maxBodyBytes must be positive")
+	}
+
+	switch c.Storage.Backend {
+	case "mongo":
+		if c.Mongo.URI == "" {
+			errs = append(errs, "mongo.uri is required when storage.backend is mongo")
+		}
+		if c.Mongo.Database == "" {
+			errs = append(errs, "mongo.database is required when storage.backend is mongo")
+		}
+		if c.Mongo.MaxPoolSize == 0 || c.Mongo.MinPoolSize > c.Mongo.MaxPoolSize {
+			errs = append(errs, "mongo.minPoolSize must not exceed mongo.maxPoolSize, which must be positive")
+		}
+		if c.Mongo.ConnectTimeout.Duration <= 0 || c.Mongo.ServerSelectionTimeout.Duration <= 0 || c.Mongo.OperationTimeout.Duration <= 0 {
+			errs = append(errs, "mongo timeouts must be positive")
+		}
+	case "hana":
+		if c.Hana.DSN == "" {
+			errs = append(errs, "hana.dsn is required when storage.backend is hana")
+		}
+	case "memory":
+	default:
+		errs = append(errs, fmt.Sprintf("storage.backend %q is not one of mongo, hana or memory", c.Storage.Backend))
+	}
+
+	if c.Cache.PollInterval.Duration <= 0 {
+		errs = append(errs, "cache.pollInterval must be positive")
+	}
+
+	errs = append(errs, c.Pipeline.validate()...)
+
+	if c.Offers.SweepInterval.Duration <= 0 {
+		errs = append(errs, "offers.sweepInterval must be positive")
+	}
+
+	if c.Requisitions.ScheduleInterval.Duration <= 0 {
+		errs = append(errs, "requisitions.scheduleInterval must be positive")
+	}
+
+	errs = append(errs, c.Approvals.validate()...)
+
+	switch c.Attachments.Backend {
+	case "filesystem":
+		if c.Attachments.Directory == "" {
+			errs = append(errs, "attachments.directory is required when attachments.backend is filesystem")
+		}
+	case "gridfs":
+		if c.Storage.Backend != "mongo" {
+			errs = append(errs, "attachments.backend gridfs requires storage.backend mongo")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("attachments.backend %q is not one of filesystem or gridfs", c.Attachments.Backend))
+	}
+	if c.Attachments.MaxBytes <= 0 {
+		errs = append(errs, "attachments.maxBytes must be positive")
+	}
+	if len(c.Attachments.AllowedTypes) == 0 {
+		errs = append(errs, "attachments.allowedTypes must not be empty")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("Invalid configuration: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+//Checks that the countries are keyed by their ISO 3166-1 alpha-2 code and that the steps of every chain are named uniquely.
+func (a Approvals) validate() []string {
+	errs := validateChain("approvals.default", a.Default)
+	for code, chain := range a.Countries {
+		if !validate.IsCountryCode(code) {
+			errs = append(errs, fmt.Sprintf("approvals.countries %q is not an ISO 3166-1 alpha-2 country code", code))
+		}
+		errs = append(errs, validateChain("approvals.countries."+code, chain)...)
+	}
+	return errs
+}
+
+//Checks that the steps of the chain have a name, used by no other step of the chain.
+func validateChain(name string, chain []ApprovalStep) []string {
+	var errs []string
+	known := make(map[string]bool)
+	for _, s := range chain {
+		if s.Name == "" || known[s.Name] {
+			errs = append(errs, fmt.Sprintf("%v step %q must be unique and not empty", name, s.Name))
+		}
+		known[s.Name] = true
+	}
+	return errs
+}
+
+//Checks that the stages listed are unique and that the transitions only mention them.
+func (p Pipeline) validate() []string {
+	var errs []string
+	if len(p.Stages) == 0 {
+		errs = append(errs, "pipeline.stages must not be empty")
+	}
+
+	known := make(map[string]bool)
+	for _, s := range p.Stages {
+		if s == "" || known[s] {
+			errs = append(errs, fmt.Sprintf("pipeline.stages %q must be unique and not empty", s))
+		}
+		known[s] = true
+	}
+
+	for from, to := range p.Transitions {
+		if !known[from] {
+			errs = append(errs, fmt.Sprintf("pipeline.transitions %q is not one of pipeline.stages", from))
+		}
+		for _, s := range to {
+			if !known[s] {
+				errs = append(errs, fmt.Sprintf("pipeline.transitions %q -> %q is not one of pipeline.stages", from, s))
+			}
+		}
+	}
+	return errs
+}
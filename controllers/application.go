@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 	"regexp"
 	"strconv"
@@ -10,11 +11,13 @@ import (
 
 type applicationController struct {
 	applicationIDPattern *regexp.Regexp
+	interviews           interviewController
+	offers               offerController
 }
 
 func newApplicationController() *applicationController {
 	return &applicationController{
-		applicationIDPattern: regexp.MustCompile(`^/application/(\d+)/?`),
+		applicationIDPattern: regexp.MustCompile(`^/application/(\d+)(?:/(\w+)(?:/([^/]+)(?:/(\w+))?)?)?/?$`),
 	}
 }
 
@@ -26,33 +29,74 @@ func (a applicationController) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		case http.MethodPost:
 			a.post(w, r)
 		default:
-			w.WriteHeader(http.StatusNotImplemented)
+			writeMethodNotAllowed(w, r, http.MethodGet, http.MethodPost)
 		}
 	} else {
 		matches := a.applicationIDPattern.FindStringSubmatch(r.URL.Path)
 		if len(matches) == 0 {
-			w.WriteHeader(http.StatusNotFound)
+			writeNotFound(w, r)
+			return
 		}
 		id, err := strconv.Atoi(matches[1])
 		if err != nil {
-			w.WriteHeader(http.StatusNotFound)
+			writeNotFound(w, r)
+			return
+		}
+
+		switch matches[2] {
+		case "":
+		case "interviews":
+			if matches[4] != "" {
+				writeNotFound(w, r)
+				return
+			}
+			a.interviews.serve(id, matches[3], w, r)
+			return
+		case "offers":
+			a.offers.serve(id, matches[3], matches[4], w, r)
+			return
+		case "transition":
+			if matches[3] != "" {
+				writeNotFound(w, r)
+				return
+			}
+			if r.Method != http.MethodPost {
+				writeMethodNotAllowed(w, r, http.MethodPost)
+				return
+			}
+			a.transition(id, w, r)
+			return
+		case "withdraw":
+			if matches[3] != "" {
+				writeNotFound(w, r)
+				return
+			}
+			if r.Method != http.MethodPost {
+				writeMethodNotAllowed(w, r, http.MethodPost)
+				return
+			}
+			a.withdraw(id, w, r)
+			return
+		default:
+			writeNotFound(w, r)
+			return
 		}
 
 		switch r.Method {
 		case http.MethodGet:
-			a.get(id, w)
+			a.get(id, w, r)
 		case http.MethodPut:
 			a.put(id, w, r)
 		case http.MethodDelete:
-			a.delete(id, w)
+			a.delete(id, w, r)
 		default:
-			w.WriteHeader(http.StatusNotImplemented)
+			writeMethodNotAllowed(w, r, http.MethodGet, http.MethodPut, http.MethodDelete)
 		}
 	}
 }
 
-func (a applicationController) parseRequest(r *http.Request) (models.Application, error) {
-	dec := json.NewDecoder(r.Body)
+func (a applicationController) parseRequest(w http.ResponseWriter, r *http.Request) (models.Application, error) {
+	dec := json.NewDecoder(requestBody(w, r))
 	var app models.Application
 	err := dec.Decode(&app)
 	if err != nil {
@@ -62,30 +106,28 @@ func (a applicationController) parseRequest(r *http.Request) (models.Application
 }
 
 func (a applicationController) getAll(w http.ResponseWriter, r *http.Request) {
-	encodeResponseAsJSON(models.GetApplications(), w)
+	encodePageAsJSON(models.GetApplications(), w, r)
 }
 
-func (a applicationController) get(id int, w http.ResponseWriter) {
+func (a applicationController) get(id int, w http.ResponseWriter, r *http.Request) {
 	app, err := models.GetApplicationByID(id)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 	encodeResponseAsJSON(app, w)
 }
 
 func (a applicationController) post(w http.ResponseWriter, r *http.Request) {
-	app, err := a.parseRequest(r)
+	app, err := a.parseRequest(w, r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("Could not parse application object"))
+		writeParseError(w, r, "application", err)
 		return
 	}
 
-	app, err = models.AddApplication(app)
+	app, err = models.AddApplication(r.Context(), app)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		writeError(w, r, err)
 		return
 	}
 
@@ -93,35 +135,72 @@ func (a applicationController) post(w http.ResponseWriter, r *http.Request) {
 }
 
 func (a applicationController) put(id int, w http.ResponseWriter, r *http.Request) {
-	app, err := a.parseRequest(r)
+	app, err := a.parseRequest(w, r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("Could not parse application object"))
+		writeParseError(w, r, "application", err)
 		return
 	}
 
 	if id != app.ID {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("ID of submitted applicant must match ID in the URL"))
+		writeBadRequest(w, r, "ID of submitted applicant must match ID in the URL")
 		return
 	}
 
-	app, err = models.UpdateApplication(app)
+	app, err = models.UpdateApplication(r.Context(), app)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		writeError(w, r, err)
 		return
 	}
 	encodeResponseAsJSON(app, w)
 	//w.WriteHeader(http.StatusNotImplemented)
 }
 
-func (a applicationController) delete(id int, w http.ResponseWriter) {
-	err := models.DeleteApplication(id)
+func (a applicationController) delete(id int, w http.ResponseWriter, r *http.Request) {
+	err := models.DeleteApplication(r.Context(), id)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		writeError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
 }
+
+//Body of POST /application/{id}/transition, POST /application/{id}/withdraw and POST /jobrequisition/{id}/transition.
+type transitionRequest struct {
+	//Stage of the Application or State of the JobRequisition to move to
+	Stage   string
+	State   string
+	By      string
+	Comment string
+}
+
+func (a applicationController) transition(id int, w http.ResponseWriter, r *http.Request) {
+	var t transitionRequest
+	if err := json.NewDecoder(requestBody(w, r)).Decode(&t); err != nil {
+		writeParseError(w, r, "transition", err)
+		return
+	}
+
+	app, err := models.TransitionApplication(r.Context(), id, t.Stage, t.By, t.Comment)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodeResponseAsJSON(app, w)
+}
+
+//Withdraws the application on behalf of the candidate, the Comment is kept as the reason.
+//The body may be empty, By then defaults to the email of the candidate.
+func (a applicationController) withdraw(id int, w http.ResponseWriter, r *http.Request) {
+	var t transitionRequest
+	if err := json.NewDecoder(requestBody(w, r)).Decode(&t); err != nil && err != io.EOF {
+		writeParseError(w, r, "withdrawal", err)
+		return
+	}
+
+	app, err := models.WithdrawApplication(r.Context(), id, t.By, t.Comment)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodeResponseAsJSON(app, w)
+}
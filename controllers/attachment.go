@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"webservice/models"
+)
+
+//Serves the files of a Candidate, under /candidate/{id}/attachments.
+//Files are uploaded as multipart/form-data with the content on the file field and, optionally, the Kind and UploadedBy fields.
+type attachmentController struct{}
+
+func (ac attachmentController) serve(candidateID int, rest string, w http.ResponseWriter, r *http.Request) {
+	if rest == "" {
+		switch r.Method {
+		case http.MethodGet:
+			ac.getAll(candidateID, w, r)
+		case http.MethodPost:
+			ac.post(candidateID, w, r)
+		default:
+			writeMethodNotAllowed(w, r, http.MethodGet, http.MethodPost)
+		}
+		return
+	}
+
+	id, err := strconv.Atoi(rest)
+	if err != nil {
+		writeNotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		ac.download(candidateID, id, w, r)
+	case http.MethodDelete:
+		ac.delete(candidateID, id, w, r)
+	default:
+		writeMethodNotAllowed(w, r, http.MethodGet, http.MethodDelete)
+	}
+}
+
+func (ac attachmentController) getAll(candidateID int, w http.ResponseWriter, r *http.Request) {
+	attachments, err := models.GetAttachmentsOfCandidate(candidateID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodePageAsJSON(attachments, w, r)
+}
+
+//Writes the content of the attachment, with the type sniffed on the upload.
+func (ac attachmentController) download(candidateID int, id int, w http.ResponseWriter, r *http.Request) {
+	a, content, err := models.OpenAttachment(r.Context(), candidateID, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	defer content.Close()
+
+	etag := `"` + a.Checksum + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.FileName}))
+	//The browser must not guess another type from the content
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	io.Copy(w, content)
+}
+
+func (ac attachmentController) post(candidateID int, w http.ResponseWriter, r *http.Request) {
+	maxBytes := models.MaxAttachmentBytes()
+	//The other fields and the multipart boundaries are allowed the size of a regular body
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+settings.Server.MaxBodyBytes)
+
+	parts, err := r.MultipartReader()
+	if err != nil {
+		writeBadRequest(w, r, "Attachment must be uploaded as multipart/form-data")
+		return
+	}
+
+	a := models.Attachment{CandidateID: candidateID}
+	var content []byte
+	uploaded := false
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeParseError(w, r, "Attachment", err)
+			return
+		}
+
+		switch part.FormName() {
+		case "file":
+			a.FileName = part.FileName()
+			content, err = ioutil.ReadAll(io.LimitReader(part, maxBytes+1))
+			uploaded = true
+		case "Kind":
+			a.Kind, err = readField(part)
+		case "UploadedBy":
+			a.UploadedBy, err = readField(part)
+		}
+		part.Close()
+		if err != nil {
+			writeParseError(w, r, "Attachment", err)
+			return
+		}
+	}
+
+	if !uploaded {
+		writeBadRequest(w, r, "Attachment must be sent on the file field")
+		return
+	}
+	if int64(len(content)) > maxBytes {
+		writeProblem(w, r, Problem{Type: problemTooLarge, Status: http.StatusRequestEntityTooLarge, Detail: fmt.Sprintf("Attachment exceeds the %v bytes allowed", maxBytes)})
+		return
+	}
+
+	a, err = models.AddAttachment(r.Context(), a, content)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodeResponseAsJSON(a, w)
+}
+
+func (ac attachmentController) delete(candidateID int, id int, w http.ResponseWriter, r *http.Request) {
+	err := models.DeleteAttachment(r.Context(), candidateID, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+//Reads a text field of a multipart form, which is limited to the size of a regular body.
+func readField(part io.Reader) (string, error) {
+	b, err := ioutil.ReadAll(io.LimitReader(part, settings.Server.MaxBodyBytes))
+	return string(b), err
+}
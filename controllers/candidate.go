@@ -2,120 +2,263 @@ package controllers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"webservice/models"
 )
 
 type candidateController struct {
 	candidateIDPattern *regexp.Regexp
+	attachments        attachmentController
 }
 
 func newCandidateController() *candidateController {
 	return &candidateController{
-		candidateIDPattern: regexp.MustCompile(`^/candidate/(\d+)/?`),
+		candidateIDPattern: regexp.MustCompile(`^/candidate/(\d+)(?:/(\w+)(?:/([^/]+))?)?/?$`),
 	}
 }
 
 func (c candidateController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path == "/candidate" {
+	switch strings.TrimSuffix(r.URL.Path, "/") {
+	case "/candidate":
 		switch r.Method {
 		case http.MethodGet:
 			c.getAll(w, r)
 		case http.MethodPost:
 			c.post(w, r)
 		default:
-			w.WriteHeader(http.StatusNotImplemented)
+			writeMethodNotAllowed(w, r, http.MethodGet, http.MethodPost)
 		}
-	} else {
+	case "/candidate/duplicates":
+		if r.Method != http.MethodGet {
+			writeMethodNotAllowed(w, r, http.MethodGet)
+			return
+		}
+		encodePageAsJSON(models.GetDuplicates(), w, r)
+	case "/candidate/merges":
+		if r.Method != http.MethodGet {
+			writeMethodNotAllowed(w, r, http.MethodGet)
+			return
+		}
+		encodePageAsJSON(models.GetCandidateMerges(), w, r)
+	case "/candidate/suggest":
+		if r.Method != http.MethodGet {
+			writeMethodNotAllowed(w, r, http.MethodGet)
+			return
+		}
+		q, limit, err := parseSuggestQuery(r)
+		if err != nil {
+			writeBadRequest(w, r, err.Error())
+			return
+		}
+		encodeResponseAsJSON(models.SuggestCandidates(q, limit), w)
+	case "/candidate/search":
+		if r.Method != http.MethodGet {
+			writeMethodNotAllowed(w, r, http.MethodGet)
+			return
+		}
+		c.search(w, r)
+	case "/candidate/merge":
+		if r.Method != http.MethodPost {
+			writeMethodNotAllowed(w, r, http.MethodPost)
+			return
+		}
+		c.merge(w, r)
+	default:
 		matches := c.candidateIDPattern.FindStringSubmatch(r.URL.Path)
 		if len(matches) == 0 {
-			w.WriteHeader(http.StatusNotFound)
+			writeNotFound(w, r)
+			return
 		}
 		id, err := strconv.Atoi(matches[1])
 		if err != nil {
-			w.WriteHeader(http.StatusNotFound)
+			writeNotFound(w, r)
+			return
+		}
+
+		switch matches[2] {
+		case "":
+		case "attachments":
+			c.attachments.serve(id, matches[3], w, r)
+			return
+		case "suggestions":
+			c.suggestions(id, matches[3], w, r)
+			return
+		default:
+			writeNotFound(w, r)
+			return
 		}
 
 		switch r.Method {
 		case http.MethodGet:
-			c.get(id, w)
+			c.get(id, w, r)
 		case http.MethodPut:
 			c.put(id, w, r)
 		case http.MethodDelete:
-			c.delete(id, w)
+			c.delete(id, w, r)
 		default:
-			w.WriteHeader(http.StatusNotImplemented)
+			writeMethodNotAllowed(w, r, http.MethodGet, http.MethodPut, http.MethodDelete)
 		}
 	}
 }
 
 func (c candidateController) getAll(w http.ResponseWriter, r *http.Request) {
-	encodeResponseAsJSON(models.GetCandidates(), w)
+	encodePageAsJSON(models.GetCandidates(), w, r)
 }
 
-func (c candidateController) get(id int, w http.ResponseWriter) {
+func (c candidateController) get(id int, w http.ResponseWriter, r *http.Request) {
 	can, err := models.GetCandidateByID(id)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 	encodeResponseAsJSON(can, w)
 }
 
 func (c candidateController) post(w http.ResponseWriter, r *http.Request) {
-	can, err := c.parseRequest(r)
+	can, err := c.parseRequest(w, r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("Could not parse Candidate object"))
+		writeParseError(w, r, "Candidate", err)
 		return
 	}
-	can, err = models.AddCandidate(can)
+	allowSimilar, err := parseAllowSimilar(r)
+	if err != nil {
+		writeBadRequest(w, r, err.Error())
+		return
+	}
+
+	can, err = models.AddCandidate(r.Context(), can, allowSimilar)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		writeError(w, r, err)
 		return
 	}
 	encodeResponseAsJSON(can, w)
 }
 
 func (c candidateController) put(id int, w http.ResponseWriter, r *http.Request) {
-	can, err := c.parseRequest(r)
+	can, err := c.parseRequest(w, r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("Could not parse Candidate object"))
+		writeParseError(w, r, "Candidate", err)
 		return
 	}
 
 	if id != can.ID {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("ID of submitted user must match ID in URL"))
+		writeBadRequest(w, r, "ID of submitted user must match ID in URL")
 		return
 	}
 
-	can, err = models.UpdateCandidate(can)
+	allowSimilar, err := parseAllowSimilar(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		writeBadRequest(w, r, err.Error())
+		return
+	}
+
+	can, err = models.UpdateCandidate(r.Context(), can, allowSimilar)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 	encodeResponseAsJSON(can, w)
 }
 
-func (c candidateController) delete(id int, w http.ResponseWriter) {
-	err := models.DeleteCandidate(id)
+//Serves GET /candidate/search?q=, the Candidates matching the query ranked by relevance unless another sort is requested.
+func (c candidateController) search(w http.ResponseWriter, r *http.Request) {
+	hits, err := models.SearchCandidates(r.URL.Query().Get("q"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodePageAsJSON(hits, w, r)
+}
+
+//Body of POST /candidate/merge, the source Candidate is merged into the target one.
+type mergeRequest struct {
+	TargetID int
+	SourceID int
+	By       string
+}
+
+func (c candidateController) merge(w http.ResponseWriter, r *http.Request) {
+	var m mergeRequest
+	if err := json.NewDecoder(requestBody(w, r)).Decode(&m); err != nil {
+		writeParseError(w, r, "merge", err)
+		return
+	}
+
+	can, err := models.MergeCandidates(r.Context(), m.TargetID, m.SourceID, m.By)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodeResponseAsJSON(can, w)
+}
+
+//Body of POST /candidate/{id}/suggestions, the labels of the suggested Tags to add to the Candidate.
+type acceptTagsRequest struct {
+	Labels []string
+}
+
+//Serves the Tags suggested from the resumes of the Candidate on GET, and adds the ones accepted on POST.
+func (c candidateController) suggestions(id int, rest string, w http.ResponseWriter, r *http.Request) {
+	if rest != "" {
+		writeNotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s, err := models.GetTagSuggestions(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		encodeResponseAsJSON(s, w)
+	case http.MethodPost:
+		var a acceptTagsRequest
+		if err := json.NewDecoder(requestBody(w, r)).Decode(&a); err != nil {
+			writeParseError(w, r, "suggested Tags", err)
+			return
+		}
+
+		can, err := models.AcceptSuggestedTags(r.Context(), id, a.Labels)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		encodeResponseAsJSON(can, w)
+	default:
+		writeMethodNotAllowed(w, r, http.MethodGet, http.MethodPost)
+	}
+}
+
+//Reads ?allowSimilar=true, which creates or updates a Candidate even if another one has a similar name and address.
+func parseAllowSimilar(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("allowSimilar")
+	if v == "" {
+		return false, nil
+	}
+	allow, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("Invalid allowSimilar %q, must be true or false", v)
+	}
+	return allow, nil
+}
+
+func (c candidateController) delete(id int, w http.ResponseWriter, r *http.Request) {
+	err := models.DeleteCandidate(r.Context(), id)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		writeError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
 }
 
-func (c candidateController) parseRequest(r *http.Request) (models.Candidate, error) {
-	dec := json.NewDecoder(r.Body)
+func (c candidateController) parseRequest(w http.ResponseWriter, r *http.Request) (models.Candidate, error) {
+	dec := json.NewDecoder(requestBody(w, r))
 	var can models.Candidate
 	err := dec.Decode(&can)
 	if err != nil {
@@ -27,96 +27,92 @@ func (cntC countryController) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		case http.MethodPost:
 			cntC.post(w, r)
 		default:
-			w.WriteHeader(http.StatusNotImplemented)
+			writeMethodNotAllowed(w, r, http.MethodGet, http.MethodPost)
 		}
 	} else {
 		matches := cntC.countryIDPattern.FindStringSubmatch(r.URL.Path)
 		if len(matches) == 0 {
-			w.WriteHeader(http.StatusNotFound)
+			writeNotFound(w, r)
+			return
 		}
 		id, err := strconv.Atoi(matches[1])
 		if err != nil {
-			w.WriteHeader(http.StatusNotFound)
+			writeNotFound(w, r)
+			return
 		}
 
 		switch r.Method {
 		case http.MethodGet:
-			cntC.get(id, w)
+			cntC.get(id, w, r)
 		case http.MethodPut:
 			cntC.put(id, w, r)
 		case http.MethodDelete:
-			cntC.delete(id, w)
+			cntC.delete(id, w, r)
 		default:
-			w.WriteHeader(http.StatusNotImplemented)
+			writeMethodNotAllowed(w, r, http.MethodGet, http.MethodPut, http.MethodDelete)
 		}
 	}
 }
 
 func (cntC countryController) getAll(w http.ResponseWriter, r *http.Request) {
-	encodeResponseAsJSON(models.GetCountries(), w)
+	encodePageAsJSON(models.GetCountries(), w, r)
 }
 
-func (cntC countryController) get(id int, w http.ResponseWriter) {
+func (cntC countryController) get(id int, w http.ResponseWriter, r *http.Request) {
 	c, err := models.GetCountryByID(id)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 	encodeResponseAsJSON(c, w)
 }
 
 func (cntC countryController) post(w http.ResponseWriter, r *http.Request) {
-	c, err := cntC.parseRequest(r)
+	c, err := cntC.parseRequest(w, r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("Could not parse Country object"))
+		writeParseError(w, r, "Country", err)
 		return
 	}
 
-	c, err = models.AddCountry(c)
+	c, err = models.AddCountry(r.Context(), c)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		writeError(w, r, err)
 		return
 	}
 	encodeResponseAsJSON(c, w)
 }
 
 func (cntC countryController) put(id int, w http.ResponseWriter, r *http.Request) {
-	c, err := cntC.parseRequest(r)
+	c, err := cntC.parseRequest(w, r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("Could not parse Country object"))
+		writeParseError(w, r, "Country", err)
 		return
 	}
 
 	if id != c.ID {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("ID of submitted user must match ID in URL"))
+		writeBadRequest(w, r, "ID of submitted user must match ID in URL")
 		return
 	}
 
-	c, err = models.UpdateCountry(c)
+	c, err = models.UpdateCountry(r.Context(), c)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		writeError(w, r, err)
 		return
 	}
 	encodeResponseAsJSON(c, w)
 }
 
-func (cntC countryController) delete(id int, w http.ResponseWriter) {
-	err := models.RemoveCountryByID(id)
+func (cntC countryController) delete(id int, w http.ResponseWriter, r *http.Request) {
+	err := models.RemoveCountryByID(r.Context(), id)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		writeError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
 }
 
-func (cntC countryController) parseRequest(r *http.Request) (models.Country, error) {
-	dec := json.NewDecoder(r.Body)
+func (cntC countryController) parseRequest(w http.ResponseWriter, r *http.Request) (models.Country, error) {
+	dec := json.NewDecoder(requestBody(w, r))
 	var c models.Country
 	err := dec.Decode(&c)
 	if err != nil {
@@ -2,16 +2,27 @@ package controllers
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"webservice/config"
+	"webservice/query"
 )
 
-func RegisterControllers() {
+//Settings the controllers were registered with.
+var settings config.Config
+
+func RegisterControllers(cfg config.Config) {
+	settings = cfg
+
 	c := newCandidateController()
 	cntC := newCountryController()
 	jr := newJobRequisitionController()
 	jrp := newJobReqPostedController()
 	a := newApplicationController()
+	iv := newInterviewerController()
+	t := newTagController()
 
 	//Candidate controller
 	http.Handle("/candidate", *c)
@@ -32,6 +43,59 @@ func RegisterControllers() {
 	//Application Controller
 	http.Handle("/application", a)
 	http.Handle("/application/", a)
+
+	//Interviewer Controller
+	http.Handle("/interviewer/", *iv)
+
+	//Tag Controller
+	http.Handle("/tag", *t)
+	http.Handle("/tag/", *t)
+}
+
+//Returns the body of the request limited to the size configured on Server.MaxBodyBytes.
+//Reading past the limit fails with the error writeParseError reports as too large,
+//and w is told to close the connection rather than read the rest of the body.
+func requestBody(w http.ResponseWriter, r *http.Request) io.Reader {
+	return http.MaxBytesReader(w, r.Body, settings.Server.MaxBodyBytes)
+}
+
+//Filters, sorts and paginates the items as requested on the query string and writes the resulting page.
+func encodePageAsJSON(items interface{}, w http.ResponseWriter, r *http.Request) {
+	q, err := query.Parse(r.URL.Query())
+	if err != nil {
+		writeBadRequest(w, r, err.Error())
+		return
+	}
+
+	page, err := q.Apply(items, r.URL)
+	if err != nil {
+		writeBadRequest(w, r, err.Error())
+		return
+	}
+	encodeResponseAsJSON(page, w)
+}
+
+//Number of suggestions returned by the autocomplete endpoints when ?limit is not sent, and the most they return.
+const (
+	defaultSuggestions = 10
+	maxSuggestions     = 50
+)
+
+//Reads ?q, the text typed on a search box, and ?limit, the number of suggestions wanted.
+func parseSuggestQuery(r *http.Request) (string, int, error) {
+	values := r.URL.Query()
+	limit := defaultSuggestions
+	if v := values.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return "", 0, fmt.Errorf("Limit must be a positive number")
+		}
+		if n > maxSuggestions {
+			n = maxSuggestions
+		}
+		limit = n
+	}
+	return values.Get("q"), limit, nil
 }
 
 func encodeResponseAsJSON(data interface{}, w io.Writer) {
@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"webservice/ics"
+	"webservice/models"
+)
+
+//Serves the interviews of an Application, under /application/{id}/interviews.
+type interviewController struct{}
+
+func (ic interviewController) serve(applicationID int, rest string, w http.ResponseWriter, r *http.Request) {
+	if rest == "" {
+		switch r.Method {
+		case http.MethodGet:
+			ic.getAll(applicationID, w, r)
+		case http.MethodPost:
+			ic.post(applicationID, w, r)
+		default:
+			writeMethodNotAllowed(w, r, http.MethodGet, http.MethodPost)
+		}
+		return
+	}
+
+	//The calendar of a single interview is served on /application/{id}/interviews/{interviewID}.ics
+	calendar := strings.HasSuffix(rest, ".ics")
+	id, err := strconv.Atoi(strings.TrimSuffix(rest, ".ics"))
+	if err != nil {
+		writeNotFound(w, r)
+		return
+	}
+
+	if calendar {
+		if r.Method != http.MethodGet {
+			writeMethodNotAllowed(w, r, http.MethodGet)
+			return
+		}
+		ic.getCalendar(applicationID, id, w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		ic.get(applicationID, id, w, r)
+	case http.MethodPut:
+		ic.put(applicationID, id, w, r)
+	case http.MethodDelete:
+		ic.delete(applicationID, id, w, r)
+	default:
+		writeMethodNotAllowed(w, r, http.MethodGet, http.MethodPut, http.MethodDelete)
+	}
+}
+
+func (ic interviewController) getAll(applicationID int, w http.ResponseWriter, r *http.Request) {
+	if _, err := models.GetApplicationByID(applicationID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodePageAsJSON(models.GetInterviewsOfApplication(applicationID), w, r)
+}
+
+func (ic interviewController) get(applicationID int, id int, w http.ResponseWriter, r *http.Request) {
+	i, err := models.GetInterviewByID(applicationID, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodeResponseAsJSON(i, w)
+}
+
+func (ic interviewController) getCalendar(applicationID int, id int, w http.ResponseWriter, r *http.Request) {
+	i, err := models.GetInterviewByID(applicationID, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeCalendar(w, fmt.Sprintf("interview-%v", id), []models.Interview{i})
+}
+
+func (ic interviewController) post(applicationID int, w http.ResponseWriter, r *http.Request) {
+	i, err := ic.parseRequest(w, r)
+	if err != nil {
+		writeParseError(w, r, "Interview", err)
+		return
+	}
+
+	i.ApplicationID = applicationID
+	i, err = models.AddInterview(r.Context(), i)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodeResponseAsJSON(i, w)
+}
+
+func (ic interviewController) put(applicationID int, id int, w http.ResponseWriter, r *http.Request) {
+	i, err := ic.parseRequest(w, r)
+	if err != nil {
+		writeParseError(w, r, "Interview", err)
+		return
+	}
+
+	if id != i.ID {
+		writeBadRequest(w, r, "ID of submitted interview must match ID in URL")
+		return
+	}
+
+	i.ApplicationID = applicationID
+	i, err = models.UpdateInterview(r.Context(), i)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodeResponseAsJSON(i, w)
+}
+
+func (ic interviewController) delete(applicationID int, id int, w http.ResponseWriter, r *http.Request) {
+	err := models.DeleteInterview(r.Context(), applicationID, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (ic interviewController) parseRequest(w http.ResponseWriter, r *http.Request) (models.Interview, error) {
+	dec := json.NewDecoder(requestBody(w, r))
+	var i models.Interview
+	err := dec.Decode(&i)
+	if err != nil {
+		return models.Interview{}, err
+	}
+	return i, nil
+}
+
+//Serves the interviews of an interviewer, under /interviewer/{email}/interviews and /interviewer/{email}/interviews.ics.
+type interviewerController struct {
+	interviewerPattern *regexp.Regexp
+}
+
+func newInterviewerController() *interviewerController {
+	return &interviewerController{
+		interviewerPattern: regexp.MustCompile(`^/interviewer/([^/]+)/interviews(\.ics)?/?$`),
+	}
+}
+
+func (ic interviewerController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	matches := ic.interviewerPattern.FindStringSubmatch(r.URL.Path)
+	if len(matches) == 0 {
+		writeNotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+
+	email := matches[1]
+	interviews := models.GetInterviewsOfInterviewer(email)
+	if matches[2] == "" {
+		encodePageAsJSON(interviews, w, r)
+		return
+	}
+	writeCalendar(w, "Interviews of "+email, interviews)
+}
+
+//Writes the interviews as an iCalendar file.
+func writeCalendar(w http.ResponseWriter, name string, interviews []models.Interview) {
+	events := make([]ics.Event, 0, len(interviews))
+	for _, i := range interviews {
+		events = append(events, interviewEvent(i))
+	}
+
+	w.Header().Set("Content-Type", ics.ContentType)
+	ics.Write(w, name, events)
+}
+
+func interviewEvent(i models.Interview) ics.Event {
+	summary := fmt.Sprintf("Interview for Application %v", i.ApplicationID)
+	if a, err := models.GetApplicationByID(i.ApplicationID); err == nil {
+		if c, err := models.GetCandidateByID(a.CandidateProfileID); err == nil {
+			summary = fmt.Sprintf("Interview with %v %v", c.FirstName, c.LastName)
+		}
+		if jr, err := models.GetJobRequisitionByID(a.JobRequisitionID); err == nil {
+			summary += " - " + jr.Title
+		}
+	}
+
+	description := fmt.Sprintf("Scheduled in %v.", i.TimeZone)
+	if i.VideoLink != "" {
+		description += "\nJoin: " + i.VideoLink
+	}
+	if i.Notes != "" {
+		description += "\n" + i.Notes
+	}
+
+	status := "CONFIRMED"
+	if i.Outcome == models.InterviewCancelled {
+		status = "CANCELLED"
+	}
+
+	e := ics.Event{
+		UID:         fmt.Sprintf("interview-%v@webservice", i.ID),
+		Start:       i.Start,
+		End:         i.End,
+		Summary:     summary,
+		Description: description,
+		Location:    i.Location,
+		URL:         i.VideoLink,
+		Status:      status,
+	}
+	for _, iv := range i.Interviewers {
+		e.Attendees = append(e.Attendees, ics.Attendee{Name: iv.Name, Email: iv.Email})
+	}
+	return e
+}
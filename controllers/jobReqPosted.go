@@ -23,35 +23,43 @@ func (jr jobReqPosted) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		case http.MethodGet:
 			jr.getPosted(w, r)
 		default:
-			w.WriteHeader(http.StatusNotImplemented)
+			writeMethodNotAllowed(w, r, http.MethodGet)
 		}
 	} else {
 		matches := jr.jobReqIDPattern.FindStringSubmatch(r.URL.Path)
 		if len(matches) == 0 {
-			w.WriteHeader(http.StatusNotFound)
+			writeNotFound(w, r)
+			return
 		}
 		id, err := strconv.Atoi(matches[1])
 		if err != nil {
-			w.WriteHeader(http.StatusNotFound)
+			writeNotFound(w, r)
+			return
 		}
 
 		switch r.Method {
 		case http.MethodGet:
-			jr.getIfPosted(id, w)
+			jr.getIfPosted(id, w, r)
 		default:
-			w.WriteHeader(http.StatusNotImplemented)
+			writeMethodNotAllowed(w, r, http.MethodGet)
 		}
 	}
 }
 
+//Lists the Open requisitions, or the ones in the states on ?State=OnHold&State=Open.
 func (jr jobReqPosted) getPosted(w http.ResponseWriter, r *http.Request) {
-	encodeResponseAsJSON(models.GetJobRequisitionPosted(), w)
+	reqs, err := models.GetJobRequisitionPosted(r.URL.Query()["State"]...)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodePageAsJSON(reqs, w, r)
 }
 
-func (jr jobReqPosted) getIfPosted(id int, w http.ResponseWriter) {
+func (jr jobReqPosted) getIfPosted(id int, w http.ResponseWriter, r *http.Request) {
 	j, err := models.IsJobReqPosted(id)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 	encodeResponseAsJSON(j, w)
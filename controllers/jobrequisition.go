@@ -14,7 +14,7 @@ type jobRequisitionController struct {
 
 func newJobRequisitionController() *jobRequisitionController {
 	return &jobRequisitionController{
-		jobReqIDPattern: regexp.MustCompile(`^/jobrequisition/(\d+)/?`),
+		jobReqIDPattern: regexp.MustCompile(`^/jobrequisition/(\d+)(?:/(\w+))?/?$`),
 	}
 }
 
@@ -26,33 +26,63 @@ func (jr jobRequisitionController) ServeHTTP(w http.ResponseWriter, r *http.Requ
 		case http.MethodPost:
 			jr.post(w, r)
 		default:
-			w.WriteHeader(http.StatusNotImplemented)
+			writeMethodNotAllowed(w, r, http.MethodGet, http.MethodPost)
 		}
 	} else {
 		matches := jr.jobReqIDPattern.FindStringSubmatch(r.URL.Path)
 		if len(matches) == 0 {
-			w.WriteHeader(http.StatusNotFound)
+			writeNotFound(w, r)
+			return
 		}
 		id, err := strconv.Atoi(matches[1])
 		if err != nil {
-			w.WriteHeader(http.StatusNotFound)
+			writeNotFound(w, r)
+			return
+		}
+
+		switch matches[2] {
+		case "":
+		case "transition", "approve", "reject":
+			if r.Method != http.MethodPost {
+				writeMethodNotAllowed(w, r, http.MethodPost)
+				return
+			}
+			jr.transition(id, matches[2], w, r)
+			return
+		case "approvals":
+			if r.Method != http.MethodGet {
+				writeMethodNotAllowed(w, r, http.MethodGet)
+				return
+			}
+			jr.getApprovals(id, w, r)
+			return
+		case "matches":
+			if r.Method != http.MethodGet {
+				writeMethodNotAllowed(w, r, http.MethodGet)
+				return
+			}
+			jr.getMatches(id, w, r)
+			return
+		default:
+			writeNotFound(w, r)
+			return
 		}
 
 		switch r.Method {
 		case http.MethodGet:
-			jr.get(id, w)
+			jr.get(id, w, r)
 		case http.MethodPut:
 			jr.put(id, w, r)
 		case http.MethodDelete:
-			jr.delete(id, w)
+			jr.delete(id, w, r)
 		default:
-			w.WriteHeader(http.StatusNotImplemented)
+			writeMethodNotAllowed(w, r, http.MethodGet, http.MethodPut, http.MethodDelete)
 		}
 	}
 }
 
-func (jr jobRequisitionController) parseRequest(r *http.Request) (models.JobRequisition, error) {
-	dec := json.NewDecoder(r.Body)
+func (jr jobRequisitionController) parseRequest(w http.ResponseWriter, r *http.Request) (models.JobRequisition, error) {
+	dec := json.NewDecoder(requestBody(w, r))
 	var can models.JobRequisition
 	err := dec.Decode(&can)
 	if err != nil {
@@ -62,63 +92,102 @@ func (jr jobRequisitionController) parseRequest(r *http.Request) (models.JobRequ
 }
 
 func (jr jobRequisitionController) getAll(w http.ResponseWriter, r *http.Request) {
-	encodeResponseAsJSON(models.GetJobRequisitions(), w)
+	encodePageAsJSON(models.GetJobRequisitions(), w, r)
 }
 
-func (jr jobRequisitionController) get(id int, w http.ResponseWriter) {
+func (jr jobRequisitionController) get(id int, w http.ResponseWriter, r *http.Request) {
 	j, err := models.GetJobRequisitionByID(id)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 	encodeResponseAsJSON(j, w)
 }
 
 func (jr jobRequisitionController) post(w http.ResponseWriter, r *http.Request) {
-	j, err := jr.parseRequest(r)
+	j, err := jr.parseRequest(w, r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("Error parsing object Job Requisition"))
+		writeParseError(w, r, "Job Requisition", err)
 		return
 	}
 
-	j, err = models.AddJobRequisition(j)
+	j, err = models.AddJobRequisition(r.Context(), j)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		writeError(w, r, err)
 		return
 	}
 	encodeResponseAsJSON(j, w)
 }
 
 func (jr jobRequisitionController) put(id int, w http.ResponseWriter, r *http.Request) {
-	j, err := jr.parseRequest(r)
+	j, err := jr.parseRequest(w, r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("Error parsing object Job Requisition"))
+		writeParseError(w, r, "Job Requisition", err)
 		return
 	}
 
 	if id != j.ID {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("ID of submitted user must match ID in URL"))
+		writeBadRequest(w, r, "ID of submitted user must match ID in URL")
 		return
 	}
 
-	j, err = models.UpdateJobRequisition(j)
+	j, err = models.UpdateJobRequisition(r.Context(), j)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		writeError(w, r, err)
 		return
 	}
 	encodeResponseAsJSON(j, w)
 }
 
-func (jr jobRequisitionController) delete(id int, w http.ResponseWriter) {
-	err := models.DeleteJobRequisition(id)
+//Moves the requisition through its lifecycle, with a body such as {"State": "Open", "By": "jane@example.com"},
+//or approves and rejects the pending step of its approval chain, with a body such as {"By": "jane@example.com", "Comment": "Budget approved"}.
+func (jr jobRequisitionController) transition(id int, action string, w http.ResponseWriter, r *http.Request) {
+	var t transitionRequest
+	if err := json.NewDecoder(requestBody(w, r)).Decode(&t); err != nil {
+		writeParseError(w, r, action, err)
+		return
+	}
+
+	var j models.JobRequisition
+	var err error
+	switch action {
+	case "approve":
+		j, err = models.ApproveJobRequisition(r.Context(), id, t.By, t.Comment)
+	case "reject":
+		j, err = models.RejectJobRequisition(r.Context(), id, t.By, t.Comment)
+	default:
+		j, err = models.TransitionJobRequisition(r.Context(), id, t.State, t.By, t.Comment)
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodeResponseAsJSON(j, w)
+}
+
+func (jr jobRequisitionController) getApprovals(id int, w http.ResponseWriter, r *http.Request) {
+	approvals, err := models.GetApprovalsOfJobRequisition(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodePageAsJSON(approvals, w, r)
+}
+
+//Ranks the candidates against the requisition, GET /jobrequisition/{id}/matches, explaining the score of each.
+func (jr jobRequisitionController) getMatches(id int, w http.ResponseWriter, r *http.Request) {
+	matches, err := models.GetCandidateMatches(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodePageAsJSON(matches, w, r)
+}
+
+func (jr jobRequisitionController) delete(id int, w http.ResponseWriter, r *http.Request) {
+	err := models.DeleteJobRequisition(r.Context(), id)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		writeError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"webservice/models"
+)
+
+//Serves the offers of an Application, under /application/{id}/offers.
+//Offers move between statuses on POST /application/{id}/offers/{offerID}/{action}.
+type offerController struct{}
+
+func (oc offerController) serve(applicationID int, rest string, action string, w http.ResponseWriter, r *http.Request) {
+	if rest == "" {
+		switch r.Method {
+		case http.MethodGet:
+			oc.getAll(applicationID, w, r)
+		case http.MethodPost:
+			oc.post(applicationID, w, r)
+		default:
+			writeMethodNotAllowed(w, r, http.MethodGet, http.MethodPost)
+		}
+		return
+	}
+
+	id, err := strconv.Atoi(rest)
+	if err != nil {
+		writeNotFound(w, r)
+		return
+	}
+
+	if action != "" {
+		if r.Method != http.MethodPost {
+			writeMethodNotAllowed(w, r, http.MethodPost)
+			return
+		}
+		oc.transition(applicationID, id, action, w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		oc.get(applicationID, id, w, r)
+	case http.MethodPut:
+		oc.put(applicationID, id, w, r)
+	case http.MethodDelete:
+		oc.delete(applicationID, id, w, r)
+	default:
+		writeMethodNotAllowed(w, r, http.MethodGet, http.MethodPut, http.MethodDelete)
+	}
+}
+
+func (oc offerController) getAll(applicationID int, w http.ResponseWriter, r *http.Request) {
+	if _, err := models.GetApplicationByID(applicationID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodePageAsJSON(models.GetOffersOfApplication(applicationID), w, r)
+}
+
+func (oc offerController) get(applicationID int, id int, w http.ResponseWriter, r *http.Request) {
+	o, err := models.GetOfferByID(applicationID, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodeResponseAsJSON(o, w)
+}
+
+func (oc offerController) post(applicationID int, w http.ResponseWriter, r *http.Request) {
+	o, err := oc.parseRequest(w, r)
+	if err != nil {
+		writeParseError(w, r, "Offer", err)
+		return
+	}
+
+	o.ApplicationID = applicationID
+	o, err = models.AddOffer(r.Context(), o)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodeResponseAsJSON(o, w)
+}
+
+func (oc offerController) put(applicationID int, id int, w http.ResponseWriter, r *http.Request) {
+	o, err := oc.parseRequest(w, r)
+	if err != nil {
+		writeParseError(w, r, "Offer", err)
+		return
+	}
+
+	if id != o.ID {
+		writeBadRequest(w, r, "ID of submitted offer must match ID in URL")
+		return
+	}
+
+	o.ApplicationID = applicationID
+	o, err = models.UpdateOffer(r.Context(), o)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodeResponseAsJSON(o, w)
+}
+
+func (oc offerController) delete(applicationID int, id int, w http.ResponseWriter, r *http.Request) {
+	err := models.DeleteOffer(r.Context(), applicationID, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (oc offerController) transition(applicationID int, id int, action string, w http.ResponseWriter, r *http.Request) {
+	var t transitionRequest
+	if err := json.NewDecoder(requestBody(w, r)).Decode(&t); err != nil {
+		writeParseError(w, r, "transition", err)
+		return
+	}
+
+	o, err := models.TransitionOffer(r.Context(), applicationID, id, action, t.By, t.Comment)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodeResponseAsJSON(o, w)
+}
+
+func (oc offerController) parseRequest(w http.ResponseWriter, r *http.Request) (models.Offer, error) {
+	dec := json.NewDecoder(requestBody(w, r))
+	var o models.Offer
+	err := dec.Decode(&o)
+	if err != nil {
+		return models.Offer{}, err
+	}
+	return o, nil
+}
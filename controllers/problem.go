@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"webservice/models"
+)
+
+//Problem is the body of every error response, as described by RFC 7807.
+type Problem struct {
+	Type     string              `json:"type"`
+	Title    string              `json:"title"`
+	Status   int                 `json:"status"`
+	Detail   string              `json:"detail,omitempty"`
+	Instance string              `json:"instance,omitempty"`
+	Errors   []models.FieldError `json:"errors,omitempty"`
+}
+
+//Types of problem returned by the controllers, relative to the service.
+const (
+	problemBadRequest       = "/problems/bad-request"
+	problemNotFound         = "/problems/not-found"
+	problemMethodNotAllowed = "/problems/method-not-allowed"
+	problemConflict         = "/problems/conflict"
+	problemTooLarge         = "/problems/request-too-large"
+	problemValidation       = "/problems/validation"
+	problemUnavailable      = "/problems/unavailable"
+	problemInternal         = "/problems/internal"
+)
+
+//Writes a problem+json response for the request.
+func writeProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	p.Instance = r.URL.Path
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+//Writes the error returned by the models with the status matching its type.
+//Errors without a type are logged and reported as internal errors, without their message.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var (
+		nf *models.NotFoundError
+		cf *models.ConflictError
+		ve *models.ValidationError
+		ue *models.UnavailableError
+	)
+
+	switch {
+	case errors.As(err, &nf):
+		writeProblem(w, r, Problem{Type: problemNotFound, Status: http.StatusNotFound, Detail: nf.Detail})
+	case errors.As(err, &cf):
+		writeProblem(w, r, Problem{Type: problemConflict, Status: http.StatusConflict, Detail: cf.Detail})
+	case errors.As(err, &ve):
+		writeProblem(w, r, Problem{Type: problemValidation, Title: "Validation failed", Status: http.StatusUnprocessableEntity, Detail: ve.Detail, Errors: ve.Fields})
+	case errors.As(err, &ue):
+		writeProblem(w, r, Problem{Type: problemUnavailable, Status: http.StatusServiceUnavailable, Detail: ue.Detail})
+	default:
+		//The error may come from a driver, it is logged rather than shown to the client
+		log.Printf("%v %v failed: %v", r.Method, r.URL.Path, err)
+		writeProblem(w, r, Problem{Type: problemInternal, Status: http.StatusInternalServerError, Detail: "The request could not be completed"})
+	}
+}
+
+//Writes the error returned while decoding the body of the request.
+//Bodies over Server.MaxBodyBytes are reported as too large, anything else as a bad request.
+func writeParseError(w http.ResponseWriter, r *http.Request, entity string, err error) {
+	if strings.Contains(err.Error(), "request body too large") {
+		writeProblem(w, r, Problem{Type: problemTooLarge, Status: http.StatusRequestEntityTooLarge, Detail: "Request body exceeds the size allowed"})
+		return
+	}
+	writeProblem(w, r, Problem{Type: problemBadRequest, Status: http.StatusBadRequest, Detail: "Could not parse " + entity + " object: " + err.Error()})
+}
+
+//Writes a bad request response with the detail provided.
+func writeBadRequest(w http.ResponseWriter, r *http.Request, detail string) {
+	writeProblem(w, r, Problem{Type: problemBadRequest, Status: http.StatusBadRequest, Detail: detail})
+}
+
+func writeNotFound(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, Problem{Type: problemNotFound, Status: http.StatusNotFound, Detail: "No resource at " + r.URL.Path})
+}
+
+//Writes a method not allowed response listing the methods accepted on the path.
+func writeMethodNotAllowed(w http.ResponseWriter, r *http.Request, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeProblem(w, r, Problem{Type: problemMethodNotAllowed, Status: http.StatusMethodNotAllowed, Detail: "Method " + r.Method + " is not supported on " + r.URL.Path})
+}
@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"webservice/models"
+)
+
+type tagController struct {
+	tagIDPattern *regexp.Regexp
+}
+
+func newTagController() *tagController {
+	return &tagController{
+		tagIDPattern: regexp.MustCompile(`^/tag/(\d+)/?$`),
+	}
+}
+
+func (t tagController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch strings.TrimSuffix(r.URL.Path, "/") {
+	case "/tag":
+		switch r.Method {
+		case http.MethodGet:
+			encodePageAsJSON(models.GetTagsWithUsage(), w, r)
+		case http.MethodPost:
+			t.post(w, r)
+		default:
+			writeMethodNotAllowed(w, r, http.MethodGet, http.MethodPost)
+		}
+	case "/tag/suggest":
+		if r.Method != http.MethodGet {
+			writeMethodNotAllowed(w, r, http.MethodGet)
+			return
+		}
+		t.suggest(w, r)
+	case "/tag/merge":
+		if r.Method != http.MethodPost {
+			writeMethodNotAllowed(w, r, http.MethodPost)
+			return
+		}
+		t.merge(w, r)
+	default:
+		matches := t.tagIDPattern.FindStringSubmatch(r.URL.Path)
+		if len(matches) == 0 {
+			writeNotFound(w, r)
+			return
+		}
+		id, err := strconv.Atoi(matches[1])
+		if err != nil {
+			writeNotFound(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			t.get(id, w, r)
+		case http.MethodPut:
+			t.put(id, w, r)
+		case http.MethodDelete:
+			t.delete(id, w, r)
+		default:
+			writeMethodNotAllowed(w, r, http.MethodGet, http.MethodPut, http.MethodDelete)
+		}
+	}
+}
+
+//Completes the text typed on a search box of tags, GET /tag/suggest?q=go&limit=5.
+func (t tagController) suggest(w http.ResponseWriter, r *http.Request) {
+	q, limit, err := parseSuggestQuery(r)
+	if err != nil {
+		writeBadRequest(w, r, err.Error())
+		return
+	}
+	encodeResponseAsJSON(models.SuggestTags(q, limit), w)
+}
+
+func (t tagController) get(id int, w http.ResponseWriter, r *http.Request) {
+	tag, err := models.GetTagByID(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodeResponseAsJSON(tag, w)
+}
+
+func (t tagController) post(w http.ResponseWriter, r *http.Request) {
+	tag, err := t.parseRequest(w, r)
+	if err != nil {
+		writeParseError(w, r, "Tag", err)
+		return
+	}
+
+	tag, err = models.CreateTag(r.Context(), tag)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodeResponseAsJSON(tag, w)
+}
+
+//Changes the label, synonyms and parent of the Tag, the label on every Candidate carrying it too.
+func (t tagController) put(id int, w http.ResponseWriter, r *http.Request) {
+	tag, err := t.parseRequest(w, r)
+	if err != nil {
+		writeParseError(w, r, "Tag", err)
+		return
+	}
+
+	if id != tag.ID {
+		writeBadRequest(w, r, "ID of submitted tag must match ID in URL")
+		return
+	}
+
+	updated, err := models.UpdateTag(r.Context(), tag)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodeResponseAsJSON(updated, w)
+}
+
+//Body of POST /tag/merge, the source Tag is merged into the target one.
+type tagMergeRequest struct {
+	TargetID int
+	SourceID int
+}
+
+func (t tagController) merge(w http.ResponseWriter, r *http.Request) {
+	var m tagMergeRequest
+	if err := json.NewDecoder(requestBody(w, r)).Decode(&m); err != nil {
+		writeParseError(w, r, "merge", err)
+		return
+	}
+
+	tag, err := models.MergeTags(r.Context(), m.TargetID, m.SourceID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encodeResponseAsJSON(tag, w)
+}
+
+//Deletes the Tag, removing it from every Candidate carrying it.
+func (t tagController) delete(id int, w http.ResponseWriter, r *http.Request) {
+	err := models.DeleteTag(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (t tagController) parseRequest(w http.ResponseWriter, r *http.Request) (models.Tag, error) {
+	dec := json.NewDecoder(requestBody(w, r))
+	var tag models.Tag
+	err := dec.Decode(&tag)
+	if err != nil {
+		return models.Tag{}, err
+	}
+	return tag, nil
+}
@@ -3,27 +3,66 @@ package db
 import (
 	"context"
 	"fmt"
+	"time"
+
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"webservice/config"
 )
 
-const uri = ""
+var (
+	//Client shared by the whole application, created once by ConnectToMongo.
+	client *mongo.Client
+	//Settings the shared client was created with.
+	settings config.Mongo
+)
 
-func GetDatabaseName () string {
-	return "myFirstDatabase"
+func GetDatabaseName() string {
+	return settings.Database
 }
 
-//Return true if successful to connect to Mongo DB.
-func OpenConnectionToMongo() (*mongo.Client, error) {
-	client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(uri))
+//Upper bound for a single operation when the caller's context has no deadline.
+func OperationTimeout() time.Duration {
+	return settings.OperationTimeout.Duration
+}
 
+//Creates the pooled client shared by every collection operation and checks that the server is reachable.
+//Returns an error if it was not possible to connect to MongoDB.
+func ConnectToMongo(ctx context.Context, cfg config.Mongo) error {
+	opts := options.Client().
+		ApplyURI(cfg.URI).
+		SetMaxPoolSize(cfg.MaxPoolSize).
+		SetMinPoolSize(cfg.MinPoolSize).
+		SetMaxConnIdleTime(cfg.MaxConnIdleTime.Duration).
+		SetConnectTimeout(cfg.ConnectTimeout.Duration).
+		SetServerSelectionTimeout(cfg.ServerSelectionTimeout.Duration)
+
+	c, err := mongo.Connect(ctx, opts)
 	if err != nil {
-		return &mongo.Client{}, fmt.Errorf("Could not establish connection to MongoDB")
+		return fmt.Errorf("Could not establish connection to MongoDB")
+	}
+
+	if err = c.Ping(ctx, readpref.Primary()); err != nil {
+		c.Disconnect(context.Background())
+		return fmt.Errorf("Could not establish connection to MongoDB")
 	}
 
-	return client, nil
+	client = c
+	settings = cfg
+	return nil
+}
+
+//Returns the database used by the application on the shared client.
+//ConnectToMongo must have been called before.
+func MongoDatabase() *mongo.Database {
+	return client.Database(GetDatabaseName())
 }
 
-func CloseConnectionToMongo(client *mongo.Client) {
-	client.Disconnect(context.TODO())
-}
\ No newline at end of file
+//Closes every connection of the shared client.
+func DisconnectFromMongo(ctx context.Context) error {
+	if client == nil {
+		return nil
+	}
+	return client.Disconnect(ctx)
+}
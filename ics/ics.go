@@ -0,0 +1,133 @@
+package ics
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+//ContentType of the calendars written by Write.
+const ContentType = "text/calendar; charset=utf-8"
+
+//Event is a VEVENT of an iCalendar file (RFC 5545).
+type Event struct {
+	//Globally unique and stable, so calendar clients update the event instead of duplicating it.
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	//CANCELLED for events that will no longer happen, CONFIRMED otherwise.
+	Status    string
+	Attendees []Attendee
+}
+
+type Attendee struct {
+	Name  string
+	Email string
+}
+
+//Write writes a VCALENDAR with the events provided.
+//Times are written in UTC, calendar clients convert them to the time zone of the user.
+func Write(w io.Writer, name string, events []Event) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) {
+		writeFolded(bw, s)
+	}
+
+	stamp := formatTime(time.Now())
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//webservice//Interviews//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	if name != "" {
+		line("X-WR-CALNAME:" + escape(name))
+	}
+
+	for _, e := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + escape(e.UID))
+		line("DTSTAMP:" + stamp)
+		line("DTSTART:" + formatTime(e.Start))
+		line("DTEND:" + formatTime(e.End))
+		line("SUMMARY:" + escape(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION:" + escape(e.Description))
+		}
+		if e.Location != "" {
+			line("LOCATION:" + escape(e.Location))
+		}
+		if e.URL != "" {
+			line("URL:" + stripControl(e.URL))
+		}
+		if e.Status != "" {
+			line("STATUS:" + stripControl(e.Status))
+		}
+		for _, a := range e.Attendees {
+			attendee := "ATTENDEE;ROLE=REQ-PARTICIPANT"
+			if a.Name != "" {
+				attendee += ";CN=" + quote(a.Name)
+			}
+			line(attendee + ":mailto:" + stripControl(a.Email))
+		}
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+//Escapes the characters with a meaning on TEXT values.
+//Line breaks, whichever way they are written, become \n and the other control characters are dropped.
+func escape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+	return stripControl(r.Replace(s))
+}
+
+//Quotes parameter values, which cannot contain double quotes nor control characters.
+func quote(s string) string {
+	return `"` + strings.ReplaceAll(stripControl(s), `"`, "'") + `"`
+}
+
+//Drops the control characters, so a value cannot end its content line and start another property.
+//Tabs are kept, they are allowed on every value.
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r != '\t' && (r < 0x20 || r == 0x7F) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+//Writes the content line ended by CRLF, folded so no line is longer than 75 octets.
+//Lines are only split between UTF-8 characters.
+func writeFolded(w *bufio.Writer, s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		//The space starting the continuation line counts towards its length
+		limit = 74
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,101 @@
+package ics
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Room 1", "Room 1"},
+		{`a\b`, `a\\b`},
+		{"a;b,c", `a\;b\,c`},
+		{"one\r\ntwo", `one\ntwo`},
+		{"one\ntwo", `one\ntwo`},
+		{"one\rtwo", `one\ntwo`},
+		{"bell\a and\ttab", "bell and\ttab"},
+		{"del\x7f", "del"},
+	}
+	for _, tt := range tests {
+		if got := escape(tt.in); got != tt.want {
+			t.Errorf("escape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Jane Doe", `"Jane Doe"`},
+		{`Jane "JD" Doe`, `"Jane 'JD' Doe"`},
+		{"Jane\r\nATTENDEE:mailto:x@evil.com", `"JaneATTENDEE:mailto:x@evil.com"`},
+	}
+	for _, tt := range tests {
+		if got := quote(tt.in); got != tt.want {
+			t.Errorf("quote(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteFolded(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"short", "SUMMARY:Interview", "SUMMARY:Interview\r\n"},
+		{"exactly 75", strings.Repeat("a", 75), strings.Repeat("a", 75) + "\r\n"},
+		{"76", strings.Repeat("a", 76), strings.Repeat("a", 75) + "\r\n a\r\n"},
+		{"continuation of 74", strings.Repeat("a", 75+74+1), strings.Repeat("a", 75) + "\r\n " + strings.Repeat("a", 74) + "\r\n a\r\n"},
+		//The 3 octets of € are not split between lines
+		{"multibyte", strings.Repeat("a", 74) + "€", strings.Repeat("a", 74) + "\r\n €\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			w := bufio.NewWriter(&b)
+			writeFolded(w, tt.in)
+			w.Flush()
+			if b.String() != tt.want {
+				t.Errorf("writeFolded() = %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+//Values carrying line breaks cannot add properties to the calendar.
+func TestWriteInjection(t *testing.T) {
+	start := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	var b bytes.Buffer
+	err := Write(&b, "Interviews", []Event{{
+		UID:       "interview-1@webservice",
+		Start:     start,
+		End:       start.Add(time.Hour),
+		Summary:   "Interview\rATTENDEE:mailto:summary@evil.com",
+		URL:       "https://meet.example.com/1\r\nATTENDEE:mailto:url@evil.com",
+		Attendees: []Attendee{{Name: "Jane\nATTENDEE:mailto:cn@evil.com", Email: "jane@corp.com\r\nATTENDEE:mailto:email@evil.com"}},
+	}})
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n") {
+		if strings.ContainsAny(line, "\r\n") {
+			t.Errorf("line %q has a bare line break", line)
+		}
+		if strings.HasPrefix(line, "ATTENDEE:") {
+			t.Errorf("line %q was injected", line)
+		}
+	}
+	if n := strings.Count(b.String(), "\r\nATTENDEE;"); n != 1 {
+		t.Errorf("calendar has %v attendees, want 1:\n%v", n, b.String())
+	}
+}
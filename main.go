@@ -1,11 +1,106 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"log"
 	"net/http"
+	"os"
+	"time"
+	//Time zones of the interviews are validated against the IANA database, even on hosts without it
+	_ "time/tzdata"
+	"webservice/config"
 	"webservice/controllers"
+	"webservice/db"
+	"webservice/models"
 )
 
 func main() {
-	controllers.RegisterControllers()
-	http.ListenAndServe(":3000", nil)
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "optional YAML or JSON configuration file")
+	flag.Parse()
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var stores models.Stores
+	switch cfg.Storage.Backend {
+	case "mongo":
+		if err := db.ConnectToMongo(ctx, cfg.Mongo); err != nil {
+			log.Fatal(err)
+		}
+		defer db.DisconnectFromMongo(context.Background())
+
+		if err = models.CreateMongoIndexes(ctx, db.MongoDatabase()); err != nil {
+			log.Fatal(err)
+		}
+		stores = models.NewMongoStores(db.MongoDatabase())
+	case "hana":
+		conn, err := db.OpenConnectionToHana(ctx, cfg.Hana.DSN)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.CloseConnectionToHana(conn)
+
+		if err = models.CreateHanaSchema(ctx, conn); err != nil {
+			log.Fatal(err)
+		}
+		stores = models.NewHanaStores(conn)
+	case "memory":
+		stores = models.NewMemoryStores()
+	}
+
+	switch cfg.Attachments.Backend {
+	case "gridfs":
+		stores.Contents = models.NewGridFSContentStore(db.MongoDatabase())
+	case "filesystem":
+		stores.Contents, err = models.NewFilesystemContentStore(cfg.Attachments.Directory)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	models.SetAttachmentRules(cfg.Attachments.MaxBytes, cfg.Attachments.AllowedTypes)
+
+	models.SetPipeline(models.Pipeline{
+		Stages:      cfg.Pipeline.Stages,
+		Transitions: cfg.Pipeline.Transitions,
+	})
+
+	byCountry := make(map[string][]models.ApprovalStep)
+	for code, chain := range cfg.Approvals.Countries {
+		byCountry[code] = approvalSteps(chain)
+	}
+	models.SetApprovalChains(approvalSteps(cfg.Approvals.Default), byCountry)
+
+	if err := models.Init(ctx, stores); err != nil {
+		log.Fatal(err)
+	}
+
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+	go models.Watch(watchCtx, cfg.Cache.PollInterval.Duration)
+	go models.SweepOffers(watchCtx, cfg.Offers.SweepInterval.Duration)
+	go models.ScheduleRequisitions(watchCtx, cfg.Requisitions.ScheduleInterval.Duration)
+
+	controllers.RegisterControllers(cfg)
+
+	server := &http.Server{
+		Addr:         cfg.Server.Addr,
+		ReadTimeout:  cfg.Server.ReadTimeout.Duration,
+		WriteTimeout: cfg.Server.WriteTimeout.Duration,
+	}
+	log.Fatal(server.ListenAndServe())
+}
+
+//Converts the approval chain read from the configuration to the one used by the models.
+func approvalSteps(chain []config.ApprovalStep) []models.ApprovalStep {
+	steps := make([]models.ApprovalStep, 0, len(chain))
+	for _, s := range chain {
+		steps = append(steps, models.ApprovalStep{Name: s.Name, Approvers: s.Approvers})
+	}
+	return steps
 }
@@ -2,32 +2,47 @@ package models
 
 import (
 	"context"
-	"fmt"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo/options"
-	"webservice/db"
+	"time"
+
+	"webservice/validate"
+)
+
+//Statuses of an Application.
+const (
+	ApplicationActive    = "Active"
+	ApplicationWithdrawn = "Withdrawn"
 )
 
+//Application of a Candidate to a JobRequisition, a candidate applies only once to each requisition.
 type Application struct {
 	ID                 int
-	CandidateProfileID int
-	JobRequisitionID   int
+	CandidateProfileID int `validate:"required"`
+	JobRequisitionID   int `validate:"required"`
 	SalaryExpectation  string
 	ApplicationSource  string
-	TimeOfExperience   int
+	TimeOfExperience   int `validate:"min=0"`
+	//Current stage on the pipeline, only changed by TransitionApplication.
+	Stage        string
+	StageHistory []StageChange
+	//Active until the candidate withdraws it through WithdrawApplication.
+	Status           string
+	WithdrawnAt      time.Time
+	WithdrawnBy      string
+	WithdrawalReason string
 }
 
-var (
-	applications = make(map[int]*Application)
-	nextAppID    = updateApplicantsInMemory()
-)
+//Returns true once the candidate has withdrawn the Application.
+func (a Application) withdrawn() bool {
+	return a.Status == ApplicationWithdrawn
+}
 
 //In Memory: Returns the complete list of Application that has been.
 //Returns a hashmap containing the list of Application
 func GetApplications() []*Application {
 	appArr := make([]*Application,0)
-	for _, v := range applications {
-		appArr = append(appArr, v)
+	for _, v := range records.allApplications() {
+		v := v
+		appArr = append(appArr, &v)
 	}
 	return appArr
 	//return applications
@@ -36,225 +51,213 @@ func GetApplications() []*Application {
 //In Memory: Searches for a specific Application on the hashmap.
 //Returns a Application object and an error in case it was not possible to find the record
 func GetApplicationByID(id int) (Application, error) {
-	if a, found := applications[id]; found {
-		return *a, nil
+	if a, found := records.application(id); found {
+		return a, nil
 	}
 
-	return Application{}, fmt.Errorf("Application with id '%v' not found", id)
+	return Application{}, notFound("Application with id '%v' not found", id)
 }
 
 //In Memory: Searches for Application that belong to the candidate with id received as parameter on the hashmap.
 //Returns a list of Application object.
 func GetApplicationsOfCandidate(id int) []Application {
-	appArr := make([]Application,0)
-
-	for _, v := range applications {
-		if v.CandidateProfileID == id {
-			appArr = append(appArr, *v)
-		}
-	}
-
-	return appArr
+	return records.applicationsOfCandidate(id)
 }
 
 //In Memory: Searches for Application done to the JobRequisition with id received as parameter on the hashmap.
 //Returns a list of Application object.
 func GetApplicationsOfJobReq(id int) []Application {
-	appArr := make([]Application,0)
-
-	for _, v := range applications {
-		if v.JobRequisitionID == id {
-			appArr = append(appArr, *v)
-		}
-	}
-
-	return appArr
+	return records.applicationsOfJobReq(id)
 }
 
 //In DB: Creates a new Application record to the collection and updates the Application in memory.
 //Returns a Application object and an error in case it was not possible to create the record
-func AddApplication(a Application) (Application, error) {
-	if a.ID != 0 {
-		return Application{}, fmt.Errorf("Application must not contain ID upon creation")
-	}
+func AddApplication(ctx context.Context, a Application) (Application, error) {
+	var v validate.Validator
+	v.Check(a.ID == 0, "ID", "Application must not contain ID upon creation")
+	v.Struct(a)
 
-	if a.CandidateProfileID == 0 || a.JobRequisitionID == 0 {
-		return Application{}, fmt.Errorf("CandidateProfileID and JobRequisitionID are mandatory for submitting application")
-	}
+	//Job Requisition has not been found
+	jr, err := GetJobRequisitionByID(a.JobRequisitionID)
+	v.Check(a.JobRequisitionID == 0 || err == nil, "JobRequisitionID", "Job Requisition '%v' not found", a.JobRequisitionID)
 
-	tf, err := IsJobReqPosted(a.JobRequisitionID)
+	_, found := records.candidate(a.CandidateProfileID)
+	v.Check(a.CandidateProfileID == 0 || found, "CandidateProfileID", "Candidate '%v' not found", a.CandidateProfileID)
 
-	//Job Requisition has not been found
-	if err != nil {
-		return Application{}, fmt.Errorf("Error getting Job Requisition posting")
+	if err := invalid("Application is not valid", &v); err != nil {
+		return Application{}, err
 	}
 
-	//Candidates cannot apply to requisitions not posted
-	if !tf {
-		return Application{}, fmt.Errorf("Job Requisition is not posted")
+	//Candidates can only apply to open requisitions
+	if jr.State != RequisitionOpen {
+		return Application{}, conflict("Job Requisition '%v' is %v, applications are only accepted while Open", jr.ID, jr.State)
 	}
 
-	a.ID = nextAppID
+	//The stores reject the pair as well, this gives a clearer message
+	if err := checkAppliedOnce(a); err != nil {
+		return Application{}, err
+	}
 
-	client, err := db.OpenConnectionToMongo()
+	id, err := nextID(ctx, applicationSequence)
 	if err != nil {
-		return Application{}, fmt.Errorf("Could not establish connection to Database")
+		return Application{}, err
 	}
-
-	coll := client.Database(db.GetDatabaseName()).Collection("Applications")
-	doc := bson.D{
-		{"ID", a.ID},
-		{"CandidateProfileID", a.CandidateProfileID},
-		{"JobRequisitionID", a.JobRequisitionID},
-		{"SalaryExpectation", a.SalaryExpectation},
-		{"ApplicationSource", a.ApplicationSource},
-		{"TimeOfExperience", a.TimeOfExperience}}
-
-	if _, err = coll.InsertOne(context.TODO(), doc); err != nil {
-		return Application{}, fmt.Errorf("Could not insert application provided")
+	a.ID = id
+	a.Stage = pipeline.initial()
+	a.StageHistory = []StageChange{{To: a.Stage, At: time.Now().UTC()}}
+	a.Status = ApplicationActive
+	a.WithdrawnAt = time.Time{}
+	a.WithdrawnBy = ""
+	a.WithdrawalReason = ""
+
+	if err = stores.Applications.Insert(ctx, a); err != nil {
+		return Application{}, err
 	}
 
-	defer db.CloseConnectionToMongo(client)
-
-	updateApplicantsInMemory()
-	updateJobRequisitionInMemory()
-	updateCandidatesInMemory()
+	records.putApplication(a)
 	return a, nil
 }
 
 //In DB: Updates a Application record on the collection and updates the Application in memory.
+//The Candidate and the JobRequisition cannot change, the candidate applies again instead.
 //Returns a Application object and an error in case it was not possible to update the record
-func UpdateApplication(a Application) (Application, error) {
-	if a.CandidateProfileID == 0 || a.JobRequisitionID == 0{
-		return Application{}, fmt.Errorf("Missing Job Requisition ID and/or Candidate ID")
+func UpdateApplication(ctx context.Context, a Application) (Application, error) {
+	var v validate.Validator
+	v.Struct(a)
+	if err := invalid("Application is not valid", &v); err != nil {
+		return Application{}, err
 	}
 
-	if _, found := applications[a.ID]; found {
-		client, err := db.OpenConnectionToMongo()
-		if err != nil {
-			return Application{}, fmt.Errorf("Could not establish connection to Database")
+	if old, found := records.application(a.ID); found {
+		//The stages, interviews and offers of the application belong to the pair it was made for
+		v.Check(a.CandidateProfileID == old.CandidateProfileID, "CandidateProfileID", "cannot be changed from '%v'", old.CandidateProfileID)
+		v.Check(a.JobRequisitionID == old.JobRequisitionID, "JobRequisitionID", "cannot be changed from '%v'", old.JobRequisitionID)
+		if err := invalid("Application is not valid", &v); err != nil {
+			return Application{}, err
 		}
 
-		coll := client.Database(db.GetDatabaseName()).Collection("Applications")
-		filter := bson.D{{"ID", a.ID}}
-		update := bson.D{{"$set", bson.D{
-			{"CandidateProfileID", a.CandidateProfileID},
-			{"JobRequisitionID", a.JobRequisitionID},
-			{"SalaryExpectation", a.SalaryExpectation},
-			{"ApplicationSource", a.ApplicationSource},
-			{"TimeOfExperience", a.TimeOfExperience}}}}
-
-		if _, err = coll.UpdateOne(context.TODO(), filter, update); err != nil {
-			return Application{}, fmt.Errorf("Could not update application provided")
+		//The stage can only be changed through TransitionApplication
+		a.Stage = old.Stage
+		a.StageHistory = old.StageHistory
+		//The status can only be changed through WithdrawApplication
+		a.Status = old.Status
+		a.WithdrawnAt = old.WithdrawnAt
+		a.WithdrawnBy = old.WithdrawnBy
+		a.WithdrawalReason = old.WithdrawalReason
+
+		if err := stores.Applications.Update(ctx, a); err != nil {
+			return Application{}, err
 		}
 
-		defer db.CloseConnectionToMongo(client)
-
-		updateApplicantsInMemory()
-		updateCandidatesInMemory()
-		updateJobRequisitionInMemory()
+		records.putApplication(a)
 		return a, nil
 	} else {
-		return Application{}, fmt.Errorf("Application with ID '%v' not found", a.ID)
+		return Application{}, notFound("Application with ID '%v' not found", a.ID)
 	}
 }
 
-//In DB: Removes a Application record from the collection and updates the Application in memory.
-//Returns error if failed to complete the deletion on the DB
-func DeleteApplication(id int) error {
-	if _, found := applications[id]; found {
-		client, err := db.OpenConnectionToMongo()
+//In DB: Withdraws the Application on behalf of the candidate, keeping the record with the Withdrawn status.
+//The open offers of the Application are declined and its pending interviews still to happen are cancelled.
+//by defaults to the email of the candidate. Returns an error if the Application is already withdrawn.
+func WithdrawApplication(ctx context.Context, id int, by string, reason string) (Application, error) {
+	a, found := records.application(id)
+	if !found {
+		return Application{}, notFound("Application with ID '%v' not found", id)
+	}
+	if a.withdrawn() {
+		return Application{}, conflict("Application '%v' was already withdrawn at %v", id, a.WithdrawnAt.Format(time.RFC3339))
+	}
 
-		if err != nil {
-			return fmt.Errorf("Could not establish connection to Database")
+	if by == "" {
+		if c, found := records.candidate(a.CandidateProfileID); found {
+			by = c.Email
 		}
+	}
 
-		coll := client.Database(db.GetDatabaseName()).Collection("Applications")
-		filter := bson.D{{"ID", id}}
-
-		if _, err = coll.DeleteOne(context.TODO(),filter); err != nil {
-			return fmt.Errorf("Could not delete Application with id provided")
+	now := time.Now().UTC()
+	for _, o := range records.offersOfApplication(id) {
+		if !o.open() {
+			continue
 		}
+		if _, err := setOfferStatus(ctx, o, OfferDeclined, by, "Application withdrawn", now); err != nil {
+			return Application{}, err
+		}
+	}
+	for _, i := range records.interviewsOfApplication(id) {
+		if i.Outcome != InterviewPending || !i.Start.After(now) {
+			continue
+		}
+		i.Outcome = InterviewCancelled
+		if err := stores.Interviews.Update(ctx, i); err != nil {
+			return Application{}, err
+		}
+		records.putInterview(i)
+	}
 
-		defer db.CloseConnectionToMongo(client)
-
-		updateApplicantsInMemory()
-		updateJobRequisitionInMemory()
-		updateCandidatesInMemory()
-		return nil
+	a.Status = ApplicationWithdrawn
+	a.WithdrawnAt = now
+	a.WithdrawnBy = by
+	a.WithdrawalReason = reason
+	if err := stores.Applications.Update(ctx, a); err != nil {
+		return Application{}, err
 	}
-	return fmt.Errorf("Application with ID '%v' not found", id)
+
+	records.putApplication(a)
+	return a, nil
 }
 
-//In DB: Removes all Application from a specified Candidate.
-func DeleteApplicationFromCandidate(id int) {
-	for _, v := range applications {
-		if v.CandidateProfileID == id {
-			DeleteApplication(v.ID)
+//Returns a ConflictError when the candidate of the Application already applied to its JobRequisition on another Application.
+func checkAppliedOnce(a Application) error {
+	for _, other := range records.applicationsOfCandidate(a.CandidateProfileID) {
+		if other.ID != a.ID && other.JobRequisitionID == a.JobRequisitionID {
+			return conflict("Candidate '%v' already applied to Job Requisition '%v' on Application '%v', which is %v",
+				a.CandidateProfileID, a.JobRequisitionID, other.ID, other.Status)
 		}
 	}
+	return nil
 }
 
-//In DB: Removes all Application from a specified JobRequisition.
-func DeleteApplicationFromJobReq(id int) {
-	for _, v := range applications {
-		if v.JobRequisitionID == id {
-			DeleteApplication(v.ID)
+//In DB: Removes a Application record from the collection and updates the Application in memory.
+//Returns error if failed to complete the deletion on the DB
+func DeleteApplication(ctx context.Context, id int) error {
+	if _, found := records.application(id); found {
+		//Remove the interviews scheduled and the offers made for the application,
+		//the application is kept when they fail so it can be deleted again
+		if err := DeleteInterviewsFromApplication(ctx, id); err != nil {
+			return err
+		}
+		if err := DeleteOffersFromApplication(ctx, id); err != nil {
+			return err
 		}
-	}
-}
 
-//Updates the hashmap containing all the Application to work with them in memory.
-//Return the next ID to be added into the Database
-func updateApplicantsInMemory() int {
-	client, err := db.OpenConnectionToMongo()
-	if err != nil {
-		return -1
-	}
+		if err := stores.Applications.Delete(ctx, id); err != nil {
+			return err
+		}
 
-	filter := bson.D{}
-	projection := bson.D{
-		{"ID", 1},
-		{"CandidateProfileID", 1},
-		{"JobRequisitionID", 1},
-		{"SalaryExpectation", 1},
-		{"ApplicationSource", 1},
-		{"TimeOfExperience", 1},
+		records.removeApplication(id)
+		return nil
 	}
-	opts := options.Find().SetProjection(projection)
-
-	coll := client.Database(db.GetDatabaseName()).Collection("Applications")
-	cursor, err := coll.Find(context.TODO(), filter, opts)
+	return notFound("Application with ID '%v' not found", id)
+}
 
-	var results []bson.D
-	if err = cursor.All(context.TODO(), &results); err != nil {
-		panic(err)
+//In DB: Removes all Application from a specified Candidate.
+//Returns error on the first Application that could not be deleted, the ones before it are already deleted
+func DeleteApplicationFromCandidate(ctx context.Context, id int) error {
+	for _, v := range GetApplicationsOfCandidate(id) {
+		if err := DeleteApplication(ctx, v.ID); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	defer db.CloseConnectionToMongo(client)
-
-	biggestId := 1
-	applications = make(map[int]*Application)
-	for _, v := range results {
-		a := bsonToApplicant(v)
-
-		applications[a.ID] = &a
-		if a.ID > biggestId {
-			biggestId = a.ID
+//In DB: Removes all Application from a specified JobRequisition.
+//Returns error on the first Application that could not be deleted, the ones before it are already deleted
+func DeleteApplicationFromJobReq(ctx context.Context, id int) error {
+	for _, v := range GetApplicationsOfJobReq(id) {
+		if err := DeleteApplication(ctx, v.ID); err != nil {
+			return err
 		}
 	}
-
-	return biggestId+1
+	return nil
 }
-
-//Receives a bson object to execute the conversion.
-//Returns a Application object.
-func bsonToApplicant(v bson.D) Application {
-	bsonBytes, _ := bson.Marshal(v)
-
-	var a Application
-	//deconvert the byarray into a struct object
-	bson.Unmarshal(bsonBytes, &a)
-
-	return a
-}
\ No newline at end of file
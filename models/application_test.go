@@ -0,0 +1,83 @@
+package models
+
+import (
+	"context"
+	"testing"
+)
+
+//Creates the Application of the Candidate to the JobRequisition.
+func addTestApplication(t *testing.T, ctx context.Context, candidateID int, jobReqID int) Application {
+	t.Helper()
+	a, err := AddApplication(ctx, Application{CandidateProfileID: candidateID, JobRequisitionID: jobReqID})
+	if err != nil {
+		t.Fatalf("AddApplication() error = %v", err)
+	}
+	return a
+}
+
+func TestAddApplicationOnce(t *testing.T) {
+	ctx := initMemoryStores(t)
+	jr := addTestRequisition(t, ctx, "Backend Engineer", RequisitionOpen, 1)
+	c := addTestCandidate(t, ctx, "Jane", "Doe")
+	first := addTestApplication(t, ctx, c.ID, jr.ID)
+
+	_, err := AddApplication(ctx, Application{CandidateProfileID: c.ID, JobRequisitionID: jr.ID})
+	wantError[*ConflictError](t, err)
+
+	//A withdrawn application still counts, the candidate does not apply twice
+	if _, err := WithdrawApplication(ctx, first.ID, "", "Accepted another job"); err != nil {
+		t.Fatalf("WithdrawApplication() error = %v", err)
+	}
+	_, err = AddApplication(ctx, Application{CandidateProfileID: c.ID, JobRequisitionID: jr.ID})
+	wantError[*ConflictError](t, err)
+
+	//The stores reject the pair as a unique index would, whatever the models checked
+	err = stores.Applications.Insert(ctx, Application{ID: first.ID + 100, CandidateProfileID: c.ID, JobRequisitionID: jr.ID})
+	wantError[*ConflictError](t, err)
+
+	if got := GetApplicationsOfCandidate(c.ID); len(got) != 1 {
+		t.Errorf("GetApplicationsOfCandidate() = %v applications, want 1", len(got))
+	}
+}
+
+func TestAddApplicationInvalid(t *testing.T) {
+	ctx := initMemoryStores(t)
+	open := addTestRequisition(t, ctx, "Backend Engineer", RequisitionOpen, 1)
+	draft := addTestRequisition(t, ctx, "Frontend Engineer", RequisitionDraft, 1)
+	c := addTestCandidate(t, ctx, "Jane", "Doe")
+
+	_, err := AddApplication(ctx, Application{CandidateProfileID: c.ID + 100, JobRequisitionID: open.ID})
+	wantError[*ValidationError](t, err)
+	_, err = AddApplication(ctx, Application{CandidateProfileID: c.ID, JobRequisitionID: open.ID + 100})
+	wantError[*ValidationError](t, err)
+	_, err = AddApplication(ctx, Application{CandidateProfileID: c.ID, JobRequisitionID: draft.ID})
+	wantError[*ConflictError](t, err)
+}
+
+func TestUpdateApplicationKeepsThePair(t *testing.T) {
+	ctx := initMemoryStores(t)
+	backend := addTestRequisition(t, ctx, "Backend Engineer", RequisitionOpen, 1)
+	frontend := addTestRequisition(t, ctx, "Frontend Engineer", RequisitionOpen, 1)
+	jane := addTestCandidate(t, ctx, "Jane", "Doe")
+	john := addTestCandidate(t, ctx, "John", "Roe")
+	a := addTestApplication(t, ctx, jane.ID, backend.ID)
+
+	moved := a
+	moved.JobRequisitionID = frontend.ID
+	_, err := UpdateApplication(ctx, moved)
+	wantError[*ValidationError](t, err)
+
+	moved = a
+	moved.CandidateProfileID = john.ID
+	_, err = UpdateApplication(ctx, moved)
+	wantError[*ValidationError](t, err)
+
+	a.SalaryExpectation = "100000"
+	updated, err := UpdateApplication(ctx, a)
+	if err != nil {
+		t.Fatalf("UpdateApplication() error = %v", err)
+	}
+	if updated.SalaryExpectation != "100000" || updated.Stage != a.Stage {
+		t.Errorf("UpdateApplication() = %+v, want the salary changed on the same stage", updated)
+	}
+}
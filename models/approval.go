@@ -0,0 +1,183 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"webservice/validate"
+)
+
+//ApprovalStep is a step of the chain a JobRequisition is approved on before it can be posted.
+type ApprovalStep struct {
+	Name string
+	//Emails of who may approve the step, anyone may when empty.
+	Approvers []string
+}
+
+//Approval records the decision taken on a step of the chain.
+type Approval struct {
+	//Every submission of the JobRequisition for approval starts a new round, the previous ones stay on the trail.
+	Round    int
+	Step     string
+	Approved bool
+	By       string
+	At       time.Time
+	Comment  string
+}
+
+//Approval chains used by the JobRequisition, set at startup by SetApprovalChains.
+var approvalChains struct {
+	byDefault []ApprovalStep
+	byCountry map[string][]ApprovalStep
+}
+
+//SetApprovalChains replaces the chains the JobRequisition are approved on.
+//byCountry is keyed by the code of the Country of the requisition, the others use byDefault.
+//Must be called before the controllers start serving requests.
+func SetApprovalChains(byDefault []ApprovalStep, byCountry map[string][]ApprovalStep) {
+	approvalChains.byDefault = byDefault
+	approvalChains.byCountry = byCountry
+}
+
+//Returns the chain of the Country with the code provided.
+func approvalChainOf(countryCode string) []ApprovalStep {
+	if chain, found := approvalChains.byCountry[countryCode]; found {
+		return chain
+	}
+	return approvalChains.byDefault
+}
+
+//Returns the names of the steps of the chain, separated by commas.
+func chainNames(chain []ApprovalStep) string {
+	names := make([]string, 0, len(chain))
+	for _, s := range chain {
+		names = append(names, s.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+//Returns the round of approvals the JobRequisition is on, the number of times it was submitted for approval.
+func approvalRound(jr JobRequisition) int {
+	round := 0
+	for _, sc := range jr.StateHistory {
+		if sc.To == RequisitionPendingApproval {
+			round++
+		}
+	}
+	return round
+}
+
+//Returns the steps of the chain not approved yet on the current round, in the order they must be approved.
+//The Country of the JobRequisition must be resolved.
+func pendingApprovals(jr JobRequisition) []string {
+	round := approvalRound(jr)
+	approved := make(map[string]bool)
+	for _, a := range jr.Approvals {
+		if a.Round == round && a.Approved {
+			approved[a.Step] = true
+		}
+	}
+
+	pending := make([]string, 0)
+	for _, s := range approvalChainOf(jr.JobReqCountry.Code) {
+		if !approved[s.Name] {
+			pending = append(pending, s.Name)
+		}
+	}
+	return pending
+}
+
+//In Memory: Returns the approval trail of the JobRequisition, every round included.
+//Returns a list of Approval and an error in case the JobRequisition does not exist
+func GetApprovalsOfJobRequisition(id int) ([]Approval, error) {
+	jr, err := GetJobRequisitionByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return append(make([]Approval, 0, len(jr.Approvals)), jr.Approvals...), nil
+}
+
+//In DB: Approves the next pending step of the JobRequisition.
+//The JobRequisition can be posted once every step is approved.
+//Returns a ConflictError when the JobRequisition was changed since it was read, such as the step approved
+//or the requisition rejected at the same time, rather than record the approval over that change.
+func ApproveJobRequisition(ctx context.Context, id int, by string, comment string) (JobRequisition, error) {
+	jr, step, err := pendingStep(id, by)
+	if err != nil {
+		return JobRequisition{}, err
+	}
+
+	approval := Approval{Round: approvalRound(jr), Step: step, Approved: true, By: by, At: time.Now().UTC(), Comment: comment}
+	jr.Approvals = append(append([]Approval(nil), jr.Approvals...), approval)
+	jr.Version++
+
+	if err := stores.JobRequisitions.Update(ctx, jr); err != nil {
+		return JobRequisition{}, err
+	}
+
+	records.putJobRequisition(jr)
+	return GetJobRequisitionByID(id)
+}
+
+//In DB: Rejects the next pending step of the JobRequisition, which goes back to Draft.
+//The whole chain must approve it again once it is submitted again.
+func RejectJobRequisition(ctx context.Context, id int, by string, comment string) (JobRequisition, error) {
+	var v validate.Validator
+	v.Check(comment != "", "Comment", "should explain the rejection")
+	if err := invalid("Rejection is not valid", &v); err != nil {
+		return JobRequisition{}, err
+	}
+
+	jr, step, err := pendingStep(id, by)
+	if err != nil {
+		return JobRequisition{}, err
+	}
+
+	now := time.Now().UTC()
+	rejection := Approval{Round: approvalRound(jr), Step: step, Approved: false, By: by, At: now, Comment: comment}
+	jr.Approvals = append(append([]Approval(nil), jr.Approvals...), rejection)
+
+	if err := setRequisitionState(ctx, &jr, RequisitionDraft, by, "Rejected on "+step+": "+comment, now); err != nil {
+		return JobRequisition{}, err
+	}
+	return GetJobRequisitionByID(id)
+}
+
+//Returns the JobRequisition and the step by may decide on.
+//Returns an error if the JobRequisition is not waiting for approval or by is not an approver of the step.
+func pendingStep(id int, by string) (JobRequisition, string, error) {
+	var v validate.Validator
+	v.Check(by != "", "By", "should be populated")
+	if err := invalid("Approval is not valid", &v); err != nil {
+		return JobRequisition{}, "", err
+	}
+
+	jr, found := records.jobRequisition(id)
+	if !found {
+		return JobRequisition{}, "", notFound("Job Requisition with ID '%v' not found", id)
+	}
+	if jr.State != RequisitionPendingApproval {
+		return JobRequisition{}, "", conflict("Job Requisition '%v' is %v, only a requisition PendingApproval can be approved", id, jr.State)
+	}
+
+	pending := pendingApprovals(jr)
+	if len(pending) == 0 {
+		return JobRequisition{}, "", conflict("Job Requisition '%v' has no approval pending", id)
+	}
+
+	for _, s := range approvalChainOf(jr.JobReqCountry.Code) {
+		if s.Name != pending[0] || len(s.Approvers) == 0 {
+			continue
+		}
+		allowed := false
+		for _, a := range s.Approvers {
+			allowed = allowed || strings.EqualFold(a, by)
+		}
+		v.Check(allowed, "By", "is not an approver of the %v step", s.Name)
+	}
+	if err := invalid("Approval is not valid", &v); err != nil {
+		return JobRequisition{}, "", err
+	}
+	return jr, pending[0], nil
+}
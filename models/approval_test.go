@@ -0,0 +1,176 @@
+package models
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+//Creates a Draft JobRequisition of the Country and submits it for approval.
+func submitTestRequisition(t *testing.T, ctx context.Context, country Country) JobRequisition {
+	t.Helper()
+	jr, err := AddJobRequisition(ctx, JobRequisition{Title: "Backend Engineer", JobDescription: "Builds the services of the product", JrCountryId: country.ID})
+	if err != nil {
+		t.Fatalf("AddJobRequisition() error = %v", err)
+	}
+	if jr, err = TransitionJobRequisition(ctx, jr.ID, RequisitionPendingApproval, "recruiter@corp.com", ""); err != nil {
+		t.Fatalf("TransitionJobRequisition(%v) error = %v", RequisitionPendingApproval, err)
+	}
+	return jr
+}
+
+func TestApproveJobRequisition(t *testing.T) {
+	ctx := initMemoryStores(t)
+	SetApprovalChains(nil, map[string][]ApprovalStep{
+		"BR": {{Name: "Finance", Approvers: []string{"cfo@corp.com"}}, {Name: "HR"}},
+	})
+	brazil := addTestCountry(t, ctx, "BR")
+
+	//Requisitions of a country with a chain are not posted on creation
+	_, err := AddJobRequisition(ctx, JobRequisition{Title: "Backend Engineer", JobDescription: "Builds the services of the product", State: RequisitionOpen, JrCountryId: brazil.ID})
+	wantError[*ConflictError](t, err)
+
+	jr := submitTestRequisition(t, ctx, brazil)
+	if want := []string{"Finance", "HR"}; !reflect.DeepEqual(jr.PendingApprovals, want) {
+		t.Fatalf("PendingApprovals = %v, want %v", jr.PendingApprovals, want)
+	}
+
+	_, err = ApproveJobRequisition(ctx, jr.ID, "someone@corp.com", "")
+	wantError[*ValidationError](t, err)
+	_, err = TransitionJobRequisition(ctx, jr.ID, RequisitionOpen, "recruiter@corp.com", "")
+	wantError[*ConflictError](t, err)
+
+	if jr, err = ApproveJobRequisition(ctx, jr.ID, "CFO@corp.com", "Budgeted"); err != nil {
+		t.Fatalf("ApproveJobRequisition(Finance) error = %v", err)
+	}
+	if jr, err = ApproveJobRequisition(ctx, jr.ID, "hr@corp.com", ""); err != nil {
+		t.Fatalf("ApproveJobRequisition(HR) error = %v", err)
+	}
+	if len(jr.PendingApprovals) != 0 {
+		t.Fatalf("PendingApprovals = %v, want none", jr.PendingApprovals)
+	}
+	_, err = ApproveJobRequisition(ctx, jr.ID, "hr@corp.com", "")
+	wantError[*ConflictError](t, err)
+
+	if jr, err = TransitionJobRequisition(ctx, jr.ID, RequisitionOpen, "recruiter@corp.com", ""); err != nil {
+		t.Fatalf("TransitionJobRequisition(%v) error = %v", RequisitionOpen, err)
+	}
+	if !jr.PostingStatus {
+		t.Error("PostingStatus = false, want true once Open")
+	}
+}
+
+func TestRejectJobRequisition(t *testing.T) {
+	ctx := initMemoryStores(t)
+	SetApprovalChains([]ApprovalStep{{Name: "Finance"}, {Name: "HR"}}, nil)
+	jr := submitTestRequisition(t, ctx, addTestCountry(t, ctx, "US"))
+
+	_, err := RejectJobRequisition(ctx, jr.ID, "cfo@corp.com", "")
+	wantError[*ValidationError](t, err)
+
+	if _, err := ApproveJobRequisition(ctx, jr.ID, "cfo@corp.com", ""); err != nil {
+		t.Fatalf("ApproveJobRequisition() error = %v", err)
+	}
+	if jr, err = RejectJobRequisition(ctx, jr.ID, "hr@corp.com", "No headcount this quarter"); err != nil {
+		t.Fatalf("RejectJobRequisition() error = %v", err)
+	}
+	if jr.State != RequisitionDraft {
+		t.Errorf("State = %v, want %v", jr.State, RequisitionDraft)
+	}
+
+	//A new round asks the whole chain again, the approvals of the previous one stay on the trail
+	jr, err = TransitionJobRequisition(ctx, jr.ID, RequisitionPendingApproval, "recruiter@corp.com", "")
+	if err != nil {
+		t.Fatalf("TransitionJobRequisition() error = %v", err)
+	}
+	if want := []string{"Finance", "HR"}; !reflect.DeepEqual(jr.PendingApprovals, want) {
+		t.Errorf("PendingApprovals = %v, want %v", jr.PendingApprovals, want)
+	}
+	trail, _ := GetApprovalsOfJobRequisition(jr.ID)
+	if len(trail) != 2 || trail[0].Round != 1 || !trail[0].Approved || trail[1].Approved {
+		t.Errorf("GetApprovalsOfJobRequisition() = %+v, want the approval and the rejection of round 1", trail)
+	}
+}
+
+//JobRequisitionStore taking its time to write, so the approvals made at once read the same requisition.
+type slowRequisitionStore struct {
+	JobRequisitionStore
+}
+
+func (s slowRequisitionStore) Update(ctx context.Context, jr JobRequisition) error {
+	time.Sleep(10 * time.Millisecond)
+	return s.JobRequisitionStore.Update(ctx, jr)
+}
+
+//A step approved at once by two of its approvers is approved once, the other approval is rejected rather than lost.
+func TestApproveJobRequisitionConcurrent(t *testing.T) {
+	ctx := initMemoryStores(t)
+	SetApprovalChains(nil, map[string][]ApprovalStep{
+		"BR": {{Name: "Finance", Approvers: []string{"cfo@corp.com", "controller@corp.com"}}, {Name: "HR"}},
+	})
+	jr := submitTestRequisition(t, ctx, addTestCountry(t, ctx, "BR"))
+	stores.JobRequisitions = slowRequisitionStore{stores.JobRequisitions}
+
+	approvers := []string{"cfo@corp.com", "controller@corp.com"}
+	errs := make([]error, len(approvers))
+	var wg sync.WaitGroup
+	for i, by := range approvers {
+		wg.Add(1)
+		go func(i int, by string) {
+			defer wg.Done()
+			_, errs[i] = ApproveJobRequisition(ctx, jr.ID, by, "")
+		}(i, by)
+	}
+	wg.Wait()
+
+	approved := 0
+	for _, err := range errs {
+		if err == nil {
+			approved++
+		} else {
+			wantError[*ConflictError](t, err)
+		}
+	}
+	if approved != 1 {
+		t.Fatalf("%v approvals succeeded, want 1", approved)
+	}
+
+	stored, err := stores.JobRequisitions.FindAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 || len(stored[0].Approvals) != 1 {
+		t.Fatalf("stored Approvals = %+v, want the Finance step approved once", stored)
+	}
+	if got, _ := GetJobRequisitionByID(jr.ID); !reflect.DeepEqual(got.PendingApprovals, []string{"HR"}) {
+		t.Errorf("PendingApprovals = %v, want [HR]", got.PendingApprovals)
+	}
+}
+
+//A requisition rejected by another replica is not approved from the copy cached before the rejection.
+func TestApproveJobRequisitionStale(t *testing.T) {
+	ctx := initMemoryStores(t)
+	SetApprovalChains(nil, map[string][]ApprovalStep{"BR": {{Name: "Finance"}}})
+	jr := submitTestRequisition(t, ctx, addTestCountry(t, ctx, "BR"))
+
+	//Rejected on another replica, the cache of this one is not updated yet
+	rejected, _ := records.jobRequisition(jr.ID)
+	rejected.State = RequisitionDraft
+	rejected.Version++
+	if err := stores.JobRequisitions.Update(ctx, rejected); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ApproveJobRequisition(ctx, jr.ID, "cfo@corp.com", "")
+	wantError[*ConflictError](t, err)
+
+	stored, err := stores.JobRequisitions.FindAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 || stored[0].State != RequisitionDraft || len(stored[0].Approvals) != 0 {
+		t.Errorf("stored = %+v, want the rejected requisition left as it is", stored)
+	}
+}
@@ -0,0 +1,281 @@
+package models
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"webservice/resume"
+	"webservice/validate"
+)
+
+//Kinds of Attachment.
+const (
+	AttachmentResume      = "Resume"
+	AttachmentCoverLetter = "CoverLetter"
+	AttachmentPortfolio   = "Portfolio"
+	AttachmentOther       = "Other"
+)
+
+//Attachment describes a file uploaded for a Candidate, such as a CV, a cover letter or a portfolio.
+//The content is kept on the ContentStore once, however many attachments have the same checksum.
+type Attachment struct {
+	ID          int
+	CandidateID int    `validate:"required"`
+	Kind        string `validate:"required,oneof=Resume CoverLetter Portfolio Other"`
+	FileName    string `validate:"required,max=255"`
+	//MIME type sniffed from the content, the one sent on the upload is ignored.
+	ContentType string
+	Size        int64
+	//SHA-256 of the content, in hexadecimal.
+	Checksum   string
+	UploadedBy string
+	UploadedAt time.Time
+	//Plain text of the documents resume.Extract reads, kept for the suggestions and not returned with the Attachment.
+	Text string `json:"-"`
+}
+
+//Longest a document is read for its text on an upload, the upload is accepted without the text past it.
+const extractTimeout = 5 * time.Second
+
+//Limits of the files uploaded, set at startup by SetAttachmentRules.
+var attachmentRules struct {
+	maxBytes     int64
+	allowedTypes map[string]bool
+}
+
+//SetAttachmentRules sets the size of the largest file accepted and the MIME types accepted.
+//Must be called before the controllers start serving requests.
+func SetAttachmentRules(maxBytes int64, allowedTypes []string) {
+	attachmentRules.maxBytes = maxBytes
+	attachmentRules.allowedTypes = make(map[string]bool)
+	for _, t := range allowedTypes {
+		attachmentRules.allowedTypes[strings.ToLower(t)] = true
+	}
+}
+
+//Returns the size of the largest file accepted on an upload.
+func MaxAttachmentBytes() int64 {
+	return attachmentRules.maxBytes
+}
+
+//In Memory: Returns the Attachment of the Candidate, in the order they were uploaded.
+//Returns a list of Attachment and an error in case the Candidate does not exist
+func GetAttachmentsOfCandidate(id int) ([]Attachment, error) {
+	if _, found := records.candidate(id); !found {
+		return nil, notFound("Candidate with id '%v' not found", id)
+	}
+	return records.attachmentsOfCandidate(id), nil
+}
+
+//In Memory: Searches for a specific Attachment of the Candidate.
+//Returns a Attachment object and an error in case it was not possible to find the record
+func GetAttachmentByID(candidateID int, id int) (Attachment, error) {
+	if a, found := records.attachment(id); found && a.CandidateID == candidateID {
+		return a, nil
+	}
+	return Attachment{}, notFound("Attachment with ID '%v' not found for Candidate '%v'", id, candidateID)
+}
+
+//In DB: Opens the content of an Attachment of the Candidate, which must be closed by the caller.
+//Returns the Attachment, its content and an error in case it was not possible to find either of them
+func OpenAttachment(ctx context.Context, candidateID int, id int) (Attachment, io.ReadCloser, error) {
+	a, err := GetAttachmentByID(candidateID, id)
+	if err != nil {
+		return Attachment{}, nil, err
+	}
+
+	content, err := stores.Contents.Open(ctx, a.Checksum)
+	if err != nil {
+		return Attachment{}, nil, err
+	}
+	return a, content, nil
+}
+
+//In DB: Stores the content uploaded for the Candidate and creates the Attachment describing it.
+//The type of the file is sniffed from the content and must be one of the allowed types.
+//Returns a Attachment object and an error in case the file is not accepted or the Candidate already has the same file
+func AddAttachment(ctx context.Context, a Attachment, content []byte) (Attachment, error) {
+	if _, found := records.candidate(a.CandidateID); !found {
+		return Attachment{}, notFound("Candidate with id '%v' not found", a.CandidateID)
+	}
+
+	if a.Kind == "" {
+		a.Kind = AttachmentOther
+	}
+	a.FileName = filepath.Base(filepath.Clean("/" + strings.ReplaceAll(a.FileName, "\\", "/")))
+	if a.FileName == "/" {
+		a.FileName = ""
+	}
+	a.ContentType = sniffContentType(a.FileName, content)
+	a.Size = int64(len(content))
+
+	var v validate.Validator
+	v.Check(a.ID == 0, "ID", "Attachment must not contain ID upon creation")
+	v.Struct(a)
+	v.Check(a.Size > 0, "File", "should not be empty")
+	v.Check(a.Size <= attachmentRules.maxBytes, "File", "must not exceed %v bytes", attachmentRules.maxBytes)
+	v.Check(a.Size == 0 || attachmentRules.allowedTypes[mediaType(a.ContentType)], "File", "type '%v' is not accepted", a.ContentType)
+	if err := invalid("Attachment is not valid", &v); err != nil {
+		return Attachment{}, err
+	}
+
+	sum := sha256.Sum256(content)
+	a.Checksum = hex.EncodeToString(sum[:])
+	for _, other := range records.attachmentsOfCandidate(a.CandidateID) {
+		if other.Checksum == a.Checksum {
+			return Attachment{}, conflict("Candidate '%v' already has the same file as Attachment '%v'", a.CandidateID, other.ID)
+		}
+	}
+
+	//A document that cannot be read is still accepted, it just has no text to suggest from.
+	//Read before the content is stored, so a document failing to read leaves nothing behind
+	extractCtx, cancel := context.WithTimeout(ctx, extractTimeout)
+	text, err := resume.Extract(extractCtx, a.ContentType, content)
+	cancel()
+	if err != nil && err != resume.ErrUnsupported {
+		log.Printf("Could not extract text of %v: %v", a.FileName, err)
+	}
+	a.Text = text
+
+	//Saving the content kept for another Attachment does nothing, so it is saved on every upload
+	//rather than trusting the cache of this replica to know whether it is kept already
+	if err := stores.Contents.Save(ctx, a.Checksum, content); err != nil {
+		return Attachment{}, err
+	}
+
+	id, err := nextID(ctx, attachmentSequence)
+	if err != nil {
+		removeUnusedContent(ctx, a.Checksum)
+		return Attachment{}, err
+	}
+	a.ID = id
+	a.UploadedAt = time.Now().UTC()
+
+	if err = stores.Attachments.Insert(ctx, a); err != nil {
+		removeUnusedContent(ctx, a.Checksum)
+		return Attachment{}, err
+	}
+
+	records.putAttachment(a)
+	return a, nil
+}
+
+//In DB: Removes an Attachment of the Candidate, and its content when no other Attachment has the same checksum.
+//Returns error if failed to complete the deletion on the DB
+func DeleteAttachment(ctx context.Context, candidateID int, id int) error {
+	a, err := GetAttachmentByID(candidateID, id)
+	if err != nil {
+		return err
+	}
+	return deleteAttachment(ctx, a)
+}
+
+//In DB: Removes every Attachment of a specified Candidate.
+//Returns error on the first Attachment that could not be deleted, the ones before it are already deleted
+func DeleteAttachmentsOfCandidate(ctx context.Context, id int) error {
+	for _, a := range records.attachmentsOfCandidate(id) {
+		if err := deleteAttachment(ctx, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//The record is removed first, then the content when the store has no Attachment left with the checksum.
+func deleteAttachment(ctx context.Context, a Attachment) error {
+	if err := stores.Attachments.Delete(ctx, a.ID); err != nil {
+		return err
+	}
+	records.removeAttachment(a.ID)
+
+	removeUnusedContent(ctx, a.Checksum)
+	return nil
+}
+
+//Removes the content kept under the checksum when the store has no Attachment with it.
+//The attachments are counted on the store rather than on the cache, which misses the ones other replicas just wrote.
+//Content that could not be checked or removed is only logged, it is harmless with no Attachment referring to it.
+func removeUnusedContent(ctx context.Context, checksum string) {
+	used, err := stores.Attachments.HasChecksum(ctx, checksum)
+	if err == nil && !used {
+		err = stores.Contents.Remove(ctx, checksum)
+	}
+	if err != nil {
+		log.Printf("Could not remove content %v: %v", checksum, err)
+	}
+}
+
+//Moves the attachments of the source Candidate to the target, dropping the ones the target already has.
+func moveAttachments(ctx context.Context, targetID int, sourceID int) error {
+	has := make(map[string]bool)
+	for _, a := range records.attachmentsOfCandidate(targetID) {
+		has[a.Checksum] = true
+	}
+
+	for _, a := range records.attachmentsOfCandidate(sourceID) {
+		if has[a.Checksum] {
+			if err := deleteAttachment(ctx, a); err != nil {
+				return err
+			}
+			continue
+		}
+
+		a.CandidateID = targetID
+		if err := stores.Attachments.Update(ctx, a); err != nil {
+			return err
+		}
+		records.putAttachment(a)
+		has[a.Checksum] = true
+	}
+	return nil
+}
+
+//Signature of the compound files legacy Office documents are written in.
+var oleSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+//Returns the MIME type of the content. Office documents, which are sniffed as zip or unknown binary files,
+//are told apart by the parts they contain or, for the legacy ones, by the extension of the file name.
+func sniffContentType(name string, content []byte) string {
+	sniffed := http.DetectContentType(content)
+	if bytes.HasPrefix(content, oleSignature) && strings.EqualFold(filepath.Ext(name), ".doc") {
+		return "application/msword"
+	}
+	if sniffed != "application/zip" {
+		return sniffed
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return sniffed
+	}
+	for _, f := range r.File {
+		switch f.Name {
+		case "word/document.xml":
+			return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+		case "xl/workbook.xml":
+			return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		case "ppt/presentation.xml":
+			return "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+		}
+	}
+	return sniffed
+}
+
+//Returns the MIME type without its parameters, such as the charset of a text/plain.
+func mediaType(contentType string) string {
+	t, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(contentType)
+	}
+	return t
+}
@@ -0,0 +1,46 @@
+package models
+
+import "testing"
+
+//The content of an Attachment is removed with the last Attachment having it on the store,
+//including the ones written by another replica and not cached yet.
+func TestDeleteAttachmentSharedContent(t *testing.T) {
+	ctx := initMemoryStores(t)
+	contents, err := NewFilesystemContentStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores.Contents = contents
+	SetAttachmentRules(1<<20, []string{"text/plain"})
+
+	jane := addTestCandidate(t, ctx, "Jane", "Doe")
+	john := addTestCandidate(t, ctx, "John", "Doe")
+	a, err := AddAttachment(ctx, Attachment{CandidateID: jane.ID, FileName: "cv.txt", UploadedBy: "recruiter"}, []byte("Go developer"))
+	if err != nil {
+		t.Fatalf("AddAttachment() error = %v", err)
+	}
+
+	//Uploaded on another replica
+	other := a
+	other.ID = a.ID + 1
+	other.CandidateID = john.ID
+	if err := stores.Attachments.Insert(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DeleteAttachment(ctx, jane.ID, a.ID); err != nil {
+		t.Fatalf("DeleteAttachment() error = %v", err)
+	}
+	content, err := stores.Contents.Open(ctx, a.Checksum)
+	if err != nil {
+		t.Fatalf("Open() error = %v, want the content of Attachment %v kept", err, other.ID)
+	}
+	content.Close()
+
+	records.putAttachment(other)
+	if err := DeleteAttachment(ctx, john.ID, other.ID); err != nil {
+		t.Fatalf("DeleteAttachment() error = %v", err)
+	}
+	_, err = stores.Contents.Open(ctx, a.Checksum)
+	wantError[*NotFoundError](t, err)
+}
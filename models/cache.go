@@ -0,0 +1,1218 @@
+package models
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"webservice/search"
+)
+
+//cache keeps every record of the stores in memory so reads never hit the Database.
+//Only the fields persisted by the stores are kept, CountryObj, JobsApplied, JobReqCountry and Applicants
+//are resolved when the record is read, so a write only has to touch the record written.
+//It is safe for concurrent use.
+type cache struct {
+	mu           sync.RWMutex
+	candidates   map[int]Candidate
+	countries    map[int]Country
+	jobReqs      map[int]JobRequisition
+	applications map[int]Application
+	tags         map[int]Tag
+	interviews   map[int]Interview
+	offers       map[int]Offer
+	merges       map[int]CandidateMerge
+	attachments  map[int]Attachment
+
+	//Secondary indexes
+	appsByCandidate        map[int]map[int]bool
+	appsByJobReq           map[int]map[int]bool
+	tagsByLabel            map[string]int
+	interviewsByApp        map[int]map[int]bool
+	offersByApp            map[int]map[int]bool
+	attachmentsByCandidate map[int]map[int]bool
+
+	//Prefix search, by the names and email of the candidates and the labels of the tags
+	candidatePrefixes *search.Index[int]
+	tagPrefixes       *search.Index[int]
+	//Full text search of the candidates, see candidateDocument
+	candidateText *search.FullText[int]
+}
+
+//Records of every entity, shared by the whole models package.
+var records = newCache()
+
+func newCache() *cache {
+	return &cache{
+		candidates:             make(map[int]Candidate),
+		countries:              make(map[int]Country),
+		jobReqs:                make(map[int]JobRequisition),
+		applications:           make(map[int]Application),
+		tags:                   make(map[int]Tag),
+		interviews:             make(map[int]Interview),
+		offers:                 make(map[int]Offer),
+		merges:                 make(map[int]CandidateMerge),
+		attachments:            make(map[int]Attachment),
+		appsByCandidate:        make(map[int]map[int]bool),
+		appsByJobReq:           make(map[int]map[int]bool),
+		tagsByLabel:            make(map[string]int),
+		interviewsByApp:        make(map[int]map[int]bool),
+		offersByApp:            make(map[int]map[int]bool),
+		attachmentsByCandidate: make(map[int]map[int]bool),
+		candidatePrefixes:      search.NewIndex[int](),
+		tagPrefixes:            search.NewIndex[int](),
+		candidateText:          newCandidateFullText(),
+	}
+}
+
+//Returns the keys of the map sorted, so the lists are always returned in the order the records were created.
+func sortedIDs(n int, each func(add func(id int))) []int {
+	ids := make([]int, 0, n)
+	each(func(id int) {
+		ids = append(ids, id)
+	})
+	sort.Ints(ids)
+	return ids
+}
+
+//Candidate
+
+func (c *cache) candidate(id int) (Candidate, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	can, found := c.candidates[id]
+	if !found {
+		return Candidate{}, false
+	}
+	return c.resolveCandidate(can), true
+}
+
+func (c *cache) allCandidates() []Candidate {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := sortedIDs(len(c.candidates), func(add func(int)) {
+		for id := range c.candidates {
+			add(id)
+		}
+	})
+
+	ret := make([]Candidate, 0, len(ids))
+	for _, id := range ids {
+		ret = append(ret, c.resolveCandidate(c.candidates[id]))
+	}
+	return ret
+}
+
+func (c *cache) putCandidate(can Candidate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	can = storedCandidate(can)
+	c.candidates[can.ID] = can
+	c.candidatePrefixes.Set(can.ID, can.FirstName, can.LastName, can.Email)
+	c.indexCandidate(can.ID)
+}
+
+func (c *cache) removeCandidate(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.candidates, id)
+	c.candidatePrefixes.Delete(id)
+	c.candidateText.Delete(id)
+}
+
+//Indexes the text of the Candidate, its country and its attachments for the full text search.
+//Must be called holding the lock.
+func (c *cache) indexCandidate(id int) {
+	can, found := c.candidates[id]
+	if !found {
+		return
+	}
+
+	var attachments []Attachment
+	for aid := range c.attachmentsByCandidate[id] {
+		attachments = append(attachments, c.attachments[aid])
+	}
+	var tags []string
+	for _, t := range can.Tags {
+		tags = append(tags, c.tagSearchLabels(t)...)
+	}
+	c.candidateText.Set(id, candidateDocument(can, c.countries[can.CanCountryId], tags, attachments))
+}
+
+//Returns the candidates matching the query of the full text search, the most relevant first.
+func (c *cache) searchCandidates(q search.Query) []CandidateHit {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	hits := c.candidateText.Search(q)
+	ret := make([]CandidateHit, 0, len(hits))
+	for _, h := range hits {
+		ret = append(ret, CandidateHit{Candidate: c.resolveCandidate(c.candidates[h.Value]), Score: h.Score})
+	}
+	return ret
+}
+
+//Returns up to k candidates with a name or email starting with each word of the query, best matches first.
+func (c *cache) suggestCandidates(query string, k int) []Candidate {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := c.candidatePrefixes.Suggest(query, k)
+	ret := make([]Candidate, 0, len(ids))
+	for _, id := range ids {
+		ret = append(ret, c.resolveCandidate(c.candidates[id]))
+	}
+	return ret
+}
+
+//Must be called holding the lock.
+func (c *cache) resolveCandidate(can Candidate) Candidate {
+	can.Tags = append([]Tag(nil), can.Tags...)
+	can.CountryObj = c.countries[can.CanCountryId]
+	can.JobsApplied = c.applicationsIn(c.appsByCandidate[can.ID])
+	return can
+}
+
+//Country
+
+func (c *cache) country(id int) (Country, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cnt, found := c.countries[id]
+	return cnt, found
+}
+
+func (c *cache) allCountries() []Country {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := sortedIDs(len(c.countries), func(add func(int)) {
+		for id := range c.countries {
+			add(id)
+		}
+	})
+
+	ret := make([]Country, 0, len(ids))
+	for _, id := range ids {
+		ret = append(ret, c.countries[id])
+	}
+	return ret
+}
+
+func (c *cache) putCountry(cnt Country) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.countries[cnt.ID] = cnt
+	//The name and code of the country are searched on its candidates
+	for id, can := range c.candidates {
+		if can.CanCountryId == cnt.ID {
+			c.indexCandidate(id)
+		}
+	}
+}
+
+func (c *cache) removeCountry(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.countries, id)
+}
+
+//JobRequisition
+
+func (c *cache) jobRequisition(id int) (JobRequisition, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	jr, found := c.jobReqs[id]
+	if !found {
+		return JobRequisition{}, false
+	}
+	return c.resolveJobRequisition(jr), true
+}
+
+func (c *cache) allJobRequisitions() []JobRequisition {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := sortedIDs(len(c.jobReqs), func(add func(int)) {
+		for id := range c.jobReqs {
+			add(id)
+		}
+	})
+
+	ret := make([]JobRequisition, 0, len(ids))
+	for _, id := range ids {
+		ret = append(ret, c.resolveJobRequisition(c.jobReqs[id]))
+	}
+	return ret
+}
+
+func (c *cache) putJobRequisition(jr JobRequisition) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	jr = storedJobRequisition(jr)
+	c.jobReqs[jr.ID] = jr
+}
+
+func (c *cache) removeJobRequisition(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.jobReqs, id)
+}
+
+//Must be called holding the lock.
+func (c *cache) resolveJobRequisition(jr JobRequisition) JobRequisition {
+	//Requisitions stored before the lifecycle only have the PostingStatus
+	if jr.State == "" && jr.PostingStatus {
+		jr.State = RequisitionOpen
+	} else if jr.State == "" {
+		jr.State = RequisitionDraft
+	}
+	jr.RequiredTags = append([]Tag(nil), jr.RequiredTags...)
+	jr.PreferredTags = append([]Tag(nil), jr.PreferredTags...)
+	jr.JobReqCountry = c.countries[jr.JrCountryId]
+	jr.Applicants = c.applicationsIn(c.appsByJobReq[jr.ID])
+	jr.ApplicantsByStage = countByStage(jr.Applicants)
+	jr.PendingApprovals = pendingApprovals(jr)
+	return jr
+}
+
+//Application
+
+func (c *cache) application(id int) (Application, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	a, found := c.applications[id]
+	return a, found
+}
+
+func (c *cache) allApplications() []Application {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := sortedIDs(len(c.applications), func(add func(int)) {
+		for id := range c.applications {
+			add(id)
+		}
+	})
+
+	ret := make([]Application, 0, len(ids))
+	for _, id := range ids {
+		ret = append(ret, c.applications[id])
+	}
+	return ret
+}
+
+func (c *cache) applicationsOfCandidate(id int) []Application {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.applicationsIn(c.appsByCandidate[id])
+}
+
+func (c *cache) applicationsOfJobReq(id int) []Application {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.applicationsIn(c.appsByJobReq[id])
+}
+
+func (c *cache) putApplication(a Application) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	a = storedApplication(a)
+	c.unindexApplication(a.ID)
+	c.applications[a.ID] = a
+	addToIndex(c.appsByCandidate, a.CandidateProfileID, a.ID)
+	addToIndex(c.appsByJobReq, a.JobRequisitionID, a.ID)
+}
+
+func (c *cache) removeApplication(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.unindexApplication(id)
+	delete(c.applications, id)
+}
+
+//Must be called holding the lock.
+func (c *cache) unindexApplication(id int) {
+	if old, found := c.applications[id]; found {
+		removeFromIndex(c.appsByCandidate, old.CandidateProfileID, id)
+		removeFromIndex(c.appsByJobReq, old.JobRequisitionID, id)
+	}
+}
+
+//Must be called holding the lock.
+func (c *cache) applicationsIn(ids map[int]bool) []Application {
+	sorted := sortedIDs(len(ids), func(add func(int)) {
+		for id := range ids {
+			add(id)
+		}
+	})
+
+	ret := make([]Application, 0, len(sorted))
+	for _, id := range sorted {
+		ret = append(ret, c.applications[id])
+	}
+	return ret
+}
+
+func addToIndex(index map[int]map[int]bool, key int, id int) {
+	if index[key] == nil {
+		index[key] = make(map[int]bool)
+	}
+	index[key][id] = true
+}
+
+func removeFromIndex(index map[int]map[int]bool, key int, id int) {
+	delete(index[key], id)
+	if len(index[key]) == 0 {
+		delete(index, key)
+	}
+}
+
+//Tag
+
+func (c *cache) tag(id int) (Tag, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	t, found := c.tags[id]
+	return t, found
+}
+
+//Returns the Tag with the label or synonym, regardless of case.
+func (c *cache) tagByLabel(label string) (Tag, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	id, found := c.tagsByLabel[tagKey(label)]
+	if !found {
+		return Tag{}, false
+	}
+	return c.tags[id], true
+}
+
+func (c *cache) allTags() []Tag {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := sortedIDs(len(c.tags), func(add func(int)) {
+		for id := range c.tags {
+			add(id)
+		}
+	})
+
+	ret := make([]Tag, 0, len(ids))
+	for _, id := range ids {
+		ret = append(ret, c.tags[id])
+	}
+	return ret
+}
+
+func (c *cache) putTag(t Tag) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.removeTagLabels(t.ID)
+	c.tags[t.ID] = t
+	for _, l := range append([]string{t.Label}, t.Synonyms...) {
+		c.tagsByLabel[tagKey(l)] = t.ID
+	}
+	c.tagPrefixes.Set(t.ID, append([]string{t.Label}, t.Synonyms...)...)
+
+	//The candidates carrying the tag or a tag under it are searched by its labels
+	for _, cid := range c.candidatesWithTags(c.tagDescendants(t.ID)) {
+		c.indexCandidate(cid)
+	}
+}
+
+//Removes the labels and synonyms of the tag from the labels index, the ones taken by another tag are kept.
+//Must be called holding the lock.
+func (c *cache) removeTagLabels(id int) {
+	old, found := c.tags[id]
+	if !found {
+		return
+	}
+	for _, l := range append([]string{old.Label}, old.Synonyms...) {
+		if c.tagsByLabel[tagKey(l)] == id {
+			delete(c.tagsByLabel, tagKey(l))
+		}
+	}
+}
+
+//Returns the ID of the Tag a Candidate carries, by its ID or, for the ones stored without it, by its label.
+//Must be called holding the lock.
+func (c *cache) tagIDOf(t Tag) (int, bool) {
+	if _, found := c.tags[t.ID]; found && t.ID != 0 {
+		return t.ID, true
+	}
+	id, found := c.tagsByLabel[tagKey(t.Label)]
+	return id, found
+}
+
+//Returns the IDs of the parent of the tag, of its parent and so on up to the root.
+//Must be called holding the lock.
+func (c *cache) tagAncestors(id int) []int {
+	var ret []int
+	visited := map[int]bool{id: true}
+	for {
+		t, found := c.tags[id]
+		if !found || t.ParentID == 0 || visited[t.ParentID] {
+			return ret
+		}
+		id = t.ParentID
+		visited[id] = true
+		ret = append(ret, id)
+	}
+}
+
+//Returns the ID of the tag and of every tag under it, by children, grandchildren and so on.
+//Must be called holding the lock.
+func (c *cache) tagDescendants(id int) map[int]bool {
+	ret := map[int]bool{id: true}
+	for tid := range c.tags {
+		for _, a := range c.tagAncestors(tid) {
+			if a == id {
+				ret[tid] = true
+				break
+			}
+		}
+	}
+	return ret
+}
+
+//Returns whether the tag with the ID ancestor is the tag id or one of its parents.
+func (c *cache) isTagAncestor(ancestor int, id int) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if ancestor == id {
+		return true
+	}
+	for _, a := range c.tagAncestors(id) {
+		if a == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+//Returns the tags with the tag with the ID as their parent, in the order they were created.
+func (c *cache) childTags(id int) []Tag {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := sortedIDs(0, func(add func(int)) {
+		for tid, t := range c.tags {
+			if t.ParentID == id {
+				add(tid)
+			}
+		}
+	})
+
+	ret := make([]Tag, 0, len(ids))
+	for _, tid := range ids {
+		ret = append(ret, c.tags[tid])
+	}
+	return ret
+}
+
+//Returns the labels a candidate carrying the tag is searched by: the labels and synonyms of the tag and of its ancestors.
+//Must be called holding the lock.
+func (c *cache) tagSearchLabels(t Tag) []string {
+	id, found := c.tagIDOf(t)
+	if !found {
+		return []string{t.Label}
+	}
+
+	var ret []string
+	for _, tid := range append([]int{id}, c.tagAncestors(id)...) {
+		ret = append(ret, c.tags[tid].Label)
+		ret = append(ret, c.tags[tid].Synonyms...)
+	}
+	return ret
+}
+
+//Returns the IDs of the candidates carrying any of the tags.
+//Must be called holding the lock.
+func (c *cache) candidatesWithTags(ids map[int]bool) []int {
+	var ret []int
+	for cid, can := range c.candidates {
+		for _, t := range can.Tags {
+			if tid, found := c.tagIDOf(t); found && ids[tid] {
+				ret = append(ret, cid)
+				break
+			}
+		}
+	}
+	return ret
+}
+
+//Returns the number of candidates carrying each tag, by ID.
+func (c *cache) tagUsage() map[int]int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ret := make(map[int]int)
+	for _, can := range c.candidates {
+		counted := make(map[int]bool)
+		for _, t := range can.Tags {
+			if id, found := c.tagIDOf(t); found && !counted[id] {
+				counted[id] = true
+				ret[id]++
+			}
+		}
+	}
+	return ret
+}
+
+//Returns the candidates carrying the tag with the ID, in the order they were created.
+func (c *cache) candidatesWithTag(id int) []Candidate {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := sortedIDs(len(c.candidates), func(add func(int)) {
+		for cid, can := range c.candidates {
+			for _, t := range can.Tags {
+				if tid, found := c.tagIDOf(t); found && tid == id {
+					add(cid)
+					break
+				}
+			}
+		}
+	})
+
+	ret := make([]Candidate, 0, len(ids))
+	for _, cid := range ids {
+		ret = append(ret, c.resolveCandidate(c.candidates[cid]))
+	}
+	return ret
+}
+
+//Returns the requisitions requiring or preferring the tag with the ID, in the order they were created.
+func (c *cache) jobRequisitionsWithTag(id int) []JobRequisition {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := sortedIDs(0, func(add func(int)) {
+		for jid, jr := range c.jobReqs {
+			for _, t := range append(append([]Tag(nil), jr.RequiredTags...), jr.PreferredTags...) {
+				if tid, found := c.tagIDOf(t); found && tid == id {
+					add(jid)
+					break
+				}
+			}
+		}
+	})
+
+	ret := make([]JobRequisition, 0, len(ids))
+	for _, jid := range ids {
+		ret = append(ret, c.resolveJobRequisition(c.jobReqs[jid]))
+	}
+	return ret
+}
+
+//Returns the tags satisfied by the tags a candidate carries, by ID, with the tag carried satisfying each.
+//A tag is satisfied by itself and by any tag under it, so a candidate tagged Go satisfies Backend.
+func (c *cache) satisfiedTags(carried []Tag) map[int]Tag {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ret := make(map[int]Tag)
+	for _, t := range carried {
+		id, found := c.tagIDOf(t)
+		if !found {
+			continue
+		}
+		//The tag itself is kept over a child satisfying it
+		ret[id] = c.tags[id]
+		for _, a := range c.tagAncestors(id) {
+			if _, found := ret[a]; !found {
+				ret[a] = c.tags[id]
+			}
+		}
+	}
+	return ret
+}
+
+func (c *cache) removeTag(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	//Read before the removal, the candidates carrying the tag are no longer found by its labels afterwards
+	affected := c.candidatesWithTags(c.tagDescendants(id))
+	c.removeTagLabels(id)
+	delete(c.tags, id)
+	c.tagPrefixes.Delete(id)
+	for _, cid := range affected {
+		c.indexCandidate(cid)
+	}
+}
+
+//Returns up to k tags with a word of the label starting with each word of the query, best matches first.
+func (c *cache) suggestTags(query string, k int) []Tag {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := c.tagPrefixes.Suggest(query, k)
+	ret := make([]Tag, 0, len(ids))
+	for _, id := range ids {
+		ret = append(ret, c.tags[id])
+	}
+	return ret
+}
+
+//Interview
+
+func (c *cache) interview(id int) (Interview, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	i, found := c.interviews[id]
+	return i, found
+}
+
+func (c *cache) allInterviews() []Interview {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := sortedIDs(len(c.interviews), func(add func(int)) {
+		for id := range c.interviews {
+			add(id)
+		}
+	})
+
+	ret := make([]Interview, 0, len(ids))
+	for _, id := range ids {
+		ret = append(ret, c.interviews[id])
+	}
+	return ret
+}
+
+func (c *cache) interviewsOfApplication(id int) []Interview {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ret := make([]Interview, 0, len(c.interviewsByApp[id]))
+	for iid := range c.interviewsByApp[id] {
+		ret = append(ret, c.interviews[iid])
+	}
+	sortInterviews(ret)
+	return ret
+}
+
+func (c *cache) putInterview(i Interview) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if old, found := c.interviews[i.ID]; found {
+		removeFromIndex(c.interviewsByApp, old.ApplicationID, i.ID)
+	}
+	i = storedInterview(i)
+	c.interviews[i.ID] = i
+	addToIndex(c.interviewsByApp, i.ApplicationID, i.ID)
+}
+
+func (c *cache) removeInterview(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if old, found := c.interviews[id]; found {
+		removeFromIndex(c.interviewsByApp, old.ApplicationID, id)
+	}
+	delete(c.interviews, id)
+}
+
+//Offer
+
+func (c *cache) offer(id int) (Offer, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	o, found := c.offers[id]
+	return o, found
+}
+
+func (c *cache) allOffers() []Offer {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := sortedIDs(len(c.offers), func(add func(int)) {
+		for id := range c.offers {
+			add(id)
+		}
+	})
+
+	ret := make([]Offer, 0, len(ids))
+	for _, id := range ids {
+		ret = append(ret, c.offers[id])
+	}
+	return ret
+}
+
+func (c *cache) offersOfApplication(id int) []Offer {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := sortedIDs(len(c.offersByApp[id]), func(add func(int)) {
+		for oid := range c.offersByApp[id] {
+			add(oid)
+		}
+	})
+
+	ret := make([]Offer, 0, len(ids))
+	for _, oid := range ids {
+		ret = append(ret, c.offers[oid])
+	}
+	return ret
+}
+
+func (c *cache) putOffer(o Offer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if old, found := c.offers[o.ID]; found {
+		removeFromIndex(c.offersByApp, old.ApplicationID, o.ID)
+	}
+	c.offers[o.ID] = o
+	addToIndex(c.offersByApp, o.ApplicationID, o.ID)
+}
+
+func (c *cache) removeOffer(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if old, found := c.offers[id]; found {
+		removeFromIndex(c.offersByApp, old.ApplicationID, id)
+	}
+	delete(c.offers, id)
+}
+
+//CandidateMerge
+
+func (c *cache) allMerges() []CandidateMerge {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := sortedIDs(len(c.merges), func(add func(int)) {
+		for id := range c.merges {
+			add(id)
+		}
+	})
+
+	ret := make([]CandidateMerge, 0, len(ids))
+	for _, id := range ids {
+		ret = append(ret, c.merges[id])
+	}
+	return ret
+}
+
+func (c *cache) putMerge(m CandidateMerge) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.merges[m.ID] = m
+}
+
+//Attachment
+
+func (c *cache) attachment(id int) (Attachment, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	a, found := c.attachments[id]
+	return a, found
+}
+
+func (c *cache) allAttachments() []Attachment {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := sortedIDs(len(c.attachments), func(add func(int)) {
+		for id := range c.attachments {
+			add(id)
+		}
+	})
+
+	ret := make([]Attachment, 0, len(ids))
+	for _, id := range ids {
+		ret = append(ret, c.attachments[id])
+	}
+	return ret
+}
+
+func (c *cache) attachmentsOfCandidate(id int) []Attachment {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := sortedIDs(len(c.attachmentsByCandidate[id]), func(add func(int)) {
+		for aid := range c.attachmentsByCandidate[id] {
+			add(aid)
+		}
+	})
+
+	ret := make([]Attachment, 0, len(ids))
+	for _, aid := range ids {
+		ret = append(ret, c.attachments[aid])
+	}
+	return ret
+}
+
+func (c *cache) putAttachment(a Attachment) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	old, existed := c.attachments[a.ID]
+	c.unindexAttachment(a.ID)
+	c.attachments[a.ID] = a
+	addToIndex(c.attachmentsByCandidate, a.CandidateID, a.ID)
+
+	//The text of the resumes is searched on their candidates
+	if existed && old.CandidateID != a.CandidateID {
+		c.indexCandidate(old.CandidateID)
+	}
+	c.indexCandidate(a.CandidateID)
+}
+
+func (c *cache) removeAttachment(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	old, found := c.attachments[id]
+	c.unindexAttachment(id)
+	delete(c.attachments, id)
+	if found {
+		c.indexCandidate(old.CandidateID)
+	}
+}
+
+//Must be called holding the lock.
+func (c *cache) unindexAttachment(id int) {
+	if old, found := c.attachments[id]; found {
+		removeFromIndex(c.attachmentsByCandidate, old.CandidateID, id)
+	}
+}
+
+//Orders the interviews by start time, then by ID.
+func sortInterviews(list []Interview) {
+	sort.Slice(list, func(a, b int) bool {
+		if !list[a].Start.Equal(list[b].Start) {
+			return list[a].Start.Before(list[b].Start)
+		}
+		return list[a].ID < list[b].ID
+	})
+}
+
+//Synchronization with the stores
+
+//Returns the Candidate as the cache keeps it, with only the fields persisted and its own copy of the tags.
+func storedCandidate(can Candidate) Candidate {
+	can.CountryObj = Country{}
+	can.JobsApplied = nil
+	can.Tags = append([]Tag(nil), can.Tags...)
+	return can
+}
+
+//Returns the JobRequisition as the cache keeps it, with only the fields persisted and its own copy of the tags.
+func storedJobRequisition(jr JobRequisition) JobRequisition {
+	jr.JobReqCountry = Country{}
+	jr.Applicants = nil
+	jr.ApplicantsByStage = nil
+	jr.PendingApprovals = nil
+	jr.RequiredTags = append([]Tag(nil), jr.RequiredTags...)
+	jr.PreferredTags = append([]Tag(nil), jr.PreferredTags...)
+	return jr
+}
+
+//Returns the Application as the cache keeps it.
+func storedApplication(a Application) Application {
+	//Applications stored before withdrawals existed have no status
+	if a.Status == "" {
+		a.Status = ApplicationActive
+	}
+	return a
+}
+
+//Returns the Interview as the cache keeps it, with its own copy of the interviewers.
+func storedInterview(i Interview) Interview {
+	i.Interviewers = append([]Interviewer(nil), i.Interviewers...)
+	return i
+}
+
+//Returns whether the record read from the stores is the one kept on byID, so putting it again would change nothing.
+//The record must be given as the cache keeps it, see storedCandidate.
+func unchanged[T any](c *cache, byID map[int]T, id int, v T) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cached, found := byID[id]
+	return found && reflect.DeepEqual(cached, v)
+}
+
+//Returns the IDs of the records cached on byID.
+func cachedIDs[T any](c *cache, byID map[int]T) map[int]bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ret := make(map[int]bool, len(byID))
+	for id := range byID {
+		ret[id] = true
+	}
+	return ret
+}
+
+//Reads every record of the collection from its store and applies the differences to the cache.
+//The records equal to the cached ones are skipped, as putting them again would rebuild their indexes for nothing.
+//Only the records cached before the store was read are removed when missing from it,
+//the ones put meanwhile were written after the read and are kept.
+//Returns the biggest ID found on the collection.
+func syncCollection(ctx context.Context, collection string) (int, error) {
+	biggestId := 0
+	seen := make(map[int]bool)
+	track := func(id int) {
+		seen[id] = true
+		if id > biggestId {
+			biggestId = id
+		}
+	}
+
+	switch collection {
+	case countrySequence:
+		before := cachedIDs(records, records.countries)
+		results, err := stores.Countries.FindAll(ctx)
+		if err != nil {
+			return -1, err
+		}
+		for _, v := range results {
+			track(v.ID)
+			if !unchanged(records, records.countries, v.ID, v) {
+				records.putCountry(v)
+			}
+		}
+		for id := range before {
+			if !seen[id] {
+				records.removeCountry(id)
+			}
+		}
+	case tagSequence:
+		before := cachedIDs(records, records.tags)
+		results, err := stores.Tags.FindAll(ctx)
+		if err != nil {
+			return -1, err
+		}
+		for _, v := range results {
+			track(v.ID)
+			if !unchanged(records, records.tags, v.ID, v) {
+				records.putTag(v)
+			}
+		}
+		for id := range before {
+			if !seen[id] {
+				records.removeTag(id)
+			}
+		}
+	case applicationSequence:
+		before := cachedIDs(records, records.applications)
+		results, err := stores.Applications.FindAll(ctx)
+		if err != nil {
+			return -1, err
+		}
+		for _, v := range results {
+			track(v.ID)
+			if !unchanged(records, records.applications, v.ID, storedApplication(v)) {
+				records.putApplication(v)
+			}
+		}
+		for id := range before {
+			if !seen[id] {
+				records.removeApplication(id)
+			}
+		}
+	case interviewSequence:
+		before := cachedIDs(records, records.interviews)
+		results, err := stores.Interviews.FindAll(ctx)
+		if err != nil {
+			return -1, err
+		}
+		for _, v := range results {
+			track(v.ID)
+			if !unchanged(records, records.interviews, v.ID, storedInterview(v)) {
+				records.putInterview(v)
+			}
+		}
+		for id := range before {
+			if !seen[id] {
+				records.removeInterview(id)
+			}
+		}
+	case offerSequence:
+		before := cachedIDs(records, records.offers)
+		results, err := stores.Offers.FindAll(ctx)
+		if err != nil {
+			return -1, err
+		}
+		for _, v := range results {
+			track(v.ID)
+			if !unchanged(records, records.offers, v.ID, v) {
+				records.putOffer(v)
+			}
+		}
+		for id := range before {
+			if !seen[id] {
+				records.removeOffer(id)
+			}
+		}
+	case mergeSequence:
+		results, err := stores.Merges.FindAll(ctx)
+		if err != nil {
+			return -1, err
+		}
+		for _, v := range results {
+			track(v.ID)
+			if !unchanged(records, records.merges, v.ID, v) {
+				records.putMerge(v)
+			}
+		}
+	case attachmentSequence:
+		before := cachedIDs(records, records.attachments)
+		results, err := stores.Attachments.FindAll(ctx)
+		if err != nil {
+			return -1, err
+		}
+		for _, v := range results {
+			track(v.ID)
+			if !unchanged(records, records.attachments, v.ID, v) {
+				records.putAttachment(v)
+			}
+		}
+		for id := range before {
+			if !seen[id] {
+				records.removeAttachment(id)
+			}
+		}
+	case jobRequisitionSequence:
+		before := cachedIDs(records, records.jobReqs)
+		results, err := stores.JobRequisitions.FindAll(ctx)
+		if err != nil {
+			return -1, err
+		}
+		for _, v := range results {
+			track(v.ID)
+			if !unchanged(records, records.jobReqs, v.ID, storedJobRequisition(v)) {
+				records.putJobRequisition(v)
+			}
+		}
+		for id := range before {
+			if !seen[id] {
+				records.removeJobRequisition(id)
+			}
+		}
+	case candidateSequence:
+		before := cachedIDs(records, records.candidates)
+		results, err := stores.Candidates.FindAll(ctx)
+		if err != nil {
+			return -1, err
+		}
+		for _, v := range results {
+			track(v.ID)
+			if !unchanged(records, records.candidates, v.ID, storedCandidate(v)) {
+				records.putCandidate(v)
+			}
+		}
+		for id := range before {
+			if !seen[id] {
+				records.removeCandidate(id)
+			}
+		}
+	}
+
+	return biggestId, nil
+}
+
+//Collections kept in the cache, in the order they are loaded.
+var cachedCollections = []string{
+	countrySequence,
+	tagSequence,
+	applicationSequence,
+	interviewSequence,
+	offerSequence,
+	mergeSequence,
+	attachmentSequence,
+	jobRequisitionSequence,
+	candidateSequence,
+}
+
+//Applies a change notified by the stores to the cache.
+//Changes that do not carry the record, such as deletions, resynchronize the whole collection.
+func applyChange(ctx context.Context, ch Change) {
+	switch v := ch.Record.(type) {
+	case Candidate:
+		records.putCandidate(v)
+	case Country:
+		records.putCountry(v)
+	case JobRequisition:
+		records.putJobRequisition(v)
+	case Application:
+		records.putApplication(v)
+	case Tag:
+		records.putTag(v)
+	case Interview:
+		records.putInterview(v)
+	case Offer:
+		records.putOffer(v)
+	case CandidateMerge:
+		records.putMerge(v)
+	case Attachment:
+		records.putAttachment(v)
+	default:
+		if _, err := syncCollection(ctx, ch.Collection); err != nil {
+			log.Printf("Could not synchronize %v: %v", ch.Collection, err)
+		}
+	}
+}
+
+//Watch keeps the cache in sync with the writes made by other replicas of the service until ctx is cancelled.
+//It follows the change feed of the stores when they have one and falls back to reading them every interval.
+func Watch(ctx context.Context, interval time.Duration) {
+	if stores.Changes != nil {
+		err := stores.Changes.Watch(ctx, func(ch Change) {
+			applyChange(ctx, ch)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Change feed unavailable, polling the Database every %v: %v", interval, err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, coll := range cachedCollections {
+				if _, err := syncCollection(ctx, coll); err != nil {
+					log.Printf("Could not synchronize %v: %v", coll, err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"context"
+	"testing"
+)
+
+//Records written on the stores by another replica are picked by the polling, the ones unchanged are kept as they are.
+func TestSyncCollection(t *testing.T) {
+	ctx := initMemoryStores(t)
+	goTag := addTestTag(t, ctx, "Go", 0)
+	rust := addTestTag(t, ctx, "Rust", 0)
+	java := addTestTag(t, ctx, "Java", 0)
+
+	renamed := goTag
+	renamed.Synonyms = []string{"golang"}
+	if err := stores.Tags.Update(ctx, renamed); err != nil {
+		t.Fatal(err)
+	}
+	if err := stores.Tags.Delete(ctx, java.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	biggest, err := syncCollection(ctx, tagSequence)
+	if err != nil {
+		t.Fatalf("syncCollection() error = %v", err)
+	}
+	if biggest != rust.ID {
+		t.Errorf("syncCollection() = %v, want the biggest ID %v", biggest, rust.ID)
+	}
+	if got, err := GetTagByLabel("golang"); err != nil || got.ID != goTag.ID {
+		t.Errorf("GetTagByLabel(golang) = %v, %v, want the Tag updated on the store", got.ID, err)
+	}
+	if _, found := records.tag(java.ID); found {
+		t.Errorf("Tag %v deleted on the store is still cached", java.ID)
+	}
+	if !unchanged(records, records.tags, rust.ID, rust) {
+		t.Errorf("Tag %v not changed on the store differs from the cached one", rust.ID)
+	}
+	if suggested := SuggestTags("ru", 5); len(suggested) != 1 || suggested[0].ID != rust.ID {
+		t.Errorf("SuggestTags(ru) = %v, want the Tag kept", suggested)
+	}
+}
+
+//TagStore calling after once its records are read, to write while the cache is synchronized.
+type snapshotTagStore struct {
+	TagStore
+	after func()
+}
+
+func (s snapshotTagStore) FindAll(ctx context.Context) ([]Tag, error) {
+	ret, err := s.TagStore.FindAll(ctx)
+	s.after()
+	return ret, err
+}
+
+//Records added after the store was read are not removed as if they had been deleted on it.
+func TestSyncCollectionKeepsNewRecords(t *testing.T) {
+	ctx := initMemoryStores(t)
+	goTag := addTestTag(t, ctx, "Go", 0)
+
+	var added Tag
+	stores.Tags = snapshotTagStore{TagStore: stores.Tags, after: func() {
+		added = addTestTag(t, ctx, "Rust", 0)
+	}}
+	if _, err := syncCollection(ctx, tagSequence); err != nil {
+		t.Fatalf("syncCollection() error = %v", err)
+	}
+
+	if _, found := records.tag(added.ID); !found {
+		t.Errorf("Tag %v added while the store was read is no longer cached", added.ID)
+	}
+	if _, found := records.tag(goTag.ID); !found {
+		t.Errorf("Tag %v read from the store is no longer cached", goTag.ID)
+	}
+}
@@ -2,36 +2,30 @@ package models
 
 import (
 	"context"
-	"fmt"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo/options"
-	"webservice/db"
+
+	"webservice/validate"
 )
 
 type Candidate struct {
 	ID          int
-	FirstName   string
-	LastName    string
-	Email       string
+	FirstName   string	`validate:"required"`
+	LastName    string	`validate:"required"`
+	Email       string	`validate:"required,email"`
 	Address     string
-	Tags		[]Tag
+	Tags		[]Tag	`validate:"dive"`
 	CanCountryId 	int
 	CountryObj  Country
 	JobsApplied []Application
 }
 
-var (
-	candidates = make(map[int]*Candidate)
-	nextCanID  = updateCandidatesInMemory()
-)
-
 //In Memory: Returns the complete list of Candidate.
 //Returns a hashmap containing the list of Candidate
 func GetCandidates() []*Candidate {
 	candArr := make([]*Candidate, 0)
 
-	for _, v := range candidates {
-		candArr = append(candArr, v)
+	for _, v := range records.allCandidates() {
+		v := v
+		candArr = append(candArr, &v)
 	}
 	return candArr
 	//return candidates
@@ -40,10 +34,10 @@ func GetCandidates() []*Candidate {
 //In Memory: Searches for a specific Candidate on the hashmap.
 //Returns a Candidate object and an error in case it was not possible to find the record
 func GetCandidateByID(id int) (Candidate, error) {
-	if c, found := candidates[id]; found {
-		return *c, nil
+	if c, found := records.candidate(id); found {
+		return c, nil
 	}
-	return Candidate{}, fmt.Errorf("Candidate with ID '%v' not found", id)
+	return Candidate{}, notFound("Candidate with ID '%v' not found", id)
 }
 
 //In Memory: Returns a list of Candidate with country received as parameter.
@@ -60,252 +54,148 @@ func GetCandidatesWithCountry(c int) []Candidate{
 	return ret
 }
 
+//In Memory: Completes the query typed on a search box, each word being the prefix of a first name, last name or email.
+//Words are matched regardless of case and accents. Returns up to limit Candidates, the closest matches first
+func SuggestCandidates(query string, limit int) []Candidate {
+	return records.suggestCandidates(query, limit)
+}
+
 //In DB: Creates a new Candidate record to the collection and updates the Candidate in memory.
+//Candidates with the email of another one are rejected, as well as the ones with a similar name and address unless allowSimilar.
 //Returns a Candidate object and an error in case it was not possible to create the record
-func AddCandidate(c Candidate) (Candidate, error) {
+func AddCandidate(ctx context.Context, c Candidate, allowSimilar bool) (Candidate, error) {
 	//Validation
-	if c.ID != 0 {
-		return Candidate{}, fmt.Errorf("Candidate must not include ID")
+	var v validate.Validator
+	v.Check(c.ID == 0, "ID", "Candidate must not include ID")
+	v.Check(c.JobsApplied == nil, "JobsApplied", "A new candidate cannot have applied to jobs yet")
+	checkCandidate(&v, c)
+	if err := invalid("Candidate is not valid", &v); err != nil {
+		return Candidate{}, err
 	}
 
-	if !AlreadyExistById(c.CountryObj.ID) {
-		return Candidate{}, fmt.Errorf("Country inserted for candidate does not exist")
-	} else if c.CountryObj.ID == 0 && (c.CountryObj.Name != "" || c.CountryObj.Code != "") {
+	if c.CountryObj.ID == 0 && (c.CountryObj.Name != "" || c.CountryObj.Code != "") {
 		c.CountryObj.Name = ""
 		c.CountryObj.Code = ""
 	}
 
-	if c.JobsApplied != nil {
-		return Candidate{}, fmt.Errorf("A new candidate cannot have applied to jobs yet")
-	}
-
-	b, e := checkRequiredFields(c)
-	if b {
-		return Candidate{}, fmt.Errorf("Field: %v should be populated", e)
+	if err := checkDuplicates(c, allowSimilar); err != nil {
+		return Candidate{}, err
 	}
 
 	//Check if tags are part of the candidate creation
 	if c.Tags != nil {
 		//Validate if tag exists to Add/Reuse
-		c.Tags = ValidateTags(c.Tags)
+		c.Tags = ValidateTags(ctx, c.Tags)
 	}
 
 	//Add new Candidate
-	client, err := db.OpenConnectionToMongo()
+	id, err := nextID(ctx, candidateSequence)
 	if err != nil {
-		return Candidate{}, fmt.Errorf("Could not establish connection to Database")
+		return Candidate{}, err
 	}
-
-	coll := client.Database(db.GetDatabaseName()).Collection("Candidates")
-	doc := bson.D{
-		{"ID", nextCanID},
-		{"FirstName", c.FirstName},
-		{"LastName", c.LastName},
-		{"Email", c.Email},
-		{"Address", c.Address},
-		{"Tags", c.Tags},
-		{"CanCountryId", c.CanCountryId}}
-
-	_, err = coll.InsertOne(context.TODO(), doc)
-	if err != nil {
-		return Candidate{}, fmt.Errorf("Could not insert Candidate provided")
+	c.ID = id
+	if err := stores.Candidates.Insert(ctx, c); err != nil {
+		return Candidate{}, err
 	}
 
-	defer db.CloseConnectionToMongo(client)
-
-	c.ID = nextCanID
-	updateCandidatesInMemory()
+	records.putCandidate(c)
 
 	return GetCandidateByID(c.ID)
 }
 
 //In DB: Updates a Candidate record on the collection and updates the Candidate in memory.
+//The duplicates are rejected as on AddCandidate.
 //Returns a Candidate object and an error in case it was not possible to update the record
-func UpdateCandidate(c Candidate) (Candidate, error) {
+func UpdateCandidate(ctx context.Context, c Candidate, allowSimilar bool) (Candidate, error) {
 	//Validation section
-	if !AlreadyExistById(c.CountryObj.ID) {
-		return Candidate{}, fmt.Errorf("Country inserted for candidate does not exist")
-	} else if c.CountryObj.ID == 0 && (c.CountryObj.Name != "" || c.CountryObj.Code != "") {
-		c.CountryObj.Name = ""
-		c.CountryObj.Code = ""
+	var v validate.Validator
+	checkCandidate(&v, c)
+	if err := invalid("Candidate is not valid", &v); err != nil {
+		return Candidate{}, err
 	}
 
-	b, e := checkRequiredFields(c)
-	if b {
-		return Candidate{}, fmt.Errorf("Field: %v should be populated", e)
+	if c.CountryObj.ID == 0 && (c.CountryObj.Name != "" || c.CountryObj.Code != "") {
+		c.CountryObj.Name = ""
+		c.CountryObj.Code = ""
 	}
 
 	//Update Candidate
-	if _, found := candidates[c.ID]; found {
+	if old, found := records.candidate(c.ID); found {
+		if err := checkDuplicates(c, allowSimilar); err != nil {
+			return Candidate{}, err
+		}
+
 		if c.Tags != nil {
 			//Validate if tag exist to add/reuse
-			c.Tags = ValidateTags(c.Tags)
+			c.Tags = ValidateTags(ctx, c.Tags)
 		}
 		//Removes the possibility of editing the JobsApplied when updating candidate
-		c.JobsApplied = candidates[c.ID].JobsApplied
+		c.JobsApplied = old.JobsApplied
 
-		client, err := db.OpenConnectionToMongo()
-		if err != nil {
-			return Candidate{}, fmt.Errorf("Could not establish connection to Database")
+		if err := stores.Candidates.Update(ctx, c); err != nil {
+			return Candidate{}, err
 		}
 
-		coll := client.Database(db.GetDatabaseName()).Collection("Candidates")
-		filter := bson.D{{"ID", c.ID}}
-		update := bson.D{{"$set", bson.D{
-			{"FirstName", c.FirstName},
-			{"LastName", c.LastName},
-			{"Email", c.Email},
-			{"Address", c.Address},
-			{"Tags", c.Tags},
-			{"CanCountryId", c.CanCountryId}}}}
-
-		if _, err = coll.UpdateOne(context.TODO(), filter, update); err != nil {
-			return Candidate{}, fmt.Errorf("Could not update candidate provided")
-		}
-
-		defer db.CloseConnectionToMongo(client)
-
-		updateCandidatesInMemory()
+		records.putCandidate(c)
 		return GetCandidateByID(c.ID)
 	}
 
 	//Return candidate not found
-	return Candidate{}, fmt.Errorf("Candidate '%v' was not found", c.FirstName)
+	return Candidate{}, notFound("Candidate '%v' was not found", c.FirstName)
 }
 
 //In DB: Removes a Candidate record from the collection and updates the Candidate in memory.
 //Returns error if failed to complete the deletion on the DB
-func DeleteCandidate(id int) error {
-	if _, found := candidates[id]; found {
-		//Remove the application record from Applications
-		DeleteApplicationFromCandidate(id)
-
-		client, err := db.OpenConnectionToMongo()
-		if err != nil {
-			return fmt.Errorf("Could not establish connection to Database")
+func DeleteCandidate(ctx context.Context, id int) error {
+	if _, found := records.candidate(id); found {
+		//Remove the application records and the attachments first, the candidate is kept when
+		//they fail so deleting it again removes what was left
+		if err := DeleteApplicationFromCandidate(ctx, id); err != nil {
+			return err
 		}
-
-		coll := client.Database(db.GetDatabaseName()).Collection("Candidates")
-		filter := bson.D{{"ID", id}}
-
-		if _, err = coll.DeleteOne(context.TODO(), filter); err != nil {
-			return fmt.Errorf("Could not delete Candidate")
+		if err := DeleteAttachmentsOfCandidate(ctx, id); err != nil {
+			return err
 		}
 
-		defer db.CloseConnectionToMongo(client)
+		if err := stores.Candidates.Delete(ctx, id); err != nil {
+			return err
+		}
 
-		updateCandidatesInMemory()
+		records.removeCandidate(id)
 		return nil
 	}
 
-	return fmt.Errorf("Candidate with id '%v' not found", id)
+	return notFound("Candidate with id '%v' not found", id)
 }
 
-//Verify if all the fields on the Candidate object that are required for teh entity are populated.
-//Returns a boolean value: True if populated, False if not populated.
-func checkRequiredFields(c Candidate) (bool, string) {
-	retBool := false
-	retString := ""
-
-	if c.FirstName == "" {
-		retString += "FirstName"
-		retBool = true
-	}
-
-	if c.LastName == "" {
-		if retBool {
-			retString += ", "
-		} else {
-			retBool = true
-		}
-		retString += "LastName"
-	}
-
-	if c.Email == "" {
-		if retBool {
-			retString += ", "
-		} else {
-			retBool = true
-		}
-
-		retString += "Email"
-	}
-
-	return retBool, retString
+//Collects the violations of the rules shared by the creation and the update of a Candidate.
+func checkCandidate(v *validate.Validator, c Candidate) {
+	v.Struct(c)
+	v.Check(AlreadyExistById(c.CountryObj.ID), "CountryObj.ID", "Country inserted for candidate does not exist")
 }
 
 //Validate the tag added to the Candidate, to make sure the current tag doesn't already exist.
 //Tags are reused accross the system so it becomes searchable and reportable.
 //Returns the tag for confirmation.
-func ValidateTags(cTags []Tag) ([]Tag) {
+func ValidateTags(ctx context.Context, cTags []Tag) ([]Tag) {
+	ret := make([]Tag, 0, len(cTags))
+	seen := make(map[int]bool)
 	for _, t := range cTags	{
-		b,id,_ := ExistTagByLabel(t.Label)
-		if !b {
-			AddTag(t)
-			continue
+		//Labels and synonyms resolve to the Tag they belong to, written with its canonical label
+		existing, err := GetTagByLabel(t.Label)
+		if err != nil {
+			//The ID sent for an unknown label refers to nothing, the new Tag gets its own
+			existing, err = AddTag(ctx, Tag{Label: t.Label})
+			if err != nil {
+				ret = append(ret, t)
+				continue
+			}
 		}
-		if b {
-			t.ID = id
+		if seen[existing.ID] {
 			continue
 		}
+		seen[existing.ID] = true
+		ret = append(ret, Tag{ID: existing.ID, Label: existing.Label})
 	}
-	return cTags
-}
-
-//Updates the hashmap containing all the countries to work with them in memory.
-//Return the next ID to be added into the Database
-func updateCandidatesInMemory() int {
-	client, err := db.OpenConnectionToMongo()
-	if err != nil {
-		return -1
-	}
-
-	filter := bson.D{}
-	projection := bson.D{
-		{"ID", 1},
-		{"FirstName", 1},
-		{"LastName", 1},
-		{"Email", 1},
-		{"Address", 1},
-		{"Tags", 1},
-		{"CanCountryId", 1}}
-	opts := options.Find().SetProjection(projection)
-
-	coll := client.Database(db.GetDatabaseName()).Collection("Candidates")
-	cursor, err := coll.Find(context.TODO(), filter, opts)
-
-	var results []bson.D
-	if err = cursor.All(context.TODO(), &results); err != nil {
-		panic(err)
-	}
-
-	defer db.CloseConnectionToMongo(client)
-
-	biggestId := 1
-	candidates = make(map[int]*Candidate)
-	for _, v := range results {
-		c := bsonToCandidate(v)
-
-		c.CountryObj, _ = GetCountryByID(c.CanCountryId)
-		c.JobsApplied = GetApplicationsOfCandidate(c.ID)
-
-		candidates[c.ID] = &c
-		if c.ID > biggestId {
-			biggestId = c.ID
-		}
-	}
-
-	return biggestId+1
+	return ret
 }
-
-//Receives a bson object to execute the conversion.
-//Returns a Candidate object.
-func bsonToCandidate(v bson.D) Candidate {
-	bsonBytes, _ := bson.Marshal(v)
-
-	var c Candidate
-	//deconvert the byarray into a struct object
-	bson.Unmarshal(bsonBytes, &c)
-
-	return c
-}
\ No newline at end of file
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+//Creates the Country with the code provided, unless it exists.
+func addTestCountry(t *testing.T, ctx context.Context, code string) Country {
+	t.Helper()
+	for _, c := range GetCountries() {
+		if c.Code == code {
+			return *c
+		}
+	}
+	c, err := AddCountry(ctx, Country{Name: code, Code: code})
+	if err != nil {
+		t.Fatalf("AddCountry(%q) error = %v", code, err)
+	}
+	return c
+}
+
+//Creates a Candidate with the name provided, living in Brazil.
+func addTestCandidate(t *testing.T, ctx context.Context, first string, last string) Candidate {
+	t.Helper()
+	country := addTestCountry(t, ctx, "BR")
+	c, err := AddCandidate(ctx, Candidate{FirstName: first, LastName: last, Email: strings.ToLower(first+"."+last) + "@mail.com", CanCountryId: country.ID, CountryObj: country}, true)
+	if err != nil {
+		t.Fatalf("AddCandidate(%q) error = %v", first, err)
+	}
+	return c
+}
+
+//The rules on the validate tags of the Candidate are reported on the field they belong to.
+func TestAddCandidateValidation(t *testing.T) {
+	ctx := initMemoryStores(t)
+	brazil := addTestCountry(t, ctx, "BR")
+	valid := Candidate{FirstName: "Jane", LastName: "Doe", Email: "jane.doe@mail.com", CanCountryId: brazil.ID, CountryObj: brazil}
+
+	tests := []struct {
+		name   string
+		change func(c *Candidate)
+		field  string
+	}{
+		{"first name missing", func(c *Candidate) { c.FirstName = "" }, "FirstName"},
+		{"last name missing", func(c *Candidate) { c.LastName = "" }, "LastName"},
+		{"email missing", func(c *Candidate) { c.Email = "" }, "Email"},
+		{"email with display name", func(c *Candidate) { c.Email = "Jane <jane.doe@mail.com>" }, "Email"},
+		{"email without domain", func(c *Candidate) { c.Email = "jane.doe@mail" }, "Email"},
+		{"unknown country", func(c *Candidate) { c.CountryObj = Country{ID: brazil.ID + 100} }, "CountryObj.ID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid
+			tt.change(&c)
+			_, err := AddCandidate(ctx, c, true)
+			var v *ValidationError
+			if !errors.As(err, &v) {
+				t.Fatalf("AddCandidate() error = %v, want a ValidationError", err)
+			}
+			if len(v.Fields) != 1 || v.Fields[0].Field != tt.field {
+				t.Errorf("fields = %+v, want %v only", v.Fields, tt.field)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"webservice/db"
+)
+
+//Keeps the content of the attachments as files on a directory of the local filesystem,
+//spread on sub directories named after the first two characters of the key.
+type filesystemContentStore struct {
+	dir string
+}
+
+//NewFilesystemContentStore returns a ContentStore keeping the content on the directory provided, which is created if needed.
+func NewFilesystemContentStore(dir string) (ContentStore, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, unavailable("Could not create attachments directory: %v", err)
+	}
+	return filesystemContentStore{dir}, nil
+}
+
+func (s filesystemContentStore) path(key string) string {
+	return filepath.Join(s.dir, key[:2], key)
+}
+
+func (s filesystemContentStore) Save(ctx context.Context, key string, content []byte) error {
+	path := s.path(key)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return unavailable("Could not save attachment content")
+	}
+
+	//Written aside and renamed, so a file is never read half written
+	tmp, err := ioutil.TempFile(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return unavailable("Could not save attachment content")
+	}
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return unavailable("Could not save attachment content")
+	}
+	return nil
+}
+
+func (s filesystemContentStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	f, err := os.Open(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, notFound("Content of attachment '%v' not found", key)
+	}
+	if err != nil {
+		return nil, unavailable("Could not read attachment content")
+	}
+	return f, nil
+}
+
+func (s filesystemContentStore) Remove(ctx context.Context, key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		return unavailable("Could not remove attachment content")
+	}
+	return nil
+}
+
+//Name of the GridFS bucket the content of the attachments is kept on.
+const gridfsBucket = "AttachmentContents"
+
+//Keeps the content of the attachments on a GridFS bucket, using the key as the ID of the file.
+type gridfsContentStore struct {
+	database *mongo.Database
+}
+
+//NewGridFSContentStore returns a ContentStore keeping the content on GridFS, in the database provided.
+func NewGridFSContentStore(database *mongo.Database) ContentStore {
+	return gridfsContentStore{database}
+}
+
+//Returns the bucket with the deadline of ctx, or the operation timeout when ctx has none.
+//A bucket is created for each operation since its deadlines are shared by every caller.
+func (s gridfsContentStore) bucket(ctx context.Context) (*gridfs.Bucket, error) {
+	b, err := gridfs.NewBucket(s.database, options.GridFSBucket().SetName(gridfsBucket))
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(db.OperationTimeout())
+	}
+	b.SetReadDeadline(deadline)
+	b.SetWriteDeadline(deadline)
+	return b, nil
+}
+
+func (s gridfsContentStore) Save(ctx context.Context, key string, content []byte) error {
+	b, err := s.bucket(ctx)
+	if err == nil {
+		err = b.UploadFromStreamWithID(key, key, bytes.NewReader(content))
+	}
+	//The content is addressed by its checksum, an upload with the same key has the same content
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return unavailable("Could not save attachment content")
+	}
+	return nil
+}
+
+func (s gridfsContentStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	b, err := s.bucket(ctx)
+	if err != nil {
+		return nil, unavailable("Could not read attachment content")
+	}
+
+	stream, err := b.OpenDownloadStream(key)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil, notFound("Content of attachment '%v' not found", key)
+	}
+	if err != nil {
+		return nil, unavailable("Could not read attachment content")
+	}
+	return stream, nil
+}
+
+func (s gridfsContentStore) Remove(ctx context.Context, key string) error {
+	b, err := s.bucket(ctx)
+	if err == nil {
+		err = b.Delete(key)
+	}
+	if err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+		return unavailable("Could not remove attachment content")
+	}
+	return nil
+}
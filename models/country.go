@@ -1,31 +1,26 @@
 package models
 
 import (
-	"fmt"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo/options"
-	"webservice/db"
-
 	"context"
+	"strings"
+
+	"webservice/validate"
 )
 
 type Country struct {
 	ID   int
-	Name string
-	Code string
+	Name string `validate:"required"`
+	//ISO 3166-1 alpha-2 code, stored in upper case.
+	Code string `validate:"required,iso3166"`
 }
 
-var (
-	countries = make(map[int]*Country)
-	nextCountryID = updateCountriesInMemory()
-)
-
 //In Memory: Returns the complete list of countries that has been.
 //Returns a hashmap containing the list of countries
 func GetCountries() []*Country {
 	countryArr := make([]*Country,0)
-	for _, v := range countries {
-		countryArr = append(countryArr, v)
+	for _, v := range records.allCountries() {
+		v := v
+		countryArr = append(countryArr, &v)
 	}
 	return countryArr
 }
@@ -33,115 +28,82 @@ func GetCountries() []*Country {
 //In Memory: Searches for a specific country on the hashmap.
 //Returns a country object and an error in case it was not possible to find the record
 func GetCountryByID(id int) (Country, error) {
-	if _, found := countries[id]; found {
-		return *countries[id], nil
+	if c, found := records.country(id); found {
+		return c, nil
 	}
 
-	return Country{}, fmt.Errorf("Country with ID '%v' not found", id)
+	return Country{}, notFound("Country with ID '%v' not found", id)
 }
 
 //In DB: Creates a new country record to the collection and updates the countries in memory.
 //Returns a country object and an error in case it was not possible to create the record
-func AddCountry(c Country) (Country, error) {
-	if c.ID != 0 {
-		return Country{}, fmt.Errorf("Country must not include ID")
+func AddCountry(ctx context.Context, c Country) (Country, error) {
+	c.Code = strings.ToUpper(strings.TrimSpace(c.Code))
+
+	var v validate.Validator
+	v.Check(c.ID == 0, "ID", "Country must not include ID")
+	v.Struct(c)
+	if err := invalid("Country is not valid", &v); err != nil {
+		return Country{}, err
 	}
 
 	if AlreadyExistByCode(c.Code) {
-		return Country{}, fmt.Errorf("Country with CODE '%v' already exists", c.Code)
+		return Country{}, conflict("Country with CODE '%v' already exists", c.Code)
 	}
 
-	//Validate if able to connect to MongoDB
-	client, err := db.OpenConnectionToMongo()
+	id, err := nextID(ctx, countrySequence)
 	if err != nil {
-		return Country{}, fmt.Errorf("Could not establish connection to MongoDB")
+		return Country{}, err
 	}
-
-	coll := client.Database("myFirstDatabase").Collection("Countries")
-	doc := bson.D{
-		{"ID", nextCountryID},
-		{"Name", c.Name},
-		{"Code", c.Code}}
-
-	//Insert information into MongoDB
-	_ , err = coll.InsertOne(context.TODO(), doc)
-	if err != nil {
-		return Country{}, fmt.Errorf("Could not insert Country provided")
+	c.ID = id
+	if err := stores.Countries.Insert(ctx, c); err != nil {
+		return Country{}, err
 	}
 
-	defer db.CloseConnectionToMongo(client)
-
-	c.ID = nextCountryID
-	updateCountriesInMemory()
+	records.putCountry(c)
 	return c, nil
 }
 
 //In DB: Updates a country record on the collection and updates the countries in memory.
 //Returns a country object and an error in case it was not possible to update the record
-func UpdateCountry(c Country) (Country, error) {
-	if _, found := countries[c.ID]; found {
-		countries[c.ID] = &c
-
-		//establish connection to database
-		client, err := db.OpenConnectionToMongo()
-		if err != nil {
-			return Country{}, fmt.Errorf("Could not establish connection to database")
-		}
+func UpdateCountry(ctx context.Context, c Country) (Country, error) {
+	c.Code = strings.ToUpper(strings.TrimSpace(c.Code))
 
-		//create parameters for updating the values of the country
-		coll := client.Database(db.GetDatabaseName()).Collection("Countries")
-		filter := bson.D{{"ID", c.ID}}
-		update := bson.D{{"$set",bson.D{{"Name", c.Name},{"Code", c.Code}}}}
+	var v validate.Validator
+	v.Struct(c)
+	if err := invalid("Country is not valid", &v); err != nil {
+		return Country{}, err
+	}
 
+	if _, found := records.country(c.ID); found {
 		//execute update on the database record
-		_, err = coll.UpdateOne(context.TODO(), filter, update)
-		if err != nil {
-			return Country{}, fmt.Errorf("Could not update country on the database")
+		if err := stores.Countries.Update(ctx, c); err != nil {
+			return Country{}, err
 		}
 
-		defer db.CloseConnectionToMongo(client)
-
 		//Update the list stored in memory
-		updateCountriesInMemory()
-		updateJobRequisitionInMemory()
-		updateCandidatesInMemory()
+		records.putCountry(c)
 		return c, nil
 	}
 
-	return Country{}, fmt.Errorf("Country to be updated not found")
+	return Country{}, notFound("Country to be updated not found")
 }
 
 //In DB: Removes a country record from the collection and updates the countries in memory.
 //Returns error if failed to complete the deletion on the DB
-func RemoveCountryByID(id int) error {
-	if _, found := countries[id]; found {
-		delete(countries, id)
-
-		client, err := db.OpenConnectionToMongo()
-		if err != nil {
-			return fmt.Errorf("Could not establish connection to database")
-		}
-
-		//create parameters for updating the values of the country
-		coll := client.Database(db.GetDatabaseName()).Collection("Countries")
-		filter := bson.D{{"ID", id}}
-
+func RemoveCountryByID(ctx context.Context, id int) error {
+	if _, found := records.country(id); found {
 		//Execute deletion on the database
-		_, err = coll.DeleteOne(context.TODO(), filter)
-		if err != nil {
-			return fmt.Errorf("Could not delete Country")
+		if err := stores.Countries.Delete(ctx, id); err != nil {
+			return err
 		}
 
-		defer db.CloseConnectionToMongo(client)
-
 		//update the list stored in memory
-		updateCountriesInMemory()
-		updateJobRequisitionInMemory()
-		updateCandidatesInMemory()
+		records.removeCountry(id)
 		return nil
 	}
 
-	return fmt.Errorf("Country with ID '%v' not found", id)
+	return notFound("Country with ID '%v' not found", id)
 }
 
 //In Memory:Validate if the country with given ID already exists on the list.
@@ -159,64 +121,10 @@ func AlreadyExistById(id int) bool {
 //Validate if the country already exists on the list.
 //Returns true when the country exists and false when it doesn't
 func AlreadyExistByCode(code string) bool {
-	for _, c := range countries {
+	for _, c := range records.allCountries() {
 		if code == c.Code {
 			return true
 		}
 	}
 	return false
 }
-
-//Updates the hashmap containing all the countries to work with them in memory.
-//Return the next ID to be added into the Database
-func updateCountriesInMemory() int {
-	client, err := db.OpenConnectionToMongo()
-
-	if err != nil {
-		return -1
-	}
-
-	filter := bson.D{}
-	projection := bson.D{
-		{"ID",1},
-		{"Name", 1},
-		{"Code", 1}}
-	opts := options.Find().SetProjection(projection)
-
-	coll := client.Database(db.GetDatabaseName()).Collection("Countries")
-	cursor, err := coll.Find(context.TODO(), filter, opts)
-
-	var results []bson.D
-
-	if err = cursor.All(context.TODO(), &results); err != nil {
-		panic(err)
-	}
-
-	defer db.CloseConnectionToMongo(client)
-
-	biggestId := 1
-	countries = make(map[int]*Country)
-	for _, v := range results {
-		c := bsonToCountry(v)
-
-		countries[c.ID] = &c
-
-		if c.ID > biggestId{
-			biggestId = c.ID
-		}
-	}
-
-	return biggestId+1
-}
-
-//Receives a bson object to execute the conversion.
-//Returns a Country object.
-func bsonToCountry(v bson.D) Country {
-	bsonBytes, _ := bson.Marshal(v)
-
-	var c Country
-	//deconvert the byarray into a struct object
-	bson.Unmarshal(bsonBytes, &c)
-
-	return c
-}
\ No newline at end of file
@@ -0,0 +1,247 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+//Similarity, from 0 to 1, the names and the addresses of two candidates must both reach to be reported as duplicates.
+//The email alone is enough when it is the same.
+const (
+	nameSimilarity    = 0.85
+	addressSimilarity = 0.75
+)
+
+//Duplicate is a pair of Candidate that are probably the same person.
+type Duplicate struct {
+	CandidateID int
+	DuplicateID int
+	//1 when the email is the same, the mean similarity of the names and addresses otherwise.
+	Score float64
+	//Fields found alike: Email, or Name and Address.
+	Reasons []string
+}
+
+//In Memory: Returns every pair of Candidate that are probably the same person, the most likely first.
+func GetDuplicates() []Duplicate {
+	candidates := records.allCandidates()
+
+	ret := make([]Duplicate, 0)
+	for i := range candidates {
+		for j := i + 1; j < len(candidates); j++ {
+			if d, found := compareCandidates(candidates[i], candidates[j]); found {
+				ret = append(ret, d)
+			}
+		}
+	}
+
+	sort.SliceStable(ret, func(a, b int) bool {
+		return ret[a].Score > ret[b].Score
+	})
+	return ret
+}
+
+//Returns the other Candidate that are probably the same person as c.
+func duplicatesOf(c Candidate) []Duplicate {
+	var ret []Duplicate
+	for _, other := range records.allCandidates() {
+		if other.ID == c.ID {
+			continue
+		}
+		if d, found := compareCandidates(c, other); found {
+			ret = append(ret, d)
+		}
+	}
+	return ret
+}
+
+//Returns the conflict describing the duplicates of c, or nil when it has none.
+//Candidates sharing the email are always duplicates, the ones with a similar name and address only when allowSimilar is false.
+func checkDuplicates(c Candidate, allowSimilar bool) error {
+	var found []string
+	for _, d := range duplicatesOf(c) {
+		if allowSimilar && d.Reasons[0] != "Email" {
+			continue
+		}
+		found = append(found, fmt.Sprintf("'%v' (%v)", d.DuplicateID, strings.Join(d.Reasons, ", ")))
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	return conflict("Candidate is a duplicate of %v", strings.Join(found, ", "))
+}
+
+//Compares two candidates, returning the Duplicate when they are probably the same person.
+func compareCandidates(a Candidate, b Candidate) (Duplicate, bool) {
+	d := Duplicate{CandidateID: a.ID, DuplicateID: b.ID}
+
+	if email := normalizeEmail(a.Email); email != "" && email == normalizeEmail(b.Email) {
+		d.Score = 1
+		d.Reasons = []string{"Email"}
+		return d, true
+	}
+
+	addressA, addressB := normalizeText(a.Address), normalizeText(b.Address)
+	if addressA == "" || addressB == "" {
+		return Duplicate{}, false
+	}
+
+	names := similarity(nameKey(a), nameKey(b))
+	addresses := similarity(addressA, addressB)
+	if names < nameSimilarity || addresses < addressSimilarity {
+		return Duplicate{}, false
+	}
+
+	d.Score = (names + addresses) / 2
+	d.Reasons = []string{"Name", "Address"}
+	return d, true
+}
+
+//Domains known to deliver the mail sent to local+suffix to local, whatever the suffix.
+//Other domains may give each suffix a mailbox of its own, so john+work@corp.com is not john@corp.com.
+var subaddressDomains = map[string]bool{
+	"gmail.com":      true,
+	"googlemail.com": true,
+	"outlook.com":    true,
+	"hotmail.com":    true,
+	"live.com":       true,
+	"icloud.com":     true,
+	"me.com":         true,
+	"fastmail.com":   true,
+	"protonmail.com": true,
+	"proton.me":      true,
+}
+
+//Returns the address an email is delivered to: in lower case, without the +suffix of the local part
+//on the domains of subaddressDomains and, for Gmail, without the dots that Gmail ignores.
+func normalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return email
+	}
+
+	local, domain := email[:at], email[at+1:]
+	if plus := strings.Index(local, "+"); plus >= 0 && subaddressDomains[domain] {
+		local = local[:plus]
+	}
+	if domain == "gmail.com" || domain == "googlemail.com" {
+		local = strings.ReplaceAll(local, ".", "")
+		domain = "gmail.com"
+	}
+	return local + "@" + domain
+}
+
+//Returns the full name with its words sorted, so swapped first and last names still match.
+func nameKey(c Candidate) string {
+	words := strings.Fields(normalizeText(c.FirstName + " " + c.LastName))
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+//Returns s in lower case, without accents and with every run of punctuation and spaces replaced by a single space.
+func normalizeText(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		r = foldAccent(r)
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+			continue
+		}
+		space = true
+	}
+	return b.String()
+}
+
+//Letters with accents of the Latin alphabets and the letter they are written with.
+var accents = func() map[rune]rune {
+	pairs := []string{
+		"àáâãäåāăą", "a",
+		"çćĉċč", "c",
+		"ďđ", "d",
+		"èéêëēĕėęě", "e",
+		"ĝğġģ", "g",
+		"ĥħ", "h",
+		"ìíîïĩīĭįı", "i",
+		"ĵ", "j",
+		"ķ", "k",
+		"ĺļľŀł", "l",
+		"ñńņňŉ", "n",
+		"òóôõöøōŏő", "o",
+		"ŕŗř", "r",
+		"śŝşšș", "s",
+		"ţťŧț", "t",
+		"ùúûüũūŭůűų", "u",
+		"ŵ", "w",
+		"ýÿŷ", "y",
+		"źżž", "z",
+	}
+	m := make(map[rune]rune)
+	for i := 0; i < len(pairs); i += 2 {
+		for _, r := range pairs[i] {
+			m[r] = rune(pairs[i+1][0])
+		}
+	}
+	return m
+}()
+
+//Returns the letter r is written with, without its accent.
+func foldAccent(r rune) rune {
+	if base, found := accents[r]; found {
+		return base
+	}
+	return r
+}
+
+//Returns how alike a and b are, from 0 for nothing in common to 1 for equal,
+//based on the number of characters that must be changed to turn one into the other.
+func similarity(a string, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+//Levenshtein distance between a and b.
+func editDistance(a []rune, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a int, b int, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,43 @@
+package models
+
+import "testing"
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{" John.Doe@Corp.com ", "john.doe@corp.com"},
+		{"john+work@corp.com", "john+work@corp.com"},
+		{"john+work@outlook.com", "john@outlook.com"},
+		{"J.o.h.n+cv@googlemail.com", "john@gmail.com"},
+		{"no-domain", "no-domain"},
+	}
+	for _, tt := range tests {
+		if got := normalizeEmail(tt.email); got != tt.want {
+			t.Errorf("normalizeEmail(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestCheckDuplicates(t *testing.T) {
+	ctx := initMemoryStores(t)
+	country := addTestCountry(t, ctx, "BR")
+	add := func(first string, email string, address string, allowSimilar bool) error {
+		_, err := AddCandidate(ctx, Candidate{FirstName: first, LastName: "Doe", Email: email, Address: address, CanCountryId: country.ID, CountryObj: country}, allowSimilar)
+		return err
+	}
+
+	if err := add("John", "john@corp.com", "1 Main Street", false); err != nil {
+		t.Fatalf("AddCandidate() error = %v", err)
+	}
+	//The suffix may be another mailbox on a domain not known to ignore it
+	if err := add("Johnny", "john+work@corp.com", "", false); err != nil {
+		t.Errorf("AddCandidate() of a suffixed email error = %v, want nil", err)
+	}
+	wantError[*ConflictError](t, add("Jon", "JOHN@corp.com", "", true))
+	wantError[*ConflictError](t, add("John", "other@corp.com", "1, Main Street", false))
+	if err := add("John", "other@corp.com", "1, Main Street", true); err != nil {
+		t.Errorf("AddCandidate() of a similar Candidate allowed error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"webservice/validate"
+)
+
+//NotFoundError is returned when the record requested does not exist.
+type NotFoundError struct {
+	Detail string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Detail
+}
+
+//ConflictError is returned when the request is valid but clashes with the current state of the records,
+//such as a duplicated code or an application to a requisition that is not posted.
+type ConflictError struct {
+	Detail string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Detail
+}
+
+//FieldError describes why a single field of the record is invalid.
+type FieldError = validate.FieldError
+
+//ValidationError is returned when the record received has invalid fields.
+//Fields lists every violation found, so they can all be fixed at once.
+type ValidationError struct {
+	Detail string
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Detail
+	}
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+": "+f.Message)
+	}
+	return e.Detail + ": " + strings.Join(msgs, "; ")
+}
+
+//UnavailableError is returned when the storage could not complete the operation.
+type UnavailableError struct {
+	Detail string
+}
+
+func (e *UnavailableError) Error() string {
+	return e.Detail
+}
+
+func notFound(format string, a ...interface{}) error {
+	return &NotFoundError{Detail: fmt.Sprintf(format, a...)}
+}
+
+func conflict(format string, a ...interface{}) error {
+	return &ConflictError{Detail: fmt.Sprintf(format, a...)}
+}
+
+//Returns a ValidationError with every violation collected by v, or nil when there is none.
+func invalid(detail string, v *validate.Validator) error {
+	if v.Valid() {
+		return nil
+	}
+	return &ValidationError{Detail: detail, Fields: v.Errors()}
+}
+
+func unavailable(format string, a ...interface{}) error {
+	return &UnavailableError{Detail: fmt.Sprintf(format, a...)}
+}
@@ -0,0 +1,1456 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/SAP/go-hdb/driver"
+)
+
+//DDL of the tables used by the SAP HANA stores.
+//Tags of a Candidate and the stage history of an Application are kept on their own tables, ordered by POSITION.
+var hanaSchema = []string{
+	`CREATE COLUMN TABLE COUNTRIES (
+		ID INTEGER NOT NULL PRIMARY KEY,
+		NAME NVARCHAR(255) NOT NULL,
+		CODE NVARCHAR(3) NOT NULL
+	)`,
+	`CREATE COLUMN TABLE TAGS (
+		ID INTEGER NOT NULL PRIMARY KEY,
+		LABEL NVARCHAR(255) NOT NULL
+	)`,
+	`CREATE COLUMN TABLE TAG_SYNONYMS (
+		TAG_ID INTEGER NOT NULL,
+		POSITION INTEGER NOT NULL,
+		LABEL NVARCHAR(255) NOT NULL,
+		PRIMARY KEY (TAG_ID, POSITION)
+	)`,
+	`CREATE COLUMN TABLE TAG_PARENTS (
+		TAG_ID INTEGER NOT NULL PRIMARY KEY,
+		PARENT_ID INTEGER NOT NULL
+	)`,
+	`CREATE COLUMN TABLE CANDIDATES (
+		ID INTEGER NOT NULL PRIMARY KEY,
+		FIRST_NAME NVARCHAR(255) NOT NULL,
+		LAST_NAME NVARCHAR(255) NOT NULL,
+		EMAIL NVARCHAR(255) NOT NULL,
+		ADDRESS NVARCHAR(1000),
+		COUNTRY_ID INTEGER
+	)`,
+	`CREATE COLUMN TABLE CANDIDATE_TAGS (
+		CANDIDATE_ID INTEGER NOT NULL,
+		POSITION INTEGER NOT NULL,
+		TAG_ID INTEGER,
+		LABEL NVARCHAR(255) NOT NULL,
+		PRIMARY KEY (CANDIDATE_ID, POSITION)
+	)`,
+	`CREATE COLUMN TABLE REQUISITIONS (
+		ID INTEGER NOT NULL PRIMARY KEY,
+		TITLE NVARCHAR(255) NOT NULL,
+		JOB_DESCRIPTION NVARCHAR(5000) NOT NULL,
+		POSTING_STATUS BOOLEAN NOT NULL,
+		STATE NVARCHAR(32),
+		OPENING_DATE TIMESTAMP,
+		CLOSING_DATE TIMESTAMP,
+		POST_AT TIMESTAMP,
+		UNPOST_AT TIMESTAMP,
+		HIRING_MANAGER NVARCHAR(255),
+		RECRUITER NVARCHAR(255),
+		COUNTRY_ID INTEGER,
+		HEADCOUNT INTEGER,
+		FILLED_COUNT INTEGER,
+		YEARS_OF_EXPERIENCE INTEGER,
+		VERSION INTEGER DEFAULT 0
+	)`,
+	`CREATE COLUMN TABLE REQUISITION_TAGS (
+		REQUISITION_ID INTEGER NOT NULL,
+		POSITION INTEGER NOT NULL,
+		REQUIRED BOOLEAN NOT NULL,
+		TAG_ID INTEGER,
+		LABEL NVARCHAR(255) NOT NULL,
+		PRIMARY KEY (REQUISITION_ID, POSITION)
+	)`,
+	`CREATE COLUMN TABLE REQUISITION_STATES (
+		REQUISITION_ID INTEGER NOT NULL,
+		POSITION INTEGER NOT NULL,
+		FROM_STATUS NVARCHAR(32),
+		TO_STATUS NVARCHAR(32) NOT NULL,
+		CHANGED_BY NVARCHAR(255),
+		CHANGED_AT TIMESTAMP NOT NULL,
+		COMMENT NVARCHAR(5000),
+		PRIMARY KEY (REQUISITION_ID, POSITION)
+	)`,
+	`CREATE COLUMN TABLE REQUISITION_APPROVALS (
+		REQUISITION_ID INTEGER NOT NULL,
+		POSITION INTEGER NOT NULL,
+		ROUND INTEGER NOT NULL,
+		STEP NVARCHAR(255) NOT NULL,
+		APPROVED BOOLEAN NOT NULL,
+		APPROVED_BY NVARCHAR(255),
+		APPROVED_AT TIMESTAMP NOT NULL,
+		COMMENT NVARCHAR(5000),
+		PRIMARY KEY (REQUISITION_ID, POSITION)
+	)`,
+	`CREATE COLUMN TABLE APPLICATIONS (
+		ID INTEGER NOT NULL PRIMARY KEY,
+		CANDIDATE_ID INTEGER NOT NULL,
+		REQUISITION_ID INTEGER NOT NULL,
+		SALARY_EXPECTATION NVARCHAR(255),
+		APPLICATION_SOURCE NVARCHAR(255),
+		TIME_OF_EXPERIENCE INTEGER,
+		STAGE NVARCHAR(64),
+		STATUS NVARCHAR(32),
+		WITHDRAWN_AT TIMESTAMP,
+		WITHDRAWN_BY NVARCHAR(255),
+		WITHDRAWAL_REASON NVARCHAR(5000),
+		UNIQUE (CANDIDATE_ID, REQUISITION_ID)
+	)`,
+	`CREATE COLUMN TABLE APPLICATION_STAGES (
+		APPLICATION_ID INTEGER NOT NULL,
+		POSITION INTEGER NOT NULL,
+		FROM_STAGE NVARCHAR(64),
+		TO_STAGE NVARCHAR(64) NOT NULL,
+		CHANGED_BY NVARCHAR(255),
+		CHANGED_AT TIMESTAMP NOT NULL,
+		COMMENT NVARCHAR(5000),
+		PRIMARY KEY (APPLICATION_ID, POSITION)
+	)`,
+	`CREATE COLUMN TABLE INTERVIEWS (
+		ID INTEGER NOT NULL PRIMARY KEY,
+		APPLICATION_ID INTEGER NOT NULL,
+		START_AT TIMESTAMP NOT NULL,
+		END_AT TIMESTAMP NOT NULL,
+		TIME_ZONE NVARCHAR(64) NOT NULL,
+		LOCATION NVARCHAR(1000),
+		VIDEO_LINK NVARCHAR(1000),
+		OUTCOME NVARCHAR(32) NOT NULL,
+		NOTES NVARCHAR(5000)
+	)`,
+	`CREATE COLUMN TABLE INTERVIEW_INTERVIEWERS (
+		INTERVIEW_ID INTEGER NOT NULL,
+		POSITION INTEGER NOT NULL,
+		NAME NVARCHAR(255),
+		EMAIL NVARCHAR(255) NOT NULL,
+		PRIMARY KEY (INTERVIEW_ID, POSITION)
+	)`,
+	`CREATE COLUMN TABLE OFFERS (
+		ID INTEGER NOT NULL PRIMARY KEY,
+		APPLICATION_ID INTEGER NOT NULL,
+		SALARY DECIMAL(18, 2) NOT NULL,
+		CURRENCY NVARCHAR(3) NOT NULL,
+		SALARY_PERIOD NVARCHAR(16) NOT NULL,
+		START_DATE TIMESTAMP NOT NULL,
+		EXPIRES_AT TIMESTAMP NOT NULL,
+		STATUS NVARCHAR(32) NOT NULL,
+		VERSION INTEGER DEFAULT 0
+	)`,
+	`CREATE COLUMN TABLE OFFER_STATUSES (
+		OFFER_ID INTEGER NOT NULL,
+		POSITION INTEGER NOT NULL,
+		FROM_STATUS NVARCHAR(32),
+		TO_STATUS NVARCHAR(32) NOT NULL,
+		CHANGED_BY NVARCHAR(255),
+		CHANGED_AT TIMESTAMP NOT NULL,
+		COMMENT NVARCHAR(5000),
+		PRIMARY KEY (OFFER_ID, POSITION)
+	)`,
+	`CREATE COLUMN TABLE CANDIDATE_MERGES (
+		ID INTEGER NOT NULL PRIMARY KEY,
+		TARGET_ID INTEGER NOT NULL,
+		SOURCE_ID INTEGER NOT NULL,
+		SOURCE_FIRST_NAME NVARCHAR(255),
+		SOURCE_LAST_NAME NVARCHAR(255),
+		SOURCE_EMAIL NVARCHAR(255),
+		SOURCE_ADDRESS NVARCHAR(1000),
+		MERGED_BY NVARCHAR(255),
+		MERGED_AT TIMESTAMP NOT NULL,
+		STATUS NVARCHAR(32)
+	)`,
+	`CREATE COLUMN TABLE CANDIDATE_MERGE_APPLICATIONS (
+		MERGE_ID INTEGER NOT NULL,
+		APPLICATION_ID INTEGER NOT NULL,
+		MOVED BOOLEAN NOT NULL,
+		PRIMARY KEY (MERGE_ID, APPLICATION_ID)
+	)`,
+	`CREATE COLUMN TABLE CANDIDATE_MERGE_TAGS (
+		MERGE_ID INTEGER NOT NULL,
+		POSITION INTEGER NOT NULL,
+		LABEL NVARCHAR(255) NOT NULL,
+		PRIMARY KEY (MERGE_ID, POSITION)
+	)`,
+	`CREATE COLUMN TABLE ATTACHMENTS (
+		ID INTEGER NOT NULL PRIMARY KEY,
+		CANDIDATE_ID INTEGER NOT NULL,
+		KIND NVARCHAR(32) NOT NULL,
+		FILE_NAME NVARCHAR(255) NOT NULL,
+		CONTENT_TYPE NVARCHAR(255),
+		SIZE BIGINT NOT NULL,
+		CHECKSUM NVARCHAR(64) NOT NULL,
+		UPLOADED_BY NVARCHAR(255),
+		UPLOADED_AT TIMESTAMP NOT NULL,
+		CONTENT_TEXT NCLOB
+	)`,
+	`CREATE COLUMN TABLE COUNTERS (
+		NAME NVARCHAR(64) NOT NULL PRIMARY KEY,
+		SEQ INTEGER NOT NULL
+	)`,
+}
+
+//Columns added to the tables of hanaSchema after they were first released.
+//The statements of hanaSchema are skipped for the tables that already exist, so CreateHanaSchema adds these columns to them.
+//Existing rows get the default of the definition, or NULL, which the stores read as the zero value.
+var hanaColumns = []struct {
+	table      string
+	column     string
+	definition string
+}{
+	{"APPLICATIONS", "STAGE", "NVARCHAR(64)"},
+	//Requisitions created before the headcount hire one person
+	{"REQUISITIONS", "HEADCOUNT", "INTEGER DEFAULT 1"},
+	{"REQUISITIONS", "FILLED_COUNT", "INTEGER DEFAULT 0"},
+	{"REQUISITIONS", "STATE", "NVARCHAR(32)"},
+	{"REQUISITIONS", "OPENING_DATE", "TIMESTAMP"},
+	{"REQUISITIONS", "CLOSING_DATE", "TIMESTAMP"},
+	{"REQUISITIONS", "HIRING_MANAGER", "NVARCHAR(255)"},
+	{"REQUISITIONS", "RECRUITER", "NVARCHAR(255)"},
+	{"REQUISITIONS", "POST_AT", "TIMESTAMP"},
+	{"REQUISITIONS", "UNPOST_AT", "TIMESTAMP"},
+	{"APPLICATIONS", "STATUS", "NVARCHAR(32)"},
+	{"APPLICATIONS", "WITHDRAWN_AT", "TIMESTAMP"},
+	{"APPLICATIONS", "WITHDRAWN_BY", "NVARCHAR(255)"},
+	{"APPLICATIONS", "WITHDRAWAL_REASON", "NVARCHAR(5000)"},
+	{"ATTACHMENTS", "CONTENT_TEXT", "NCLOB"},
+	{"REQUISITIONS", "YEARS_OF_EXPERIENCE", "INTEGER"},
+	{"CANDIDATE_MERGES", "STATUS", "NVARCHAR(32)"},
+	{"REQUISITIONS", "VERSION", "INTEGER DEFAULT 0"},
+	{"OFFERS", "VERSION", "INTEGER DEFAULT 0"},
+}
+
+//Returned inside the transactions of the stores whose Update is conditional on the Version, see staleRecord.
+var errHanaStaleVersion = errors.New("Record has another version")
+
+//Runs the UPDATE of a record whose WHERE clause matches its previous version.
+//Returns errHanaStaleVersion when no row matched.
+func updateHanaVersion(ctx context.Context, tx *sql.Tx, stmt string, args ...interface{}) error {
+	res, err := tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return errHanaStaleVersion
+	}
+	return nil
+}
+
+//HANA error codes handled by the stores.
+const (
+	//Returned when creating a table that already exists.
+	hanaDuplicateTableName = 288
+	//Returned when a primary key or unique constraint is violated.
+	hanaUniqueConstraintViolated = 301
+)
+
+//Creates every table used by the SAP HANA stores, skipping the ones that already exist,
+//and adds the columns of hanaColumns missing on the existing ones. Running it again has no effect.
+//Returns an error if any of the statements failed for another reason.
+func CreateHanaSchema(ctx context.Context, conn *sql.DB) error {
+	for _, stmt := range hanaSchema {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			if isHanaError(err, hanaDuplicateTableName) {
+				continue
+			}
+			return unavailable("Could not create SAP HANA schema: %v", err)
+		}
+	}
+
+	for _, c := range hanaColumns {
+		found, err := hasHanaColumn(ctx, conn, c.table, c.column)
+		if err != nil {
+			return unavailable("Could not read SAP HANA schema: %v", err)
+		}
+		if found {
+			continue
+		}
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %v ADD (%v %v)`, c.table, c.column, c.definition)); err != nil {
+			return unavailable("Could not add column %v.%v to SAP HANA schema: %v", c.table, c.column, err)
+		}
+		log.Printf("Added column %v.%v to SAP HANA schema", c.table, c.column)
+	}
+	return addHanaApplicationsUnique(ctx, conn)
+}
+
+//Adds the unique constraint of APPLICATIONS to the tables created before it, a candidate applying only once to each requisition.
+//Returns the error of duplicateApplications, without adding it, while a candidate has applied twice to the same requisition.
+func addHanaApplicationsUnique(ctx context.Context, conn *sql.DB) error {
+	var n int
+	err := conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM SYS.CONSTRAINTS WHERE SCHEMA_NAME = CURRENT_SCHEMA AND TABLE_NAME = 'APPLICATIONS' AND COLUMN_NAME = 'CANDIDATE_ID' AND IS_UNIQUE_KEY = 'TRUE'`).Scan(&n)
+	if err != nil {
+		return unavailable("Could not read SAP HANA schema: %v", err)
+	}
+	if n > 0 {
+		return nil
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT CANDIDATE_ID, REQUISITION_ID, STRING_AGG(TO_NVARCHAR(ID), ', ') FROM APPLICATIONS GROUP BY CANDIDATE_ID, REQUISITION_ID HAVING COUNT(*) > 1`)
+	if err != nil {
+		return unavailable("Could not check SAP HANA applications: %v", err)
+	}
+	defer rows.Close()
+
+	var pairs []string
+	for rows.Next() {
+		var candidateID, requisitionID int
+		var ids string
+		if err = rows.Scan(&candidateID, &requisitionID, &ids); err != nil {
+			return unavailable("Could not check SAP HANA applications: %v", err)
+		}
+		pairs = append(pairs, fmt.Sprintf("Candidate '%v' to Job Requisition '%v' on applications %v", candidateID, requisitionID, ids))
+	}
+	if err = rows.Err(); err != nil {
+		return unavailable("Could not check SAP HANA applications: %v", err)
+	}
+	if len(pairs) > 0 {
+		return duplicateApplications(pairs)
+	}
+
+	if _, err = conn.ExecContext(ctx, `ALTER TABLE APPLICATIONS ADD CONSTRAINT APPLICATIONS_CANDIDATE_REQUISITION UNIQUE (CANDIDATE_ID, REQUISITION_ID)`); err != nil {
+		return unavailable("Could not add unique constraint to APPLICATIONS: %v", err)
+	}
+	log.Printf("Added unique constraint of APPLICATIONS to SAP HANA schema")
+	return nil
+}
+
+//Returns true when the table of the current schema has the column.
+func hasHanaColumn(ctx context.Context, conn *sql.DB, table string, column string) (bool, error) {
+	var n int
+	err := conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM SYS.TABLE_COLUMNS WHERE SCHEMA_NAME = CURRENT_SCHEMA AND TABLE_NAME = ? AND COLUMN_NAME = ?`, table, column).Scan(&n)
+	return n > 0, err
+}
+
+//NewHanaStores returns the stores backed by the SAP HANA tables described on hanaSchema.
+func NewHanaStores(conn *sql.DB) Stores {
+	return Stores{
+		Candidates:      hanaCandidateStore{conn},
+		Countries:       hanaCountryStore{conn},
+		JobRequisitions: hanaJobRequisitionStore{conn},
+		Applications:    hanaApplicationStore{conn},
+		Tags:            hanaTagStore{conn},
+		Interviews:      hanaInterviewStore{conn},
+		Offers:          hanaOfferStore{conn},
+		Merges:          hanaMergeStore{conn},
+		Attachments:     hanaAttachmentStore{conn},
+		Sequences:       hanaSequenceStore{conn},
+	}
+}
+
+type hanaCandidateStore struct {
+	conn *sql.DB
+}
+
+func (s hanaCandidateStore) FindAll(ctx context.Context) ([]Candidate, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT ID, FIRST_NAME, LAST_NAME, EMAIL, ADDRESS, COUNTRY_ID FROM CANDIDATES ORDER BY ID`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ret []Candidate
+	index := make(map[int]int)
+	for rows.Next() {
+		var c Candidate
+		var address sql.NullString
+		var countryID sql.NullInt64
+		if err = rows.Scan(&c.ID, &c.FirstName, &c.LastName, &c.Email, &address, &countryID); err != nil {
+			return nil, err
+		}
+		c.Address = address.String
+		c.CanCountryId = int(countryID.Int64)
+
+		index[c.ID] = len(ret)
+		ret = append(ret, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tagRows, err := s.conn.QueryContext(ctx, `SELECT CANDIDATE_ID, TAG_ID, LABEL FROM CANDIDATE_TAGS ORDER BY CANDIDATE_ID, POSITION`)
+	if err != nil {
+		return nil, err
+	}
+	defer tagRows.Close()
+
+	for tagRows.Next() {
+		var candidateID int
+		var tagID sql.NullInt64
+		var t Tag
+		if err = tagRows.Scan(&candidateID, &tagID, &t.Label); err != nil {
+			return nil, err
+		}
+		t.ID = int(tagID.Int64)
+
+		if i, found := index[candidateID]; found {
+			ret[i].Tags = append(ret[i].Tags, t)
+		}
+	}
+	return ret, tagRows.Err()
+}
+
+func (s hanaCandidateStore) Insert(ctx context.Context, c Candidate) error {
+	err := inHanaTransaction(ctx, s.conn, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO CANDIDATES (ID, FIRST_NAME, LAST_NAME, EMAIL, ADDRESS, COUNTRY_ID) VALUES (?, ?, ?, ?, ?, ?)`,
+			c.ID, c.FirstName, c.LastName, c.Email, c.Address, c.CanCountryId)
+		if err != nil {
+			return err
+		}
+		return insertHanaCandidateTags(ctx, tx, c)
+	})
+	if err != nil {
+		return unavailable("Could not insert Candidate provided")
+	}
+	return nil
+}
+
+func (s hanaCandidateStore) Update(ctx context.Context, c Candidate) error {
+	err := inHanaTransaction(ctx, s.conn, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `UPDATE CANDIDATES SET FIRST_NAME = ?, LAST_NAME = ?, EMAIL = ?, ADDRESS = ?, COUNTRY_ID = ? WHERE ID = ?`,
+			c.FirstName, c.LastName, c.Email, c.Address, c.CanCountryId, c.ID)
+		if err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, `DELETE FROM CANDIDATE_TAGS WHERE CANDIDATE_ID = ?`, c.ID); err != nil {
+			return err
+		}
+		return insertHanaCandidateTags(ctx, tx, c)
+	})
+	if err != nil {
+		return unavailable("Could not update candidate provided")
+	}
+	return nil
+}
+
+func (s hanaCandidateStore) Delete(ctx context.Context, id int) error {
+	err := inHanaTransaction(ctx, s.conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM CANDIDATE_TAGS WHERE CANDIDATE_ID = ?`, id); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM CANDIDATES WHERE ID = ?`, id)
+		return err
+	})
+	if err != nil {
+		return unavailable("Could not delete Candidate")
+	}
+	return nil
+}
+
+//Inserts the tags of the Candidate keeping the order in which they were provided.
+func insertHanaCandidateTags(ctx context.Context, tx *sql.Tx, c Candidate) error {
+	for i, t := range c.Tags {
+		_, err := tx.ExecContext(ctx, `INSERT INTO CANDIDATE_TAGS (CANDIDATE_ID, POSITION, TAG_ID, LABEL) VALUES (?, ?, ?, ?)`,
+			c.ID, i, t.ID, t.Label)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type hanaCountryStore struct {
+	conn *sql.DB
+}
+
+func (s hanaCountryStore) FindAll(ctx context.Context) ([]Country, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT ID, NAME, CODE FROM COUNTRIES ORDER BY ID`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ret []Country
+	for rows.Next() {
+		var c Country
+		if err = rows.Scan(&c.ID, &c.Name, &c.Code); err != nil {
+			return nil, err
+		}
+		ret = append(ret, c)
+	}
+	return ret, rows.Err()
+}
+
+func (s hanaCountryStore) Insert(ctx context.Context, c Country) error {
+	if _, err := s.conn.ExecContext(ctx, `INSERT INTO COUNTRIES (ID, NAME, CODE) VALUES (?, ?, ?)`, c.ID, c.Name, c.Code); err != nil {
+		return unavailable("Could not insert Country provided")
+	}
+	return nil
+}
+
+func (s hanaCountryStore) Update(ctx context.Context, c Country) error {
+	if _, err := s.conn.ExecContext(ctx, `UPDATE COUNTRIES SET NAME = ?, CODE = ? WHERE ID = ?`, c.Name, c.Code, c.ID); err != nil {
+		return unavailable("Could not update country on the database")
+	}
+	return nil
+}
+
+func (s hanaCountryStore) Delete(ctx context.Context, id int) error {
+	if _, err := s.conn.ExecContext(ctx, `DELETE FROM COUNTRIES WHERE ID = ?`, id); err != nil {
+		return unavailable("Could not delete Country")
+	}
+	return nil
+}
+
+type hanaJobRequisitionStore struct {
+	conn *sql.DB
+}
+
+func (s hanaJobRequisitionStore) FindAll(ctx context.Context) ([]JobRequisition, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT ID, TITLE, JOB_DESCRIPTION, POSTING_STATUS, STATE, OPENING_DATE, CLOSING_DATE, POST_AT, UNPOST_AT, HIRING_MANAGER, RECRUITER, COUNTRY_ID, HEADCOUNT, FILLED_COUNT, YEARS_OF_EXPERIENCE, VERSION FROM REQUISITIONS ORDER BY ID`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ret []JobRequisition
+	index := make(map[int]int)
+	for rows.Next() {
+		var jr JobRequisition
+		var state, manager, recruiter sql.NullString
+		var opening, closing, postAt, unpostAt sql.NullTime
+		var countryID, headcount, filled, years, version sql.NullInt64
+		if err = rows.Scan(&jr.ID, &jr.Title, &jr.JobDescription, &jr.PostingStatus, &state, &opening, &closing, &postAt, &unpostAt, &manager, &recruiter, &countryID, &headcount, &filled, &years, &version); err != nil {
+			return nil, err
+		}
+		jr.State = state.String
+		if opening.Valid {
+			jr.OpeningDate = opening.Time.UTC()
+		}
+		if closing.Valid {
+			jr.ClosingDate = closing.Time.UTC()
+		}
+		if postAt.Valid {
+			jr.PostAt = postAt.Time.UTC()
+		}
+		if unpostAt.Valid {
+			jr.UnpostAt = unpostAt.Time.UTC()
+		}
+		jr.HiringManager = manager.String
+		jr.Recruiter = recruiter.String
+		jr.JrCountryId = int(countryID.Int64)
+		jr.Headcount = int(headcount.Int64)
+		jr.FilledCount = int(filled.Int64)
+		jr.YearsOfExperience = int(years.Int64)
+		jr.Version = int(version.Int64)
+
+		index[jr.ID] = len(ret)
+		ret = append(ret, jr)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	stateRows, err := s.conn.QueryContext(ctx, `SELECT REQUISITION_ID, FROM_STATUS, TO_STATUS, CHANGED_BY, CHANGED_AT, COMMENT FROM REQUISITION_STATES ORDER BY REQUISITION_ID, POSITION`)
+	if err != nil {
+		return nil, err
+	}
+	defer stateRows.Close()
+
+	for stateRows.Next() {
+		var requisitionID int
+		var from, by, comment sql.NullString
+		var sc StageChange
+		if err = stateRows.Scan(&requisitionID, &from, &sc.To, &by, &sc.At, &comment); err != nil {
+			return nil, err
+		}
+		sc.From = from.String
+		sc.By = by.String
+		sc.Comment = comment.String
+
+		if i, found := index[requisitionID]; found {
+			ret[i].StateHistory = append(ret[i].StateHistory, sc)
+		}
+	}
+	if err = stateRows.Err(); err != nil {
+		return nil, err
+	}
+
+	approvalRows, err := s.conn.QueryContext(ctx, `SELECT REQUISITION_ID, ROUND, STEP, APPROVED, APPROVED_BY, APPROVED_AT, COMMENT FROM REQUISITION_APPROVALS ORDER BY REQUISITION_ID, POSITION`)
+	if err != nil {
+		return nil, err
+	}
+	defer approvalRows.Close()
+
+	for approvalRows.Next() {
+		var requisitionID int
+		var by, comment sql.NullString
+		var a Approval
+		if err = approvalRows.Scan(&requisitionID, &a.Round, &a.Step, &a.Approved, &by, &a.At, &comment); err != nil {
+			return nil, err
+		}
+		a.By = by.String
+		a.Comment = comment.String
+
+		if i, found := index[requisitionID]; found {
+			ret[i].Approvals = append(ret[i].Approvals, a)
+		}
+	}
+	if err = approvalRows.Err(); err != nil {
+		return nil, err
+	}
+
+	tagRows, err := s.conn.QueryContext(ctx, `SELECT REQUISITION_ID, REQUIRED, TAG_ID, LABEL FROM REQUISITION_TAGS ORDER BY REQUISITION_ID, POSITION`)
+	if err != nil {
+		return nil, err
+	}
+	defer tagRows.Close()
+
+	for tagRows.Next() {
+		var requisitionID int
+		var required bool
+		var tagID sql.NullInt64
+		var t Tag
+		if err = tagRows.Scan(&requisitionID, &required, &tagID, &t.Label); err != nil {
+			return nil, err
+		}
+		t.ID = int(tagID.Int64)
+
+		if i, found := index[requisitionID]; found {
+			if required {
+				ret[i].RequiredTags = append(ret[i].RequiredTags, t)
+			} else {
+				ret[i].PreferredTags = append(ret[i].PreferredTags, t)
+			}
+		}
+	}
+	return ret, tagRows.Err()
+}
+
+func (s hanaJobRequisitionStore) Insert(ctx context.Context, jr JobRequisition) error {
+	err := inHanaTransaction(ctx, s.conn, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO REQUISITIONS (ID, TITLE, JOB_DESCRIPTION, POSTING_STATUS, STATE, OPENING_DATE, CLOSING_DATE, POST_AT, UNPOST_AT, HIRING_MANAGER, RECRUITER, COUNTRY_ID, HEADCOUNT, FILLED_COUNT, YEARS_OF_EXPERIENCE, VERSION) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			jr.ID, jr.Title, jr.JobDescription, jr.PostingStatus, jr.State, hanaTime(jr.OpeningDate), hanaTime(jr.ClosingDate), hanaTime(jr.PostAt), hanaTime(jr.UnpostAt), jr.HiringManager, jr.Recruiter, jr.JrCountryId, jr.Headcount, jr.FilledCount, jr.YearsOfExperience, jr.Version)
+		if err != nil {
+			return err
+		}
+		if err = insertHanaStatusHistory(ctx, tx, "REQUISITION_STATES", "REQUISITION_ID", jr.ID, jr.StateHistory); err != nil {
+			return err
+		}
+		if err = insertHanaApprovals(ctx, tx, jr); err != nil {
+			return err
+		}
+		return insertHanaRequisitionTags(ctx, tx, jr)
+	})
+	if err != nil {
+		return unavailable("Could not insert Job Requisition provided")
+	}
+	return nil
+}
+
+func (s hanaJobRequisitionStore) Update(ctx context.Context, jr JobRequisition) error {
+	err := inHanaTransaction(ctx, s.conn, func(tx *sql.Tx) error {
+		err := updateHanaVersion(ctx, tx, `UPDATE REQUISITIONS SET TITLE = ?, JOB_DESCRIPTION = ?, POSTING_STATUS = ?, STATE = ?, OPENING_DATE = ?, CLOSING_DATE = ?, POST_AT = ?, UNPOST_AT = ?, HIRING_MANAGER = ?, RECRUITER = ?, COUNTRY_ID = ?, HEADCOUNT = ?, FILLED_COUNT = ?, YEARS_OF_EXPERIENCE = ?, VERSION = ? WHERE ID = ? AND COALESCE(VERSION, 0) = ?`,
+			jr.Title, jr.JobDescription, jr.PostingStatus, jr.State, hanaTime(jr.OpeningDate), hanaTime(jr.ClosingDate), hanaTime(jr.PostAt), hanaTime(jr.UnpostAt), jr.HiringManager, jr.Recruiter, jr.JrCountryId, jr.Headcount, jr.FilledCount, jr.YearsOfExperience, jr.Version, jr.ID, jr.Version-1)
+		if err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, `DELETE FROM REQUISITION_STATES WHERE REQUISITION_ID = ?`, jr.ID); err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, `DELETE FROM REQUISITION_APPROVALS WHERE REQUISITION_ID = ?`, jr.ID); err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, `DELETE FROM REQUISITION_TAGS WHERE REQUISITION_ID = ?`, jr.ID); err != nil {
+			return err
+		}
+		if err = insertHanaStatusHistory(ctx, tx, "REQUISITION_STATES", "REQUISITION_ID", jr.ID, jr.StateHistory); err != nil {
+			return err
+		}
+		if err = insertHanaApprovals(ctx, tx, jr); err != nil {
+			return err
+		}
+		return insertHanaRequisitionTags(ctx, tx, jr)
+	})
+	if err == errHanaStaleVersion {
+		return staleRecord("Job Requisition", jr.ID)
+	}
+	if err != nil {
+		return unavailable("Could not update  Requisition provided")
+	}
+	return nil
+}
+
+func (s hanaJobRequisitionStore) Delete(ctx context.Context, id int) error {
+	err := inHanaTransaction(ctx, s.conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM REQUISITION_STATES WHERE REQUISITION_ID = ?`, id); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM REQUISITION_APPROVALS WHERE REQUISITION_ID = ?`, id); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM REQUISITION_TAGS WHERE REQUISITION_ID = ?`, id); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM REQUISITIONS WHERE ID = ?`, id)
+		return err
+	})
+	if err != nil {
+		return unavailable("Could not delete requisition wiht ID provided")
+	}
+	return nil
+}
+
+func insertHanaApprovals(ctx context.Context, tx *sql.Tx, jr JobRequisition) error {
+	for i, a := range jr.Approvals {
+		_, err := tx.ExecContext(ctx, `INSERT INTO REQUISITION_APPROVALS (REQUISITION_ID, POSITION, ROUND, STEP, APPROVED, APPROVED_BY, APPROVED_AT, COMMENT) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			jr.ID, i, a.Round, a.Step, a.Approved, a.By, a.At, a.Comment)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Inserts the required Tags of the JobRequisition and then the preferred ones, keeping their order.
+func insertHanaRequisitionTags(ctx context.Context, tx *sql.Tx, jr JobRequisition) error {
+	tags := append(append([]Tag(nil), jr.RequiredTags...), jr.PreferredTags...)
+	for i, t := range tags {
+		_, err := tx.ExecContext(ctx, `INSERT INTO REQUISITION_TAGS (REQUISITION_ID, POSITION, REQUIRED, TAG_ID, LABEL) VALUES (?, ?, ?, ?, ?)`,
+			jr.ID, i, i < len(jr.RequiredTags), t.ID, t.Label)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Returns NULL for the zero time, so optional dates are not stored as year 1.
+func hanaTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+type hanaApplicationStore struct {
+	conn *sql.DB
+}
+
+func (s hanaApplicationStore) FindAll(ctx context.Context) ([]Application, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT ID, CANDIDATE_ID, REQUISITION_ID, SALARY_EXPECTATION, APPLICATION_SOURCE, TIME_OF_EXPERIENCE, STAGE, STATUS, WITHDRAWN_AT, WITHDRAWN_BY, WITHDRAWAL_REASON FROM APPLICATIONS ORDER BY ID`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ret []Application
+	index := make(map[int]int)
+	for rows.Next() {
+		var a Application
+		var salary, source, stage, status, withdrawnBy, reason sql.NullString
+		var experience sql.NullInt64
+		var withdrawnAt sql.NullTime
+		if err = rows.Scan(&a.ID, &a.CandidateProfileID, &a.JobRequisitionID, &salary, &source, &experience, &stage, &status, &withdrawnAt, &withdrawnBy, &reason); err != nil {
+			return nil, err
+		}
+		a.SalaryExpectation = salary.String
+		a.ApplicationSource = source.String
+		a.TimeOfExperience = int(experience.Int64)
+		a.Stage = stage.String
+		a.Status = status.String
+		if withdrawnAt.Valid {
+			a.WithdrawnAt = withdrawnAt.Time.UTC()
+		}
+		a.WithdrawnBy = withdrawnBy.String
+		a.WithdrawalReason = reason.String
+
+		index[a.ID] = len(ret)
+		ret = append(ret, a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	stageRows, err := s.conn.QueryContext(ctx, `SELECT APPLICATION_ID, FROM_STAGE, TO_STAGE, CHANGED_BY, CHANGED_AT, COMMENT FROM APPLICATION_STAGES ORDER BY APPLICATION_ID, POSITION`)
+	if err != nil {
+		return nil, err
+	}
+	defer stageRows.Close()
+
+	for stageRows.Next() {
+		var applicationID int
+		var from, by, comment sql.NullString
+		var sc StageChange
+		if err = stageRows.Scan(&applicationID, &from, &sc.To, &by, &sc.At, &comment); err != nil {
+			return nil, err
+		}
+		sc.From = from.String
+		sc.By = by.String
+		sc.Comment = comment.String
+
+		if i, found := index[applicationID]; found {
+			ret[i].StageHistory = append(ret[i].StageHistory, sc)
+		}
+	}
+	return ret, stageRows.Err()
+}
+
+func (s hanaApplicationStore) Insert(ctx context.Context, a Application) error {
+	err := inHanaTransaction(ctx, s.conn, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO APPLICATIONS (ID, CANDIDATE_ID, REQUISITION_ID, SALARY_EXPECTATION, APPLICATION_SOURCE, TIME_OF_EXPERIENCE, STAGE, STATUS, WITHDRAWN_AT, WITHDRAWN_BY, WITHDRAWAL_REASON) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			a.ID, a.CandidateProfileID, a.JobRequisitionID, a.SalaryExpectation, a.ApplicationSource, a.TimeOfExperience, a.Stage, a.Status, hanaTime(a.WithdrawnAt), a.WithdrawnBy, a.WithdrawalReason)
+		if err != nil {
+			return err
+		}
+		return insertHanaStageHistory(ctx, tx, a)
+	})
+	if isHanaError(err, hanaUniqueConstraintViolated) {
+		return conflict("Candidate already applied to the Job Requisition")
+	}
+	if err != nil {
+		return unavailable("Could not insert application provided")
+	}
+	return nil
+}
+
+func (s hanaApplicationStore) Update(ctx context.Context, a Application) error {
+	err := inHanaTransaction(ctx, s.conn, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `UPDATE APPLICATIONS SET CANDIDATE_ID = ?, REQUISITION_ID = ?, SALARY_EXPECTATION = ?, APPLICATION_SOURCE = ?, TIME_OF_EXPERIENCE = ?, STAGE = ?, STATUS = ?, WITHDRAWN_AT = ?, WITHDRAWN_BY = ?, WITHDRAWAL_REASON = ? WHERE ID = ?`,
+			a.CandidateProfileID, a.JobRequisitionID, a.SalaryExpectation, a.ApplicationSource, a.TimeOfExperience, a.Stage, a.Status, hanaTime(a.WithdrawnAt), a.WithdrawnBy, a.WithdrawalReason, a.ID)
+		if err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, `DELETE FROM APPLICATION_STAGES WHERE APPLICATION_ID = ?`, a.ID); err != nil {
+			return err
+		}
+		return insertHanaStageHistory(ctx, tx, a)
+	})
+	if isHanaError(err, hanaUniqueConstraintViolated) {
+		return conflict("Candidate already applied to the Job Requisition")
+	}
+	if err != nil {
+		return unavailable("Could not update application provided")
+	}
+	return nil
+}
+
+//Inserts the stage history of the Application keeping the order in which the changes happened.
+func insertHanaStageHistory(ctx context.Context, tx *sql.Tx, a Application) error {
+	for i, sc := range a.StageHistory {
+		_, err := tx.ExecContext(ctx, `INSERT INTO APPLICATION_STAGES (APPLICATION_ID, POSITION, FROM_STAGE, TO_STAGE, CHANGED_BY, CHANGED_AT, COMMENT) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			a.ID, i, sc.From, sc.To, sc.By, sc.At, sc.Comment)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s hanaApplicationStore) Delete(ctx context.Context, id int) error {
+	err := inHanaTransaction(ctx, s.conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM APPLICATION_STAGES WHERE APPLICATION_ID = ?`, id); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM APPLICATIONS WHERE ID = ?`, id)
+		return err
+	})
+	if err != nil {
+		return unavailable("Could not delete Application with id provided")
+	}
+	return nil
+}
+
+type hanaInterviewStore struct {
+	conn *sql.DB
+}
+
+func (s hanaInterviewStore) FindAll(ctx context.Context) ([]Interview, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT ID, APPLICATION_ID, START_AT, END_AT, TIME_ZONE, LOCATION, VIDEO_LINK, OUTCOME, NOTES FROM INTERVIEWS ORDER BY ID`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ret []Interview
+	index := make(map[int]int)
+	for rows.Next() {
+		var i Interview
+		var location, videoLink, notes sql.NullString
+		if err = rows.Scan(&i.ID, &i.ApplicationID, &i.Start, &i.End, &i.TimeZone, &location, &videoLink, &i.Outcome, &notes); err != nil {
+			return nil, err
+		}
+		i.Start = i.Start.UTC()
+		i.End = i.End.UTC()
+		i.Location = location.String
+		i.VideoLink = videoLink.String
+		i.Notes = notes.String
+
+		index[i.ID] = len(ret)
+		ret = append(ret, i)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	interviewerRows, err := s.conn.QueryContext(ctx, `SELECT INTERVIEW_ID, NAME, EMAIL FROM INTERVIEW_INTERVIEWERS ORDER BY INTERVIEW_ID, POSITION`)
+	if err != nil {
+		return nil, err
+	}
+	defer interviewerRows.Close()
+
+	for interviewerRows.Next() {
+		var interviewID int
+		var name sql.NullString
+		var iv Interviewer
+		if err = interviewerRows.Scan(&interviewID, &name, &iv.Email); err != nil {
+			return nil, err
+		}
+		iv.Name = name.String
+
+		if n, found := index[interviewID]; found {
+			ret[n].Interviewers = append(ret[n].Interviewers, iv)
+		}
+	}
+	return ret, interviewerRows.Err()
+}
+
+func (s hanaInterviewStore) Insert(ctx context.Context, i Interview) error {
+	err := inHanaTransaction(ctx, s.conn, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO INTERVIEWS (ID, APPLICATION_ID, START_AT, END_AT, TIME_ZONE, LOCATION, VIDEO_LINK, OUTCOME, NOTES) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			i.ID, i.ApplicationID, i.Start, i.End, i.TimeZone, i.Location, i.VideoLink, i.Outcome, i.Notes)
+		if err != nil {
+			return err
+		}
+		return insertHanaInterviewers(ctx, tx, i)
+	})
+	if err != nil {
+		return unavailable("Could not insert interview provided")
+	}
+	return nil
+}
+
+func (s hanaInterviewStore) Update(ctx context.Context, i Interview) error {
+	err := inHanaTransaction(ctx, s.conn, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `UPDATE INTERVIEWS SET APPLICATION_ID = ?, START_AT = ?, END_AT = ?, TIME_ZONE = ?, LOCATION = ?, VIDEO_LINK = ?, OUTCOME = ?, NOTES = ? WHERE ID = ?`,
+			i.ApplicationID, i.Start, i.End, i.TimeZone, i.Location, i.VideoLink, i.Outcome, i.Notes, i.ID)
+		if err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, `DELETE FROM INTERVIEW_INTERVIEWERS WHERE INTERVIEW_ID = ?`, i.ID); err != nil {
+			return err
+		}
+		return insertHanaInterviewers(ctx, tx, i)
+	})
+	if err != nil {
+		return unavailable("Could not update interview provided")
+	}
+	return nil
+}
+
+func (s hanaInterviewStore) Delete(ctx context.Context, id int) error {
+	err := inHanaTransaction(ctx, s.conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM INTERVIEW_INTERVIEWERS WHERE INTERVIEW_ID = ?`, id); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM INTERVIEWS WHERE ID = ?`, id)
+		return err
+	})
+	if err != nil {
+		return unavailable("Could not delete Interview with id provided")
+	}
+	return nil
+}
+
+//Inserts the interviewers keeping the order in which they were provided.
+func insertHanaInterviewers(ctx context.Context, tx *sql.Tx, i Interview) error {
+	for n, iv := range i.Interviewers {
+		_, err := tx.ExecContext(ctx, `INSERT INTO INTERVIEW_INTERVIEWERS (INTERVIEW_ID, POSITION, NAME, EMAIL) VALUES (?, ?, ?, ?)`,
+			i.ID, n, iv.Name, iv.Email)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type hanaOfferStore struct {
+	conn *sql.DB
+}
+
+func (s hanaOfferStore) FindAll(ctx context.Context) ([]Offer, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT ID, APPLICATION_ID, SALARY, CURRENCY, SALARY_PERIOD, START_DATE, EXPIRES_AT, STATUS, VERSION FROM OFFERS ORDER BY ID`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ret []Offer
+	index := make(map[int]int)
+	for rows.Next() {
+		var o Offer
+		var version sql.NullInt64
+		if err = rows.Scan(&o.ID, &o.ApplicationID, &o.Salary, &o.Currency, &o.SalaryPeriod, &o.StartDate, &o.ExpiresAt, &o.Status, &version); err != nil {
+			return nil, err
+		}
+		o.Version = int(version.Int64)
+		o.StartDate = o.StartDate.UTC()
+		o.ExpiresAt = o.ExpiresAt.UTC()
+
+		index[o.ID] = len(ret)
+		ret = append(ret, o)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statusRows, err := s.conn.QueryContext(ctx, `SELECT OFFER_ID, FROM_STATUS, TO_STATUS, CHANGED_BY, CHANGED_AT, COMMENT FROM OFFER_STATUSES ORDER BY OFFER_ID, POSITION`)
+	if err != nil {
+		return nil, err
+	}
+	defer statusRows.Close()
+
+	for statusRows.Next() {
+		var offerID int
+		var from, by, comment sql.NullString
+		var sc StageChange
+		if err = statusRows.Scan(&offerID, &from, &sc.To, &by, &sc.At, &comment); err != nil {
+			return nil, err
+		}
+		sc.From = from.String
+		sc.By = by.String
+		sc.Comment = comment.String
+
+		if i, found := index[offerID]; found {
+			ret[i].StatusHistory = append(ret[i].StatusHistory, sc)
+		}
+	}
+	return ret, statusRows.Err()
+}
+
+func (s hanaOfferStore) Insert(ctx context.Context, o Offer) error {
+	err := inHanaTransaction(ctx, s.conn, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO OFFERS (ID, APPLICATION_ID, SALARY, CURRENCY, SALARY_PERIOD, START_DATE, EXPIRES_AT, STATUS, VERSION) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			o.ID, o.ApplicationID, o.Salary, o.Currency, o.SalaryPeriod, o.StartDate, o.ExpiresAt, o.Status, o.Version)
+		if err != nil {
+			return err
+		}
+		return insertHanaStatusHistory(ctx, tx, "OFFER_STATUSES", "OFFER_ID", o.ID, o.StatusHistory)
+	})
+	if err != nil {
+		return unavailable("Could not insert offer provided")
+	}
+	return nil
+}
+
+func (s hanaOfferStore) Update(ctx context.Context, o Offer) error {
+	err := inHanaTransaction(ctx, s.conn, func(tx *sql.Tx) error {
+		err := updateHanaVersion(ctx, tx, `UPDATE OFFERS SET APPLICATION_ID = ?, SALARY = ?, CURRENCY = ?, SALARY_PERIOD = ?, START_DATE = ?, EXPIRES_AT = ?, STATUS = ?, VERSION = ? WHERE ID = ? AND COALESCE(VERSION, 0) = ?`,
+			o.ApplicationID, o.Salary, o.Currency, o.SalaryPeriod, o.StartDate, o.ExpiresAt, o.Status, o.Version, o.ID, o.Version-1)
+		if err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, `DELETE FROM OFFER_STATUSES WHERE OFFER_ID = ?`, o.ID); err != nil {
+			return err
+		}
+		return insertHanaStatusHistory(ctx, tx, "OFFER_STATUSES", "OFFER_ID", o.ID, o.StatusHistory)
+	})
+	if err == errHanaStaleVersion {
+		return staleRecord("Offer", o.ID)
+	}
+	if err != nil {
+		return unavailable("Could not update offer provided")
+	}
+	return nil
+}
+
+func (s hanaOfferStore) Delete(ctx context.Context, id int) error {
+	err := inHanaTransaction(ctx, s.conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM OFFER_STATUSES WHERE OFFER_ID = ?`, id); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM OFFERS WHERE ID = ?`, id)
+		return err
+	})
+	if err != nil {
+		return unavailable("Could not delete Offer with id provided")
+	}
+	return nil
+}
+
+//Inserts the status history of a record on table, keeping the order in which the changes happened.
+//The table must have the columns of OFFER_STATUSES, with the ID of the record on the column named by key.
+func insertHanaStatusHistory(ctx context.Context, tx *sql.Tx, table string, key string, id int, history []StageChange) error {
+	stmt := `INSERT INTO ` + table + ` (` + key + `, POSITION, FROM_STATUS, TO_STATUS, CHANGED_BY, CHANGED_AT, COMMENT) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	for i, sc := range history {
+		if _, err := tx.ExecContext(ctx, stmt, id, i, sc.From, sc.To, sc.By, sc.At, sc.Comment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type hanaMergeStore struct {
+	conn *sql.DB
+}
+
+func (s hanaMergeStore) FindAll(ctx context.Context) ([]CandidateMerge, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT ID, TARGET_ID, SOURCE_ID, SOURCE_FIRST_NAME, SOURCE_LAST_NAME, SOURCE_EMAIL, SOURCE_ADDRESS, MERGED_BY, MERGED_AT, STATUS FROM CANDIDATE_MERGES ORDER BY ID`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ret []CandidateMerge
+	index := make(map[int]int)
+	for rows.Next() {
+		var m CandidateMerge
+		var first, last, email, address, by, status sql.NullString
+		if err = rows.Scan(&m.ID, &m.TargetID, &m.SourceID, &first, &last, &email, &address, &by, &m.At, &status); err != nil {
+			return nil, err
+		}
+		m.SourceFirstName = first.String
+		m.SourceLastName = last.String
+		m.SourceEmail = email.String
+		m.SourceAddress = address.String
+		m.By = by.String
+		m.At = m.At.UTC()
+		m.Status = status.String
+
+		index[m.ID] = len(ret)
+		ret = append(ret, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	appRows, err := s.conn.QueryContext(ctx, `SELECT MERGE_ID, APPLICATION_ID, MOVED FROM CANDIDATE_MERGE_APPLICATIONS ORDER BY MERGE_ID, APPLICATION_ID`)
+	if err != nil {
+		return nil, err
+	}
+	defer appRows.Close()
+
+	for appRows.Next() {
+		var mergeID, applicationID int
+		var moved bool
+		if err = appRows.Scan(&mergeID, &applicationID, &moved); err != nil {
+			return nil, err
+		}
+		if i, found := index[mergeID]; found && moved {
+			ret[i].MovedApplications = append(ret[i].MovedApplications, applicationID)
+		} else if found {
+			ret[i].RemovedApplications = append(ret[i].RemovedApplications, applicationID)
+		}
+	}
+	if err = appRows.Err(); err != nil {
+		return nil, err
+	}
+
+	tagRows, err := s.conn.QueryContext(ctx, `SELECT MERGE_ID, LABEL FROM CANDIDATE_MERGE_TAGS ORDER BY MERGE_ID, POSITION`)
+	if err != nil {
+		return nil, err
+	}
+	defer tagRows.Close()
+
+	for tagRows.Next() {
+		var mergeID int
+		var label string
+		if err = tagRows.Scan(&mergeID, &label); err != nil {
+			return nil, err
+		}
+		if i, found := index[mergeID]; found {
+			ret[i].AddedTags = append(ret[i].AddedTags, label)
+		}
+	}
+	return ret, tagRows.Err()
+}
+
+func (s hanaMergeStore) Insert(ctx context.Context, m CandidateMerge) error {
+	err := inHanaTransaction(ctx, s.conn, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO CANDIDATE_MERGES (ID, TARGET_ID, SOURCE_ID, SOURCE_FIRST_NAME, SOURCE_LAST_NAME, SOURCE_EMAIL, SOURCE_ADDRESS, MERGED_BY, MERGED_AT, STATUS) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			m.ID, m.TargetID, m.SourceID, m.SourceFirstName, m.SourceLastName, m.SourceEmail, m.SourceAddress, m.By, m.At, m.Status)
+		if err != nil {
+			return err
+		}
+		return insertHanaMergeDetails(ctx, tx, m)
+	})
+	if err != nil {
+		return unavailable("Could not insert Candidate merge provided")
+	}
+	return nil
+}
+
+func (s hanaMergeStore) Update(ctx context.Context, m CandidateMerge) error {
+	err := inHanaTransaction(ctx, s.conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `UPDATE CANDIDATE_MERGES SET STATUS = ? WHERE ID = ?`, m.Status, m.ID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM CANDIDATE_MERGE_APPLICATIONS WHERE MERGE_ID = ?`, m.ID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM CANDIDATE_MERGE_TAGS WHERE MERGE_ID = ?`, m.ID); err != nil {
+			return err
+		}
+		return insertHanaMergeDetails(ctx, tx, m)
+	})
+	if err != nil {
+		return unavailable("Could not update Candidate merge provided")
+	}
+	return nil
+}
+
+//Inserts the applications and tags of the merge.
+func insertHanaMergeDetails(ctx context.Context, tx *sql.Tx, m CandidateMerge) error {
+	stmt := `INSERT INTO CANDIDATE_MERGE_APPLICATIONS (MERGE_ID, APPLICATION_ID, MOVED) VALUES (?, ?, ?)`
+	for _, id := range m.MovedApplications {
+		if _, err := tx.ExecContext(ctx, stmt, m.ID, id, true); err != nil {
+			return err
+		}
+	}
+	for _, id := range m.RemovedApplications {
+		if _, err := tx.ExecContext(ctx, stmt, m.ID, id, false); err != nil {
+			return err
+		}
+	}
+
+	for i, label := range m.AddedTags {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO CANDIDATE_MERGE_TAGS (MERGE_ID, POSITION, LABEL) VALUES (?, ?, ?)`, m.ID, i, label); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type hanaAttachmentStore struct {
+	conn *sql.DB
+}
+
+func (s hanaAttachmentStore) FindAll(ctx context.Context) ([]Attachment, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT ID, CANDIDATE_ID, KIND, FILE_NAME, CONTENT_TYPE, SIZE, CHECKSUM, UPLOADED_BY, UPLOADED_AT, CONTENT_TEXT FROM ATTACHMENTS ORDER BY ID`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ret []Attachment
+	for rows.Next() {
+		var a Attachment
+		var contentType, by sql.NullString
+		var text strings.Builder
+		lob := driver.NullLob{Lob: driver.NewLob(nil, &text)}
+		if err = rows.Scan(&a.ID, &a.CandidateID, &a.Kind, &a.FileName, &contentType, &a.Size, &a.Checksum, &by, &a.UploadedAt, &lob); err != nil {
+			return nil, err
+		}
+		a.ContentType = contentType.String
+		a.UploadedBy = by.String
+		a.UploadedAt = a.UploadedAt.UTC()
+		a.Text = text.String()
+		ret = append(ret, a)
+	}
+	return ret, rows.Err()
+}
+
+func (s hanaAttachmentStore) Insert(ctx context.Context, a Attachment) error {
+	_, err := s.conn.ExecContext(ctx, `INSERT INTO ATTACHMENTS (ID, CANDIDATE_ID, KIND, FILE_NAME, CONTENT_TYPE, SIZE, CHECKSUM, UPLOADED_BY, UPLOADED_AT, CONTENT_TEXT) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		a.ID, a.CandidateID, a.Kind, a.FileName, a.ContentType, a.Size, a.Checksum, a.UploadedBy, a.UploadedAt, hanaText(a.Text))
+	if err != nil {
+		return unavailable("Could not insert attachment provided")
+	}
+	return nil
+}
+
+func (s hanaAttachmentStore) Update(ctx context.Context, a Attachment) error {
+	_, err := s.conn.ExecContext(ctx, `UPDATE ATTACHMENTS SET CANDIDATE_ID = ?, KIND = ?, FILE_NAME = ?, CONTENT_TYPE = ?, SIZE = ?, CHECKSUM = ?, UPLOADED_BY = ?, UPLOADED_AT = ?, CONTENT_TEXT = ? WHERE ID = ?`,
+		a.CandidateID, a.Kind, a.FileName, a.ContentType, a.Size, a.Checksum, a.UploadedBy, a.UploadedAt, hanaText(a.Text), a.ID)
+	if err != nil {
+		return unavailable("Could not update attachment provided")
+	}
+	return nil
+}
+
+//Returns the text to be written on a NCLOB column, NULL when it is empty.
+func hanaText(text string) driver.NullLob {
+	return driver.NullLob{Lob: driver.NewLob(strings.NewReader(text), nil), Valid: text != ""}
+}
+
+func (s hanaAttachmentStore) Delete(ctx context.Context, id int) error {
+	if _, err := s.conn.ExecContext(ctx, `DELETE FROM ATTACHMENTS WHERE ID = ?`, id); err != nil {
+		return unavailable("Could not delete Attachment with id provided")
+	}
+	return nil
+}
+
+func (s hanaAttachmentStore) HasChecksum(ctx context.Context, checksum string) (bool, error) {
+	var n int
+	if err := s.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM ATTACHMENTS WHERE CHECKSUM = ?`, checksum).Scan(&n); err != nil {
+		return false, unavailable("Could not check attachments with checksum provided")
+	}
+	return n > 0, nil
+}
+
+type hanaTagStore struct {
+	conn *sql.DB
+}
+
+func (s hanaTagStore) FindAll(ctx context.Context) ([]Tag, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT T.ID, T.LABEL, P.PARENT_ID FROM TAGS T LEFT JOIN TAG_PARENTS P ON P.TAG_ID = T.ID ORDER BY T.ID`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ret []Tag
+	index := make(map[int]int)
+	for rows.Next() {
+		var t Tag
+		var parentID sql.NullInt64
+		if err = rows.Scan(&t.ID, &t.Label, &parentID); err != nil {
+			return nil, err
+		}
+		t.ParentID = int(parentID.Int64)
+
+		index[t.ID] = len(ret)
+		ret = append(ret, t)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	synonymRows, err := s.conn.QueryContext(ctx, `SELECT TAG_ID, LABEL FROM TAG_SYNONYMS ORDER BY TAG_ID, POSITION`)
+	if err != nil {
+		return nil, err
+	}
+	defer synonymRows.Close()
+
+	for synonymRows.Next() {
+		var tagID int
+		var label string
+		if err = synonymRows.Scan(&tagID, &label); err != nil {
+			return nil, err
+		}
+		if i, found := index[tagID]; found {
+			ret[i].Synonyms = append(ret[i].Synonyms, label)
+		}
+	}
+	return ret, synonymRows.Err()
+}
+
+func (s hanaTagStore) Insert(ctx context.Context, t Tag) error {
+	err := inHanaTransaction(ctx, s.conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO TAGS (ID, LABEL) VALUES (?, ?)`, t.ID, t.Label); err != nil {
+			return err
+		}
+		return insertHanaTagRelations(ctx, tx, t)
+	})
+	if err != nil {
+		return unavailable("Could not insert Tag provided into the Database")
+	}
+	return nil
+}
+
+func (s hanaTagStore) Update(ctx context.Context, t Tag) error {
+	err := inHanaTransaction(ctx, s.conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `UPDATE TAGS SET LABEL = ? WHERE ID = ?`, t.Label, t.ID); err != nil {
+			return err
+		}
+		if err := deleteHanaTagRelations(ctx, tx, t.ID); err != nil {
+			return err
+		}
+		return insertHanaTagRelations(ctx, tx, t)
+	})
+	if err != nil {
+		return unavailable("Could not update Tag provided")
+	}
+	return nil
+}
+
+func (s hanaTagStore) Delete(ctx context.Context, id int) error {
+	err := inHanaTransaction(ctx, s.conn, func(tx *sql.Tx) error {
+		if err := deleteHanaTagRelations(ctx, tx, id); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM TAGS WHERE ID = ?`, id)
+		return err
+	})
+	if err != nil {
+		return unavailable("Could not delete Tag with id provided")
+	}
+	return nil
+}
+
+//Inserts the synonyms of the Tag, keeping their order, and its parent.
+func insertHanaTagRelations(ctx context.Context, tx *sql.Tx, t Tag) error {
+	for i, label := range t.Synonyms {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO TAG_SYNONYMS (TAG_ID, POSITION, LABEL) VALUES (?, ?, ?)`, t.ID, i, label); err != nil {
+			return err
+		}
+	}
+	if t.ParentID != 0 {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO TAG_PARENTS (TAG_ID, PARENT_ID) VALUES (?, ?)`, t.ID, t.ParentID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteHanaTagRelations(ctx context.Context, tx *sql.Tx, id int) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM TAG_SYNONYMS WHERE TAG_ID = ?`, id); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `DELETE FROM TAG_PARENTS WHERE TAG_ID = ?`, id)
+	return err
+}
+
+//Keeps one row per sequence on the COUNTERS table, holding the last value allocated.
+type hanaSequenceStore struct {
+	conn *sql.DB
+}
+
+func (s hanaSequenceStore) Seed(ctx context.Context, name string, min int) error {
+	_, err := s.conn.ExecContext(ctx, `INSERT INTO COUNTERS (NAME, SEQ) VALUES (?, ?)`, name, min)
+	if isHanaError(err, hanaUniqueConstraintViolated) {
+		_, err = s.conn.ExecContext(ctx, `UPDATE COUNTERS SET SEQ = ? WHERE NAME = ? AND SEQ < ?`, min, name, min)
+	}
+	if err != nil {
+		return unavailable("Could not seed sequence %v", name)
+	}
+	return nil
+}
+
+func (s hanaSequenceStore) Next(ctx context.Context, name string) (int, error) {
+	var seq int
+	err := inHanaTransaction(ctx, s.conn, func(tx *sql.Tx) error {
+		//The update locks the row until the transaction ends, so concurrent callers wait for each other
+		res, err := tx.ExecContext(ctx, `UPDATE COUNTERS SET SEQ = SEQ + 1 WHERE NAME = ?`, name)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			if _, err = tx.ExecContext(ctx, `INSERT INTO COUNTERS (NAME, SEQ) VALUES (?, 1)`, name); err != nil {
+				return err
+			}
+		}
+		return tx.QueryRowContext(ctx, `SELECT SEQ FROM COUNTERS WHERE NAME = ?`, name).Scan(&seq)
+	})
+	return seq, err
+}
+
+//Returns true when err was sent by the HANA server with the code provided.
+func isHanaError(err error, code int) bool {
+	var dbErr driver.Error
+	return errors.As(err, &dbErr) && dbErr.Code() == code
+}
+
+//Runs fn inside a transaction, committing it when fn succeeds and rolling it back otherwise.
+func inHanaTransaction(ctx context.Context, conn *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,228 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"webservice/validate"
+)
+
+//Outcomes of an Interview.
+const (
+	InterviewPending   = "Pending"
+	InterviewPassed    = "Passed"
+	InterviewFailed    = "Failed"
+	InterviewNoShow    = "NoShow"
+	InterviewCancelled = "Cancelled"
+)
+
+type Interview struct {
+	ID            int
+	ApplicationID int           `validate:"required"`
+	Interviewers  []Interviewer `validate:"required,dive"`
+	Start         time.Time     `validate:"required"`
+	End           time.Time     `validate:"required"`
+	//IANA name of the time zone the interview was scheduled in, such as America/Sao_Paulo.
+	TimeZone  string `validate:"required,timezone"`
+	Location  string
+	VideoLink string `validate:"url"`
+	Outcome   string `validate:"oneof=Pending Passed Failed NoShow Cancelled"`
+	Notes     string
+}
+
+//Interviewer is identified by the email, which is used to detect double bookings.
+type Interviewer struct {
+	Name  string
+	Email string `validate:"required,email"`
+}
+
+//In Memory: Returns the Interview of the Application with id received as parameter, ordered by start time.
+//Returns a list of Interview object.
+func GetInterviewsOfApplication(id int) []Interview {
+	return records.interviewsOfApplication(id)
+}
+
+//In Memory: Returns the Interview on which the interviewer with the email received takes part, ordered by start time.
+//Returns a list of Interview object.
+func GetInterviewsOfInterviewer(email string) []Interview {
+	var ret []Interview
+	for _, i := range records.allInterviews() {
+		if i.hasInterviewer(email) {
+			ret = append(ret, i)
+		}
+	}
+	sortInterviews(ret)
+	return ret
+}
+
+//In Memory: Searches for a specific Interview of the Application.
+//Returns a Interview object and an error in case it was not possible to find the record
+func GetInterviewByID(applicationID int, id int) (Interview, error) {
+	if i, found := records.interview(id); found && i.ApplicationID == applicationID {
+		return i, nil
+	}
+	return Interview{}, notFound("Interview with ID '%v' not found for Application '%v'", id, applicationID)
+}
+
+//In DB: Schedules a new Interview for the Application.
+//Returns a Interview object and an error in case the Interview is invalid or an interviewer is already booked.
+func AddInterview(ctx context.Context, i Interview) (Interview, error) {
+	a, found := records.application(i.ApplicationID)
+	if !found {
+		return Interview{}, notFound("Application with ID '%v' not found", i.ApplicationID)
+	}
+	if a.withdrawn() {
+		return Interview{}, conflict("Application '%v' was withdrawn, no interview can be scheduled", i.ApplicationID)
+	}
+
+	if i.Outcome == "" {
+		i.Outcome = InterviewPending
+	}
+	i.normalize()
+
+	var v validate.Validator
+	v.Check(i.ID == 0, "ID", "Interview must not contain ID upon creation")
+	checkInterview(&v, i)
+	if err := invalid("Interview is not valid", &v); err != nil {
+		return Interview{}, err
+	}
+
+	if err := checkDoubleBooking(i); err != nil {
+		return Interview{}, err
+	}
+
+	id, err := nextID(ctx, interviewSequence)
+	if err != nil {
+		return Interview{}, err
+	}
+	i.ID = id
+
+	if err = stores.Interviews.Insert(ctx, i); err != nil {
+		return Interview{}, err
+	}
+
+	records.putInterview(i)
+	return i, nil
+}
+
+//In DB: Updates an Interview of the Application.
+//Returns a Interview object and an error in case the Interview is invalid or an interviewer is already booked.
+func UpdateInterview(ctx context.Context, i Interview) (Interview, error) {
+	old, err := GetInterviewByID(i.ApplicationID, i.ID)
+	if err != nil {
+		return Interview{}, err
+	}
+
+	if i.Outcome == "" {
+		i.Outcome = old.Outcome
+	}
+	i.normalize()
+
+	var v validate.Validator
+	checkInterview(&v, i)
+	if err := invalid("Interview is not valid", &v); err != nil {
+		return Interview{}, err
+	}
+
+	if err := checkDoubleBooking(i); err != nil {
+		return Interview{}, err
+	}
+
+	if err := stores.Interviews.Update(ctx, i); err != nil {
+		return Interview{}, err
+	}
+
+	records.putInterview(i)
+	return i, nil
+}
+
+//In DB: Removes an Interview of the Application.
+//Returns error if failed to complete the deletion on the DB
+func DeleteInterview(ctx context.Context, applicationID int, id int) error {
+	if _, err := GetInterviewByID(applicationID, id); err != nil {
+		return err
+	}
+
+	if err := stores.Interviews.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	records.removeInterview(id)
+	return nil
+}
+
+//In DB: Removes all Interview from a specified Application.
+//Returns error on the first Interview that could not be deleted, the ones before it are already deleted
+func DeleteInterviewsFromApplication(ctx context.Context, id int) error {
+	for _, v := range GetInterviewsOfApplication(id) {
+		if err := DeleteInterview(ctx, id, v.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Collects the violations of the rules shared by the creation and the update of an Interview.
+func checkInterview(v *validate.Validator, i Interview) {
+	v.Struct(i)
+	v.Check(i.Start.IsZero() || i.End.After(i.Start), "End", "must be after Start")
+	v.Check(i.Location != "" || i.VideoLink != "", "Location", "Location or VideoLink should be populated")
+
+	seen := make(map[string]bool)
+	for n, iv := range i.Interviewers {
+		v.Check(!seen[iv.Email], fmt.Sprintf("Interviewers[%v].Email", n), "'%v' is listed more than once", iv.Email)
+		seen[iv.Email] = true
+	}
+}
+
+//Returns a ConflictError when any of the interviewers has another Interview overlapping this one.
+//Cancelled interviews do not book their interviewers.
+//The interviews are read from the cache, so an Interview booked on another replica moments before,
+//and not yet received by this one, is not seen: the check prevents mistakes, not two bookings made at once.
+func checkDoubleBooking(i Interview) error {
+	if i.Outcome == InterviewCancelled {
+		return nil
+	}
+
+	var clashes []string
+	for _, other := range records.allInterviews() {
+		if other.ID == i.ID || other.Outcome == InterviewCancelled {
+			continue
+		}
+		if !other.Start.Before(i.End) || !i.Start.Before(other.End) {
+			continue
+		}
+		for _, iv := range i.Interviewers {
+			if other.hasInterviewer(iv.Email) {
+				clashes = append(clashes, fmt.Sprintf("%v is already booked on Interview '%v' from %v to %v",
+					iv.Email, other.ID, other.Start.Format(time.RFC3339), other.End.Format(time.RFC3339)))
+			}
+		}
+	}
+
+	if len(clashes) > 0 {
+		return conflict("Interviewer double-booked: %v", strings.Join(clashes, "; "))
+	}
+	return nil
+}
+
+//Stores the times in UTC and the emails in lower case, so they can be compared.
+func (i *Interview) normalize() {
+	i.Start = i.Start.UTC()
+	i.End = i.End.UTC()
+	i.Interviewers = append([]Interviewer(nil), i.Interviewers...)
+	for n := range i.Interviewers {
+		i.Interviewers[n].Email = strings.ToLower(strings.TrimSpace(i.Interviewers[n].Email))
+	}
+}
+
+func (i Interview) hasInterviewer(email string) bool {
+	for _, iv := range i.Interviewers {
+		if strings.EqualFold(iv.Email, email) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,34 +2,79 @@ package models
 
 import (
 	"context"
-	"fmt"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo/options"
-	"webservice/db"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"webservice/validate"
+)
+
+//States of the lifecycle of a JobRequisition.
+const (
+	RequisitionDraft           = "Draft"
+	RequisitionPendingApproval = "PendingApproval"
+	RequisitionOpen            = "Open"
+	RequisitionOnHold          = "OnHold"
+	RequisitionClosed          = "Closed"
+	RequisitionFilled          = "Filled"
 )
 
+//States a JobRequisition may move to from each state, as used on POST /jobrequisition/{id}/transition.
+var requisitionTransitions = map[string][]string{
+	RequisitionDraft:           {RequisitionPendingApproval, RequisitionClosed},
+	RequisitionPendingApproval: {RequisitionDraft, RequisitionOpen, RequisitionClosed},
+	RequisitionOpen:            {RequisitionOnHold, RequisitionClosed, RequisitionFilled},
+	RequisitionOnHold:          {RequisitionOpen, RequisitionClosed},
+	RequisitionClosed:          {RequisitionDraft},
+	RequisitionFilled:          {RequisitionOpen, RequisitionClosed},
+}
+
 type JobRequisition struct {
 	ID				int
-	Title			string
-	JobDescription	string
+	Title			string	`validate:"required"`
+	JobDescription	string	`validate:"required"`
+	//True while the State is Open, kept for the clients of /jobrequisition/posted.
 	PostingStatus	bool
+	//Only changed through TransitionJobRequisition, new requisitions start as Draft.
+	State			string	`validate:"oneof=Draft PendingApproval Open OnHold Closed Filled"`
+	StateHistory	[]StageChange
+	//Decisions taken on the approval chain of the Country, the requisition is only posted once it is approved.
+	Approvals		[]Approval
+	//Steps of the chain still to approve on the current round.
+	PendingApprovals	[]string
+	//Set when the requisition is opened and closed, unless planned beforehand.
+	OpeningDate		time.Time
+	ClosingDate		time.Time
+	//Times at which the scheduler opens the requisition and puts it on hold, cleared once handled.
+	PostAt			time.Time
+	UnpostAt		time.Time
+	HiringManager	string	`validate:"email"`
+	Recruiter		string	`validate:"email"`
 	JrCountryId		int
+	//Number of people to hire, FilledCount is increased by every accepted Offer.
+	Headcount		int	`validate:"min=0"`
+	FilledCount		int
+	//Skills the candidates must and should have, resolved to the existing Tags as the ones of a Candidate.
+	RequiredTags	[]Tag	`validate:"dive"`
+	PreferredTags	[]Tag	`validate:"dive"`
+	//Years of experience asked, matched against the TimeOfExperience of the applications of the candidates.
+	YearsOfExperience	int	`validate:"min=0"`
 	JobReqCountry	Country
 	Applicants		[]Application
+	ApplicantsByStage	[]StageCount
+	//Times the JobRequisition was written, so a change made from a JobRequisition read before another one is rejected by the store.
+	Version			int	`json:"-"`
 }
 
-var (
-	jobReqs		=	make(map[int]*JobRequisition)
-	nextJobID	=	updateJobRequisitionInMemory()
-)
-
 //In Memory: Returns the complete list of JobRequisition that has been.
 //Returns a hashmap containing the list of JobRequisition
 func GetJobRequisitions() []*JobRequisition {
 	reqArr := make([]*JobRequisition,0)
 
-	for _, v := range jobReqs {
-		reqArr = append(reqArr, v)
+	for _, v := range records.allJobRequisitions() {
+		v := v
+		reqArr = append(reqArr, &v)
 	}
 	return reqArr
 	//return jobReqs
@@ -38,127 +83,163 @@ func GetJobRequisitions() []*JobRequisition {
 //In Memory: Searches for a specific JobRequisition on the hashmap.
 //Returns a JobRequisition object and an error in case it was not possible to find the record
 func GetJobRequisitionByID(id int) (JobRequisition, error) {
-	if jr, found := jobReqs[id]; found {
-		return *jr, nil
+	if jr, found := records.jobRequisition(id); found {
+		return jr, nil
 	}
 
-	return JobRequisition{}, fmt.Errorf("Job Requisition with ID '%v' not found", id)
+	return JobRequisition{}, notFound("Job Requisition with ID '%v' not found", id)
 }
 
-//In Memory: Searches for a specific JobRequisition on the hashmap that has posted = true
-//Returns a JobRequisition object and an error in case it was not possible to find the record
-func GetJobRequisitionPosted() []*JobRequisition{
+//In Memory: Searches for the JobRequisition in any of the states provided, Open when none is provided.
+//Returns a list of JobRequisition and an error in case one of the states does not exist
+func GetJobRequisitionPosted(states ...string) ([]*JobRequisition, error) {
+	if len(states) == 0 {
+		states = []string{RequisitionOpen}
+	}
+
+	//States are matched ignoring case, as the other filters of the query string
+	wanted := make(map[string]bool)
+	for _, s := range states {
+		known := false
+		for state := range requisitionTransitions {
+			if strings.EqualFold(s, state) {
+				wanted[state] = true
+				known = true
+			}
+		}
+		if !known {
+			var v validate.Validator
+			v.Add("State", "must be one of Draft, PendingApproval, Open, OnHold, Closed, Filled")
+			return nil, invalid("State filter is not valid", &v)
+		}
+	}
+
 	//use main method for retrieving job requisitions for updating country values.
 	reqs := GetJobRequisitions()
 
-	var postedReqs []*JobRequisition
+	postedReqs := make([]*JobRequisition, 0)
 	for _, jr := range reqs {
-		if jr.PostingStatus {
+		if wanted[jr.State] {
 			postedReqs = append(postedReqs, jr)
 		}
 	}
-	return postedReqs
+	return postedReqs, nil
 }
 
 //In DB: Creates a new JobRequisition record to the collection and updates the JobRequisition in memory.
 //Returns a JobRequisition object and an error in case it was not possible to create the record
-func AddJobRequisition(jr JobRequisition) (JobRequisition, error) {
+func AddJobRequisition(ctx context.Context, jr JobRequisition) (JobRequisition, error) {
 	//Validation section
-	if jr.ID != 0 {
-		return JobRequisition{}, fmt.Errorf("Job Requisition must not contain ID upon creation")
+	//Clients not aware of the lifecycle post requisitions with PostingStatus
+	if jr.State == "" && jr.PostingStatus {
+		jr.State = RequisitionOpen
+	} else if jr.State == "" {
+		jr.State = RequisitionDraft
+	}
+
+	var v validate.Validator
+	v.Check(jr.ID == 0, "ID", "Job Requisition must not contain ID upon creation")
+	v.Check(jr.State == RequisitionDraft || jr.State == RequisitionOpen, "State", "must be Draft or Open upon creation")
+	checkJobRequisition(&v, jr)
+	if err := invalid("Job Requisition is not valid", &v); err != nil {
+		return JobRequisition{}, err
 	}
-	if jr.Title == "" || jr.JobDescription == "" {
-		return JobRequisition{}, fmt.Errorf("Mandatory fields should be populated upon creating Job Requisition")
+
+	//Requisitions of countries with an approval chain are only posted once approved
+	if jr.State == RequisitionOpen {
+		country, _ := records.country(jr.JrCountryId)
+		if chain := approvalChainOf(country.Code); len(chain) > 0 {
+			return JobRequisition{}, conflict("Job Requisition cannot be posted before it is approved on %v", chainNames(chain))
+		}
 	}
+	jr.Approvals = nil
+	jr.RequiredTags, jr.PreferredTags = validateRequisitionTags(ctx, jr.RequiredTags, jr.PreferredTags)
 
 	//Add New JobRequisition
-	jr.ID = nextJobID
-
-	client, err := db.OpenConnectionToMongo()
+	id, err := nextID(ctx, jobRequisitionSequence)
 	if err != nil {
-		return JobRequisition{}, fmt.Errorf("Could not establish conneciton to Database")
+		return JobRequisition{}, err
 	}
-
-	coll := client.Database(db.GetDatabaseName()).Collection("Requisitions")
-	doc := bson.D{
-		{"ID", jr.ID},
-		{"Title", jr.Title},
-		{"JobDescription", jr.JobDescription},
-		{"PostingStatus", jr.PostingStatus},
-		{"JrCountryId", jr.JrCountryId}}
-
-	if _, err = coll.InsertOne(context.TODO(), doc); err != nil {
-		return JobRequisition{}, fmt.Errorf("Could not insert Job Requisition provided")
+	jr.ID = id
+	if jr.Headcount == 0 {
+		jr.Headcount = 1
+	}
+	//Only accepted offers fill the requisition
+	jr.FilledCount = 0
+	jr.Version = 0
+
+	now := time.Now().UTC()
+	jr.StateHistory = []StageChange{{To: jr.State, At: now}}
+	if jr.State == RequisitionOpen && jr.OpeningDate.IsZero() {
+		jr.OpeningDate = now
 	}
+	jr.PostingStatus = jr.State == RequisitionOpen
 
-	defer db.CloseConnectionToMongo(client)
+	if err := stores.JobRequisitions.Insert(ctx, jr); err != nil {
+		return JobRequisition{}, err
+	}
 
-	updateJobRequisitionInMemory()
+	records.putJobRequisition(jr)
 	return GetJobRequisitionByID(jr.ID)
 }
 
 //In DB: Updates a JobRequisition record on the collection and updates the JobRequisition in memory.
 //Returns a JobRequisition object and an error in case it was not possible to update the record
-func UpdateJobRequisition(jr JobRequisition) (JobRequisition, error) {
-	if jr.Title == "" || jr.JobDescription == "" {
-		return JobRequisition{}, fmt.Errorf("Mandatory fields should be populated upon creating Job Requisition")
+func UpdateJobRequisition(ctx context.Context, jr JobRequisition) (JobRequisition, error) {
+	var v validate.Validator
+	checkJobRequisition(&v, jr)
+	if err := invalid("Job Requisition is not valid", &v); err != nil {
+		return JobRequisition{}, err
 	}
 
 	//Update Job Requisition
-	if _, found := jobReqs[jr.ID]; found {
-
-		client, err := db.OpenConnectionToMongo()
-		if err != nil {
-			return JobRequisition{}, fmt.Errorf("Could not establish connection to the Database")
+	if old, found := records.jobRequisition(jr.ID); found {
+		//Only accepted offers fill the requisition and the state only changes through TransitionJobRequisition
+		jr.FilledCount = old.FilledCount
+		jr.State = old.State
+		jr.StateHistory = old.StateHistory
+		jr.Approvals = old.Approvals
+		jr.PostingStatus = old.PostingStatus
+		jr.Version = old.Version + 1
+		if jr.Headcount == 0 {
+			jr.Headcount = old.Headcount
 		}
 
-		coll := client.Database(db.GetDatabaseName()).Collection("Requisitions")
-		filter := bson.D{{"ID", jr.ID}}
-
-		update := bson.D{{"$set", bson.D{
-			{"Title", jr.Title},
-			{"JobDescription", jr.JobDescription},
-			{"PostingStatus", jr.PostingStatus},
-			{"JrCountryId", jr.JrCountryId}}}}
-
-		if _, err := coll.UpdateOne(context.TODO(), filter, update); err != nil {
-			return JobRequisition{}, fmt.Errorf("Could not update  Requisition provided")
+		var v validate.Validator
+		v.Check(jr.Headcount >= jr.FilledCount, "Headcount", "must not be lower than the %v positions already filled", jr.FilledCount)
+		if err := invalid("Job Requisition is not valid", &v); err != nil {
+			return JobRequisition{}, err
 		}
+		jr.RequiredTags, jr.PreferredTags = validateRequisitionTags(ctx, jr.RequiredTags, jr.PreferredTags)
 
-		defer db.CloseConnectionToMongo(client)
+		if err := stores.JobRequisitions.Update(ctx, jr); err != nil {
+			return JobRequisition{}, err
+		}
 
-		updateJobRequisitionInMemory()
+		records.putJobRequisition(jr)
 		return GetJobRequisitionByID(jr.ID)
 	}
 
 	//Return Job Req not found
-	return JobRequisition{}, fmt.Errorf("Job Requisition with ID '%v' not found", jr.ID)
+	return JobRequisition{}, notFound("Job Requisition with ID '%v' not found", jr.ID)
 }
 
 //In DB: Removes a JobRequisition record from the collection and updates the JobRequisition in memory.
 //Returns error if failed to complete the deletion on the DB
-func DeleteJobRequisition(id int) error {
-	if _, found := jobReqs[id]; found {
-		DeleteApplicationFromJobReq(id)
-
-		client, err := db.OpenConnectionToMongo()
-		if err != nil {
-			return fmt.Errorf("Could not connect to Database")
+func DeleteJobRequisition(ctx context.Context, id int) error {
+	if _, found := records.jobRequisition(id); found {
+		if err := DeleteApplicationFromJobReq(ctx, id); err != nil {
+			return err
 		}
 
-		coll := client.Database(db.GetDatabaseName()).Collection("Requisitions")
-		filter := bson.D{{"ID", id}}
-
-		if _, err = coll.DeleteOne(context.TODO(), filter); err != nil {
-			return fmt.Errorf("Could not delete requisition wiht ID provided")
+		if err := stores.JobRequisitions.Delete(ctx, id); err != nil {
+			return err
 		}
 
-		defer db.CloseConnectionToMongo(client)
-
-		updateJobRequisitionInMemory()
+		records.removeJobRequisition(id)
 		return nil
 	}
-	return fmt.Errorf("Job Requisition with ID '%v' not found", id)
+	return notFound("Job Requisition with ID '%v' not found", id)
 }
 
 //In Memory: Verify if the JobRequisition id provided is referring to a posted job req.
@@ -166,80 +247,223 @@ func DeleteJobRequisition(id int) error {
 func IsJobReqPosted(id int) (bool, error) {
 	jr, err := GetJobRequisitionByID(id)
 	if err != nil {
-		return false, fmt.Errorf("Counld not find Job Requisition '%v'", id)
+		return false, notFound("Could not find Job Requisition '%v'", id)
 	}
 
-	return jr.PostingStatus, nil
+	return jr.State == RequisitionOpen, nil
 }
 
-//In Memory: Searches for JobRequisition with Country.
-//Return a list of JobRequisition
-func GetRequisitionsWithCountry(c int) []JobRequisition {
-	ret := make([]JobRequisition, 0)
+//In DB: Moves the JobRequisition to the state provided, recording who moved it.
+//Returns the updated JobRequisition, or an error if the state is unknown or cannot be reached from the current state.
+func TransitionJobRequisition(ctx context.Context, id int, to string, by string, comment string) (JobRequisition, error) {
+	var v validate.Validator
+	_, known := requisitionTransitions[to]
+	v.Check(known, "State", "must be one of Draft, PendingApproval, Open, OnHold, Closed, Filled")
+	v.Check(by != "", "By", "should be populated")
+	if err := invalid("Transition is not valid", &v); err != nil {
+		return JobRequisition{}, err
+	}
 
-	for _, v := range GetJobRequisitions() {
-		if v.JobReqCountry.ID == c {
-			ret = append(ret, *v)
-		}
+	jr, found := records.jobRequisition(id)
+	if !found {
+		return JobRequisition{}, notFound("Job Requisition with ID '%v' not found", id)
 	}
 
-	return ret
+	if err := moveRequisition(ctx, &jr, to, by, comment, time.Now().UTC()); err != nil {
+		return JobRequisition{}, err
+	}
+	return GetJobRequisitionByID(id)
 }
 
-//Updates the hashmap containing all the JobRequisition to work with them in memory.
-//Return the next ID to be added into the Database
-func updateJobRequisitionInMemory() int {
-	client, err := db.OpenConnectionToMongo()
-	if err != nil {
-		return -1
+//Checks the JobRequisition may move to the state provided before recording the move.
+//Returns a ConflictError when it may not.
+func moveRequisition(ctx context.Context, jr *JobRequisition, to string, by string, comment string, at time.Time) error {
+	allowed := false
+	for _, s := range requisitionTransitions[jr.State] {
+		allowed = allowed || s == to
+	}
+	if !allowed {
+		return conflict("Job Requisition '%v' cannot move from %v to %v", jr.ID, jr.State, to)
+	}
+	if to == RequisitionOpen && jr.FilledCount >= jr.Headcount {
+		return conflict("Job Requisition '%v' has its headcount of %v already filled", jr.ID, jr.Headcount)
+	}
+	if pending := pendingApprovals(*jr); to == RequisitionOpen && len(pending) > 0 {
+		return conflict("Job Requisition '%v' cannot be posted before it is approved on %v", jr.ID, strings.Join(pending, ", "))
 	}
 
-	filter := bson.D{}
-	projection := bson.D{
-		{"ID", 1},
-		{"Title", 1},
-		{"JobDescription", 1},
-		{"PostingStatus", 1},
-		{"JrCountryId", 1}}
-	opts := options.Find().SetProjection(projection)
+	return setRequisitionState(ctx, jr, to, by, comment, at)
+}
 
-	coll := client.Database(db.GetDatabaseName()).Collection("Requisitions")
-	cursor, err := coll.Find(context.TODO(), filter, opts)
+//Records the new state of the JobRequisition on the stores and in memory.
+//Opening and closing the requisition set the dates not planned beforehand.
+func setRequisitionState(ctx context.Context, jr *JobRequisition, state string, by string, comment string, at time.Time) error {
+	change := StageChange{From: jr.State, To: state, By: by, At: at, Comment: comment}
+	updated := *jr
+	updated.State = state
+	updated.StateHistory = append(append([]StageChange(nil), jr.StateHistory...), change)
+	updated.PostingStatus = state == RequisitionOpen
+	updated.Version++
+
+	switch state {
+	case RequisitionOpen:
+		if updated.OpeningDate.IsZero() {
+			updated.OpeningDate = at
+		}
+		//A requisition opened again is no longer closed
+		if !updated.ClosingDate.After(at) {
+			updated.ClosingDate = time.Time{}
+		}
+	case RequisitionClosed, RequisitionFilled:
+		if updated.ClosingDate.IsZero() || updated.ClosingDate.After(at) {
+			updated.ClosingDate = at
+		}
+	}
 
-	var results []bson.D
-	if err = cursor.All(context.TODO(), &results); err != nil {
-		panic(err)
+	if err := stores.JobRequisitions.Update(ctx, updated); err != nil {
+		return err
 	}
 
-	defer db.CloseConnectionToMongo(client)
+	records.putJobRequisition(updated)
+	*jr = updated
+	return nil
+}
 
-	biggestId := 1
-	jobReqs = make(map[int]*JobRequisition)
-	for _, v := range results {
-		jr := bsonToJobRequisition(v)
+//In DB: Opens the requisitions whose PostAt has passed and puts on hold the open ones whose UnpostAt has passed.
+//Each move is recorded on the StateHistory as made by the scheduler, and the time handled is cleared,
+//so running it again has no effect and times missed while the server was down are handled on the next run.
+//Every replica runs it: the requisitions another replica moved since they were read are rejected by the store
+//and left for the next run, so each move is recorded once.
+//Returns the number of requisitions moved.
+func PublishScheduledRequisitions(ctx context.Context, now time.Time) (int, error) {
+	now = now.UTC()
+	moved := 0
+	for _, jr := range records.allJobRequisitions() {
+		jr := jr
+		changed := false
+
+		if !jr.PostAt.IsZero() && !now.Before(jr.PostAt) {
+			switch jr.State {
+			case RequisitionDraft:
+				//Posted once submitted for approval
+			case RequisitionPendingApproval, RequisitionOnHold:
+				postAt := jr.PostAt
+				jr.PostAt = time.Time{}
+				err := moveRequisition(ctx, &jr, RequisitionOpen, "scheduler", "Posted as scheduled for "+postAt.Format(time.RFC3339), now)
+				var c *ConflictError
+				if errors.As(err, &c) {
+					//Not ready to be posted yet, tried again on the next run
+					jr.PostAt = postAt
+					break
+				}
+				if err != nil {
+					return moved, err
+				}
+				moved++
+			default:
+				//Already posted or no longer hiring
+				jr.PostAt = time.Time{}
+				changed = true
+			}
+		}
+
+		if !jr.UnpostAt.IsZero() && !now.Before(jr.UnpostAt) {
+			unpostAt := jr.UnpostAt
+			jr.UnpostAt = time.Time{}
+			if jr.State == RequisitionOpen {
+				err := moveRequisition(ctx, &jr, RequisitionOnHold, "scheduler", "Unposted as scheduled for "+unpostAt.Format(time.RFC3339), now)
+				var c *ConflictError
+				if errors.As(err, &c) {
+					continue
+				}
+				if err != nil {
+					return moved, err
+				}
+				moved++
+			} else {
+				//The requisition was not posted on its window, so it is not posted after it either
+				jr.PostAt = time.Time{}
+				changed = true
+			}
+		}
 
-		jr.JobReqCountry, err = GetCountryByID(jr.JrCountryId)
+		if changed {
+			jr.Version++
+			err := stores.JobRequisitions.Update(ctx, jr)
+			var c *ConflictError
+			if errors.As(err, &c) {
+				continue
+			}
+			if err != nil {
+				return moved, err
+			}
+			records.putJobRequisition(jr)
+		}
+	}
+	return moved, nil
+}
 
-		//Returns all applications of this JobRequisition
-		jr.Applicants = GetApplicationsOfJobReq(jr.ID)
+//ScheduleRequisitions runs PublishScheduledRequisitions when started and then every interval until ctx is cancelled.
+func ScheduleRequisitions(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	now := time.Now()
+	for {
+		if n, err := PublishScheduledRequisitions(ctx, now); err != nil {
+			log.Printf("Could not publish scheduled requisitions: %v", err)
+		} else if n > 0 {
+			log.Printf("Moved %v scheduled requisitions", n)
+		}
 
-		jobReqs[jr.ID] = &jr
-		if jr.ID > biggestId {
-			biggestId = jr.ID
+		select {
+		case <-ctx.Done():
+			return
+		case now = <-ticker.C:
 		}
 	}
+}
 
-	return biggestId+1
+//Collects the violations of the rules shared by the creation and the update of a JobRequisition.
+func checkJobRequisition(v *validate.Validator, jr JobRequisition) {
+	v.Struct(jr)
+	v.Check(jr.OpeningDate.IsZero() || jr.ClosingDate.IsZero() || jr.ClosingDate.After(jr.OpeningDate), "ClosingDate", "must be after OpeningDate")
+	v.Check(jr.PostAt.IsZero() || jr.UnpostAt.IsZero() || jr.UnpostAt.After(jr.PostAt), "UnpostAt", "must be after PostAt")
 }
 
-//Receives a bson object to execute the conversion.
-//Returns a JobRequisition object.
-func bsonToJobRequisition(v bson.D) JobRequisition {
-	bsonBytes, _ := bson.Marshal(v)
+//Resolves the tags of a JobRequisition as ValidateTags does for a Candidate.
+//A tag both required and preferred is only kept as required.
+func validateRequisitionTags(ctx context.Context, required []Tag, preferred []Tag) ([]Tag, []Tag) {
+	if required != nil {
+		required = ValidateTags(ctx, required)
+	}
+	if preferred == nil {
+		return required, nil
+	}
 
-	var jr JobRequisition
-	//deconvert the byarray into a struct object
-	bson.Unmarshal(bsonBytes, &jr)
+	isRequired := make(map[int]bool)
+	for _, t := range required {
+		isRequired[t.ID] = true
+	}
+	kept := make([]Tag, 0, len(preferred))
+	for _, t := range ValidateTags(ctx, preferred) {
+		if !isRequired[t.ID] {
+			kept = append(kept, t)
+		}
+	}
+	return required, kept
+}
 
-	return jr
-}
\ No newline at end of file
+//In Memory: Searches for JobRequisition with Country.
+//Return a list of JobRequisition
+func GetRequisitionsWithCountry(c int) []JobRequisition {
+	ret := make([]JobRequisition, 0)
+
+	for _, v := range GetJobRequisitions() {
+		if v.JobReqCountry.ID == c {
+			ret = append(ret, *v)
+		}
+	}
+
+	return ret
+}
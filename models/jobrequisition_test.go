@@ -0,0 +1,85 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+//Creates a JobRequisition in the state provided, Draft or Open.
+func addTestRequisition(t *testing.T, ctx context.Context, title string, state string, headcount int) JobRequisition {
+	t.Helper()
+	jr, err := AddJobRequisition(ctx, JobRequisition{
+		Title:          title,
+		JobDescription: "Builds the services of the product",
+		State:          state,
+		HiringManager:  "manager@corp.com",
+		Recruiter:      "recruiter@corp.com",
+		Headcount:      headcount,
+	})
+	if err != nil {
+		t.Fatalf("AddJobRequisition(%q) error = %v", title, err)
+	}
+	return jr
+}
+
+//The scheduler running on two replicas moves each requisition once, the replica reading it before the move leaves it as it is.
+func TestPublishScheduledRequisitionsStale(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []struct {
+		name     string
+		state    string
+		schedule func(jr *JobRequisition)
+		want     string
+		comment  string
+	}{
+		{"post", RequisitionOnHold, func(jr *JobRequisition) { jr.PostAt = now.Add(-time.Minute) }, RequisitionOpen, "Posted as scheduled"},
+		{"unpost", RequisitionOpen, func(jr *JobRequisition) { jr.UnpostAt = now.Add(-time.Minute) }, RequisitionOnHold, "Unposted as scheduled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := initMemoryStores(t)
+			jr := addTestRequisition(t, ctx, "Backend Engineer", RequisitionOpen, 1)
+			if tt.state != RequisitionOpen {
+				if _, err := TransitionJobRequisition(ctx, jr.ID, tt.state, "recruiter@corp.com", ""); err != nil {
+					t.Fatal(err)
+				}
+			}
+			jr, _ = records.jobRequisition(jr.ID)
+			tt.schedule(&jr)
+			jr.Version++
+			if err := stores.JobRequisitions.Update(ctx, jr); err != nil {
+				t.Fatal(err)
+			}
+			records.putJobRequisition(jr)
+
+			if moved, err := PublishScheduledRequisitions(ctx, now); err != nil || moved != 1 {
+				t.Fatalf("PublishScheduledRequisitions() = %v, %v, want 1 requisition moved", moved, err)
+			}
+
+			//The other replica still has the requisition as it was before the move
+			records.putJobRequisition(jr)
+			if moved, err := PublishScheduledRequisitions(ctx, now); err != nil || moved != 0 {
+				t.Fatalf("PublishScheduledRequisitions() on the stale copy = %v, %v, want no requisition moved", moved, err)
+			}
+
+			stored, err := stores.JobRequisitions.FindAll(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored[0].State != tt.want {
+				t.Errorf("State = %v, want %v", stored[0].State, tt.want)
+			}
+			moves := 0
+			for _, ch := range stored[0].StateHistory {
+				if strings.HasPrefix(ch.Comment, tt.comment) {
+					moves++
+				}
+			}
+			if moves != 1 {
+				t.Errorf("StateHistory = %+v, want the move recorded once", stored[0].StateHistory)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+)
+
+//Points of each criterion a Candidate is scored by against a JobRequisition, adding up to 100.
+//A criterion the requisition asks nothing of is scored in full, so every requisition is scored on the same scale.
+const (
+	requiredTagsPoints  = 50
+	preferredTagsPoints = 20
+	experiencePoints    = 20
+	countryPoints       = 10
+)
+
+//Criteria of the match of a Candidate to a JobRequisition.
+const (
+	MatchRequiredTags  = "RequiredTags"
+	MatchPreferredTags = "PreferredTags"
+	MatchExperience    = "Experience"
+	MatchCountry       = "Country"
+)
+
+//CandidateMatch is a Candidate scored against a JobRequisition, with the explanation of the score.
+type CandidateMatch struct {
+	Candidate
+	//From 0 to 100, the sum of the points of the Explanation.
+	Score float64
+	//True when the Candidate already applied to the JobRequisition.
+	Applied     bool
+	Explanation []MatchCriterion
+}
+
+//MatchCriterion explains the points scored by a Candidate on one criterion.
+type MatchCriterion struct {
+	Criterion string
+	Points    float64
+	MaxPoints float64
+	Detail    string
+	//Labels of the Tags asked by the JobRequisition the Candidate has and has not.
+	//A Tag is had through any Tag under it, written as "Backend (Go)".
+	Matched []string `json:",omitempty"`
+	Missing []string `json:",omitempty"`
+}
+
+//In Memory: Scores every Candidate against the required and preferred Tags, the years of experience and the country of the JobRequisition.
+//The years of experience of a Candidate are the TimeOfExperience of its application to the requisition or, when it did not apply, the highest of its applications.
+//Returns the Candidates scoring any point, the best matches first, or an error in case the JobRequisition does not exist
+func GetCandidateMatches(jobReqID int) ([]CandidateMatch, error) {
+	jr, err := GetJobRequisitionByID(jobReqID)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]CandidateMatch, 0)
+	for _, c := range records.allCandidates() {
+		m := matchCandidate(jr, c)
+		if m.Score > 0 {
+			ret = append(ret, m)
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Score > ret[j].Score
+	})
+	return ret, nil
+}
+
+//Scores the Candidate against the JobRequisition.
+func matchCandidate(jr JobRequisition, c Candidate) CandidateMatch {
+	m := CandidateMatch{Candidate: c}
+
+	has := records.satisfiedTags(c.Tags)
+	m.Explanation = append(m.Explanation,
+		matchTags(MatchRequiredTags, requiredTagsPoints, jr.RequiredTags, has),
+		matchTags(MatchPreferredTags, preferredTagsPoints, jr.PreferredTags, has))
+
+	years, known := 0, false
+	for _, a := range c.JobsApplied {
+		if a.JobRequisitionID == jr.ID {
+			m.Applied = true
+			years, known = a.TimeOfExperience, true
+			break
+		}
+		if !known || a.TimeOfExperience > years {
+			years, known = a.TimeOfExperience, true
+		}
+	}
+	m.Explanation = append(m.Explanation, matchExperience(jr.YearsOfExperience, years, known))
+
+	country := MatchCriterion{Criterion: MatchCountry, MaxPoints: countryPoints}
+	switch {
+	case jr.JrCountryId == 0:
+		country.Points = countryPoints
+		country.Detail = "No country asked"
+	case c.CanCountryId == jr.JrCountryId:
+		country.Points = countryPoints
+		country.Detail = fmt.Sprintf("Lives in %v, the country of the requisition", jr.JobReqCountry.Name)
+	default:
+		country.Detail = fmt.Sprintf("Does not live in %v", jr.JobReqCountry.Name)
+	}
+	m.Explanation = append(m.Explanation, country)
+
+	for _, e := range m.Explanation {
+		m.Score += e.Points
+	}
+	return m
+}
+
+//Scores the Tags asked by the JobRequisition the Candidate has, each one worth the same share of the points.
+func matchTags(criterion string, points float64, asked []Tag, has map[int]Tag) MatchCriterion {
+	ret := MatchCriterion{Criterion: criterion, MaxPoints: points}
+	if len(asked) == 0 {
+		ret.Points = points
+		ret.Detail = "No tags asked"
+		return ret
+	}
+
+	for _, t := range asked {
+		carried, found := has[t.ID]
+		switch {
+		case !found:
+			ret.Missing = append(ret.Missing, t.Label)
+		case carried.ID != t.ID:
+			ret.Matched = append(ret.Matched, fmt.Sprintf("%v (%v)", t.Label, carried.Label))
+		default:
+			ret.Matched = append(ret.Matched, t.Label)
+		}
+	}
+	ret.Points = points * float64(len(ret.Matched)) / float64(len(asked))
+	ret.Detail = fmt.Sprintf("Has %v of the %v tags asked", len(ret.Matched), len(asked))
+	return ret
+}
+
+//Scores the years of experience of the Candidate, in proportion to the years asked up to the full points.
+func matchExperience(asked int, years int, known bool) MatchCriterion {
+	ret := MatchCriterion{Criterion: MatchExperience, MaxPoints: experiencePoints}
+	switch {
+	case asked == 0:
+		ret.Points = experiencePoints
+		ret.Detail = "No experience asked"
+	case !known:
+		ret.Detail = fmt.Sprintf("No application telling the years of experience, %v asked", asked)
+	case years >= asked:
+		ret.Points = experiencePoints
+		ret.Detail = fmt.Sprintf("%v years of experience, %v asked", years, asked)
+	default:
+		ret.Points = experiencePoints * float64(years) / float64(asked)
+		ret.Detail = fmt.Sprintf("%v years of experience, %v asked", years, asked)
+	}
+	return ret
+}
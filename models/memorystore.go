@@ -0,0 +1,167 @@
+package models
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+//NewMemoryStores returns stores that keep every record in the process memory.
+//Nothing is persisted, it is meant to be used on tests and local demos.
+func NewMemoryStores() Stores {
+	candidates := newMemoryStore("Candidate", func(c Candidate) int { return c.ID })
+	candidates.stored = storedCandidate
+
+	jobReqs := newMemoryStore("Job Requisition", func(jr JobRequisition) int { return jr.ID })
+	jobReqs.stored = storedJobRequisition
+	jobReqs.version = func(jr JobRequisition) int { return jr.Version }
+
+	offers := newMemoryStore("Offer", func(o Offer) int { return o.ID })
+	offers.version = func(o Offer) int { return o.Version }
+
+	applications := newMemoryStore("Application", func(a Application) int { return a.ID })
+	applications.check = func(records map[int]Application, a Application) error {
+		for id, other := range records {
+			if id != a.ID && other.CandidateProfileID == a.CandidateProfileID && other.JobRequisitionID == a.JobRequisitionID {
+				return conflict("Candidate already applied to the Job Requisition")
+			}
+		}
+		return nil
+	}
+
+	return Stores{
+		Candidates:      candidates,
+		Countries:       newMemoryStore("Country", func(c Country) int { return c.ID }),
+		JobRequisitions: jobReqs,
+		Applications:    applications,
+		Tags:            newMemoryStore("Tag", func(t Tag) int { return t.ID }),
+		Interviews:      newMemoryStore("Interview", func(i Interview) int { return i.ID }),
+		Offers:          offers,
+		Merges:          newMemoryStore("Candidate merge", func(m CandidateMerge) int { return m.ID }),
+		Attachments:     memoryAttachmentStore{newMemoryStore("Attachment", func(a Attachment) int { return a.ID })},
+		Sequences:       &memorySequenceStore{values: make(map[string]int)},
+	}
+}
+
+//memoryStore keeps the records of one entity in a map keyed by their ID.
+//It implements the store of every entity, FindAll returning the records in the order of their IDs.
+type memoryStore[T any] struct {
+	mu      sync.Mutex
+	records map[int]T
+	//Name of the entity on the errors returned.
+	name string
+	id   func(T) int
+	//Optional, returns the record with only the fields persisted.
+	stored func(T) T
+	//Optional, returns the Version of the records written over the previous one only, see staleRecord.
+	version func(T) int
+	//Optional, returns the error of a record clashing with the others, such as a unique index would.
+	//Called holding the lock.
+	check func(records map[int]T, v T) error
+}
+
+func newMemoryStore[T any](name string, id func(T) int) *memoryStore[T] {
+	return &memoryStore[T]{records: make(map[int]T), name: name, id: id}
+}
+
+func (s *memoryStore[T]) FindAll(ctx context.Context) ([]T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ret := make([]T, 0, len(s.records))
+	ids := make([]int, 0, len(s.records))
+	for id := range s.records {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		ret = append(ret, s.records[id])
+	}
+	return ret, nil
+}
+
+func (s *memoryStore[T]) Insert(ctx context.Context, v T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, found := s.records[s.id(v)]; found {
+		return conflict("Could not insert %v provided", s.name)
+	}
+	return s.put(v)
+}
+
+func (s *memoryStore[T]) Update(ctx context.Context, v T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, found := s.records[s.id(v)]
+	if !found {
+		return notFound("Could not update %v provided", s.name)
+	}
+	if s.version != nil && s.version(old) != s.version(v)-1 {
+		return staleRecord(s.name, s.id(v))
+	}
+	return s.put(v)
+}
+
+//Must be called holding the lock.
+func (s *memoryStore[T]) put(v T) error {
+	if s.check != nil {
+		if err := s.check(s.records, v); err != nil {
+			return err
+		}
+	}
+	if s.stored != nil {
+		v = s.stored(v)
+	}
+	s.records[s.id(v)] = v
+	return nil
+}
+
+func (s *memoryStore[T]) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, id)
+	return nil
+}
+
+type memoryAttachmentStore struct {
+	*memoryStore[Attachment]
+}
+
+func (s memoryAttachmentStore) HasChecksum(ctx context.Context, checksum string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range s.records {
+		if a.Checksum == checksum {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type memorySequenceStore struct {
+	mu     sync.Mutex
+	values map[string]int
+}
+
+func (s *memorySequenceStore) Seed(ctx context.Context, name string, min int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.values[name] < min {
+		s.values[name] = min
+	}
+	return nil
+}
+
+func (s *memorySequenceStore) Next(ctx context.Context, name string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values[name]++
+	return s.values[name], nil
+}
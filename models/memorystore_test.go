@@ -0,0 +1,60 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+//Starts the models package on empty memory stores, as a fresh service would.
+func initMemoryStores(t *testing.T) context.Context {
+	t.Helper()
+	ctx := context.Background()
+	records = newCache()
+	SetApprovalChains(nil, nil)
+	if err := Init(ctx, NewMemoryStores()); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	return ctx
+}
+
+//Fails the test unless err is an error of the type E, such as *ConflictError.
+func wantError[E error](t *testing.T, err error) {
+	t.Helper()
+	var target E
+	if !errors.As(err, &target) {
+		t.Fatalf("error = %v (%T), want %T", err, err, target)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStores().Candidates
+
+	for _, id := range []int{3, 1, 2} {
+		if err := s.Insert(ctx, Candidate{ID: id, FirstName: "Jane", JobsApplied: []Application{{ID: 9}}}); err != nil {
+			t.Fatalf("Insert(%v) error = %v", id, err)
+		}
+	}
+	wantError[*ConflictError](t, s.Insert(ctx, Candidate{ID: 1}))
+	wantError[*NotFoundError](t, s.Update(ctx, Candidate{ID: 4}))
+
+	if err := s.Update(ctx, Candidate{ID: 2, FirstName: "John"}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := s.Delete(ctx, 3); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	all, err := s.FindAll(ctx)
+	if err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+	if len(all) != 2 || all[0].ID != 1 || all[1].ID != 2 || all[1].FirstName != "John" {
+		t.Fatalf("FindAll() = %+v, want Candidates 1 and 2 in order, 2 updated", all)
+	}
+	//Only the fields persisted are kept, as on the other stores
+	if all[0].JobsApplied != nil {
+		t.Errorf("JobsApplied = %v, want nil", all[0].JobsApplied)
+	}
+}
@@ -0,0 +1,217 @@
+package models
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"webservice/validate"
+)
+
+//CandidateMerge is the audit record of two Candidate profiles merged into one.
+type CandidateMerge struct {
+	ID int
+	//Candidate kept, which received the applications and tags of the source.
+	TargetID int
+	//Candidate removed by the merge, with its fields as they were before the removal.
+	SourceID        int
+	SourceFirstName string
+	SourceLastName  string
+	SourceEmail     string
+	SourceAddress   string
+	//Applications moved from the source to the target.
+	MovedApplications []int
+	//Applications of the source to requisitions the target had already applied to, removed with their interviews and offers.
+	RemovedApplications []int
+	//Labels of the tags of the source the target did not have.
+	AddedTags []string
+	//Pending from the start of the merge until the source is removed. Empty on the merges recorded before the status existed, all completed.
+	Status string
+	By     string
+	At     time.Time
+}
+
+//Statuses of a CandidateMerge.
+const (
+	MergePending   = "Pending"
+	MergeCompleted = "Completed"
+)
+
+//In Memory: Returns the audit records of every merge, in the order they happened.
+func GetCandidateMerges() []CandidateMerge {
+	return records.allMerges()
+}
+
+//In DB: Merges the source Candidate into the target one and removes the source.
+//The applications and attachments of the source are moved to the target, the tags are united and
+//the fields the target left empty are taken from the source.
+//The audit record of the merge is written first, Pending, with what is about to be moved. A failure partway
+//leaves it Pending with the source not removed, and what was already moved on the target: merging the same
+//Candidates again resumes the merge, adding what is left to the same record, and completes it.
+//Returns the target Candidate and an error in case either Candidate does not exist.
+func MergeCandidates(ctx context.Context, targetID int, sourceID int, by string) (Candidate, error) {
+	var v validate.Validator
+	v.Check(targetID != 0, "TargetID", "should be populated")
+	v.Check(sourceID != 0, "SourceID", "should be populated")
+	v.Check(targetID != sourceID, "SourceID", "must not be the TargetID")
+	v.Check(by != "", "By", "should be populated")
+	if err := invalid("Merge is not valid", &v); err != nil {
+		return Candidate{}, err
+	}
+
+	target, err := GetCandidateByID(targetID)
+	if err != nil {
+		return Candidate{}, err
+	}
+	m, resumed := pendingMerge(targetID, sourceID)
+	source, err := GetCandidateByID(sourceID)
+	if err != nil && resumed {
+		//The merge failed after removing the source, only its record is left to complete
+		if err := completeMerge(ctx, m); err != nil {
+			return Candidate{}, err
+		}
+		return GetCandidateByID(targetID)
+	}
+	if err != nil {
+		return Candidate{}, err
+	}
+
+	if !resumed {
+		m = CandidateMerge{
+			TargetID:        targetID,
+			SourceID:        sourceID,
+			SourceFirstName: source.FirstName,
+			SourceLastName:  source.LastName,
+			SourceEmail:     source.Email,
+			SourceAddress:   source.Address,
+			Status:          MergePending,
+			By:              by,
+			At:              time.Now().UTC(),
+		}
+	}
+
+	//A candidate applies only once to each requisition, the application of the target is kept
+	var moved, removed []Application
+	applied := make(map[int]bool)
+	for _, a := range target.JobsApplied {
+		applied[a.JobRequisitionID] = true
+	}
+	for _, a := range source.JobsApplied {
+		if applied[a.JobRequisitionID] {
+			removed = append(removed, a)
+			m.RemovedApplications = append(m.RemovedApplications, a.ID)
+			continue
+		}
+		applied[a.JobRequisitionID] = true
+		moved = append(moved, a)
+		m.MovedApplications = append(m.MovedApplications, a.ID)
+	}
+
+	//Tags are the same when they have the same ID, their labels may have been renamed since they were added
+	has := make(map[string]bool)
+	for _, t := range target.Tags {
+		has[mergedTagKey(t)] = true
+	}
+	for _, t := range source.Tags {
+		if !has[mergedTagKey(t)] {
+			target.Tags = append(target.Tags, t)
+			has[mergedTagKey(t)] = true
+			m.AddedTags = append(m.AddedTags, t.Label)
+		}
+	}
+
+	if err := saveMerge(ctx, &m, resumed); err != nil {
+		return Candidate{}, err
+	}
+
+	for _, a := range removed {
+		if err := DeleteApplication(ctx, a.ID); err != nil {
+			return Candidate{}, err
+		}
+	}
+	for _, a := range moved {
+		a.CandidateProfileID = targetID
+		if err := stores.Applications.Update(ctx, a); err != nil {
+			return Candidate{}, err
+		}
+		records.putApplication(a)
+	}
+
+	if err := moveAttachments(ctx, targetID, sourceID); err != nil {
+		return Candidate{}, err
+	}
+
+	if target.Address == "" {
+		target.Address = source.Address
+	}
+	if target.CanCountryId == 0 {
+		target.CanCountryId = source.CanCountryId
+	}
+
+	if err := stores.Candidates.Update(ctx, target); err != nil {
+		return Candidate{}, err
+	}
+	records.putCandidate(target)
+
+	//The applications were moved, so the source is removed without them
+	if err := stores.Candidates.Delete(ctx, sourceID); err != nil {
+		return Candidate{}, err
+	}
+	records.removeCandidate(sourceID)
+
+	if err := completeMerge(ctx, m); err != nil {
+		return Candidate{}, err
+	}
+	return GetCandidateByID(targetID)
+}
+
+//Returns the Pending merge of the source into the target, left by a merge that failed partway.
+func pendingMerge(targetID int, sourceID int) (CandidateMerge, bool) {
+	for _, m := range records.allMerges() {
+		if m.Status == MergePending && m.TargetID == targetID && m.SourceID == sourceID {
+			return m, true
+		}
+	}
+	return CandidateMerge{}, false
+}
+
+//Returns the key the tags of the merged candidates are matched by: the ID of the Tag,
+//or its label for a Tag that was never saved and has no ID.
+func mergedTagKey(t Tag) string {
+	if t.ID != 0 {
+		return strconv.Itoa(t.ID)
+	}
+	return "label:" + tagKey(t.Label)
+}
+
+//Inserts the Pending record of a new merge, or updates the one of a merge resumed.
+func saveMerge(ctx context.Context, m *CandidateMerge, resumed bool) error {
+	if resumed {
+		if err := stores.Merges.Update(ctx, *m); err != nil {
+			return err
+		}
+		records.putMerge(*m)
+		return nil
+	}
+
+	id, err := nextID(ctx, mergeSequence)
+	if err != nil {
+		return err
+	}
+	m.ID = id
+	if err := stores.Merges.Insert(ctx, *m); err != nil {
+		return err
+	}
+	records.putMerge(*m)
+	return nil
+}
+
+//Marks the merge Completed once the source is removed.
+func completeMerge(ctx context.Context, m CandidateMerge) error {
+	m.Status = MergeCompleted
+	if err := stores.Merges.Update(ctx, m); err != nil {
+		return err
+	}
+	records.putMerge(m)
+	return nil
+}
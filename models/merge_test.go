@@ -0,0 +1,97 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+//CandidateStore failing every update.
+type failingCandidateStore struct {
+	CandidateStore
+}
+
+func (failingCandidateStore) Update(ctx context.Context, c Candidate) error {
+	return errors.New("Storage unavailable")
+}
+
+func TestMergeCandidatesResumed(t *testing.T) {
+	ctx := initMemoryStores(t)
+	backend := addTestRequisition(t, ctx, "Backend Engineer", RequisitionOpen, 1)
+	frontend := addTestRequisition(t, ctx, "Frontend Engineer", RequisitionOpen, 1)
+	target := addTestCandidate(t, ctx, "Jane", "Doe")
+	source := addTestCandidate(t, ctx, "Jane", "Smith")
+	addTestApplication(t, ctx, target.ID, backend.ID)
+	removed := addTestApplication(t, ctx, source.ID, backend.ID)
+	moved := addTestApplication(t, ctx, source.ID, frontend.ID)
+
+	working := stores.Candidates
+	stores.Candidates = failingCandidateStore{working}
+	if _, err := MergeCandidates(ctx, target.ID, source.ID, "recruiter@corp.com"); err == nil {
+		t.Fatal("MergeCandidates() error = nil, want the error of the store")
+	}
+
+	//The record tells what was started, the source is still there
+	merges := GetCandidateMerges()
+	if len(merges) != 1 || merges[0].Status != MergePending {
+		t.Fatalf("GetCandidateMerges() = %+v, want one Pending merge", merges)
+	}
+	if _, err := GetCandidateByID(source.ID); err != nil {
+		t.Fatalf("GetCandidateByID(source) error = %v", err)
+	}
+
+	stores.Candidates = working
+	merged, err := MergeCandidates(ctx, target.ID, source.ID, "recruiter@corp.com")
+	if err != nil {
+		t.Fatalf("MergeCandidates() error = %v", err)
+	}
+	if len(merged.JobsApplied) != 2 {
+		t.Errorf("JobsApplied = %v applications, want 2", len(merged.JobsApplied))
+	}
+	_, err = GetCandidateByID(source.ID)
+	wantError[*NotFoundError](t, err)
+
+	merges = GetCandidateMerges()
+	if len(merges) != 1 {
+		t.Fatalf("GetCandidateMerges() = %v records, want the one resumed", len(merges))
+	}
+	m := merges[0]
+	if m.Status != MergeCompleted || !reflect.DeepEqual(m.MovedApplications, []int{moved.ID}) || !reflect.DeepEqual(m.RemovedApplications, []int{removed.ID}) {
+		t.Errorf("merge = %v moving %v and removing %v, want Completed moving %v and removing %v",
+			m.Status, m.MovedApplications, m.RemovedApplications, []int{moved.ID}, []int{removed.ID})
+	}
+}
+
+//Tags are united by their ID, a Tag written with an older label on one of the candidates is not added twice.
+func TestMergeCandidatesTags(t *testing.T) {
+	ctx := initMemoryStores(t)
+	goTag := addTestTag(t, ctx, "Go", 0)
+	rust := addTestTag(t, ctx, "Rust", 0)
+	target := addTestCandidate(t, ctx, "Jane", "Doe")
+	source := addTestCandidate(t, ctx, "Jane", "Smith")
+
+	target.Tags = []Tag{goTag}
+	source.Tags = []Tag{{ID: goTag.ID, Label: "Golang"}, rust}
+	for _, c := range []Candidate{target, source} {
+		if err := stores.Candidates.Update(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+		records.putCandidate(c)
+	}
+
+	merged, err := MergeCandidates(ctx, target.ID, source.ID, "recruiter@corp.com")
+	if err != nil {
+		t.Fatalf("MergeCandidates() error = %v", err)
+	}
+	var ids []int
+	for _, tag := range merged.Tags {
+		ids = append(ids, tag.ID)
+	}
+	if want := []int{goTag.ID, rust.ID}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Tags = %v, want %v", ids, want)
+	}
+	if m := GetCandidateMerges(); len(m) != 1 || !reflect.DeepEqual(m[0].AddedTags, []string{"Rust"}) {
+		t.Errorf("AddedTags = %+v, want [Rust]", m)
+	}
+}
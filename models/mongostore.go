@@ -0,0 +1,984 @@
+package models
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"webservice/db"
+)
+
+//NewMongoStores returns the stores backed by the collections of the MongoDB database provided.
+func NewMongoStores(database *mongo.Database) Stores {
+	return Stores{
+		Candidates:      mongoCandidateStore{database.Collection("Candidates")},
+		Countries:       mongoCountryStore{database.Collection("Countries")},
+		JobRequisitions: mongoJobRequisitionStore{database.Collection("Requisitions")},
+		Applications:    mongoApplicationStore{database.Collection("Applications")},
+		Tags:            mongoTagStore{database.Collection("Tags")},
+		Interviews:      mongoInterviewStore{database.Collection("Interviews")},
+		Offers:          mongoOfferStore{database.Collection("Offers")},
+		Merges:          mongoMergeStore{database.Collection("CandidateMerges")},
+		Attachments:     mongoAttachmentStore{database.Collection("Attachments")},
+		Sequences:       mongoSequenceStore{database.Collection("Counters")},
+		Changes:         mongoChangeFeed{database},
+	}
+}
+
+//Creates the indexes the MongoDB stores rely on, keeping the ones that already exist.
+//Returns the error of duplicateApplications, without creating the unique index of the applications,
+//while a candidate has applied twice to the same requisition.
+//Returns an error if an index could not be created for another reason.
+func CreateMongoIndexes(ctx context.Context, database *mongo.Database) error {
+	applications := database.Collection("Applications")
+	duplicates, err := duplicateMongoApplications(ctx, applications)
+	if err != nil {
+		return unavailable("Could not check MongoDB applications: %v", err)
+	}
+	if len(duplicates) > 0 {
+		pairs := make([]string, len(duplicates))
+		for i, d := range duplicates {
+			pairs[i] = fmt.Sprintf("Candidate '%v' to Job Requisition '%v' on applications %v", d.Pair.CandidateProfileID, d.Pair.JobRequisitionID, d.IDs)
+		}
+		return duplicateApplications(pairs)
+	}
+
+	//A candidate applies only once to each requisition
+	_, err = applications.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"CandidateProfileID", 1}, {"JobRequisitionID", 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return unavailable("Could not create MongoDB indexes: %v", err)
+	}
+
+	//The content of an attachment is removed once no attachment has its checksum
+	_, err = database.Collection("Attachments").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{"Checksum", 1}},
+	})
+	if err != nil {
+		return unavailable("Could not create MongoDB indexes: %v", err)
+	}
+	return nil
+}
+
+//Applications of a candidate to the same requisition.
+type duplicateApplication struct {
+	Pair struct {
+		CandidateProfileID int `bson:"CandidateProfileID"`
+		JobRequisitionID   int `bson:"JobRequisitionID"`
+	} `bson:"_id"`
+	IDs []int `bson:"IDs"`
+}
+
+//Returns the candidates that applied more than once to the same requisition.
+func duplicateMongoApplications(ctx context.Context, coll *mongo.Collection) ([]duplicateApplication, error) {
+	pipeline := mongo.Pipeline{
+		{{"$group", bson.D{
+			{"_id", bson.D{{"CandidateProfileID", "$CandidateProfileID"}, {"JobRequisitionID", "$JobRequisitionID"}}},
+			{"IDs", bson.D{{"$push", "$ID"}}}}}},
+		{{"$match", bson.D{{"IDs.1", bson.D{{"$exists", true}}}}}},
+	}
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []duplicateApplication
+	err = cursor.All(ctx, &ret)
+	return ret, err
+}
+
+type mongoCandidateStore struct {
+	coll *mongo.Collection
+}
+
+func (s mongoCandidateStore) FindAll(ctx context.Context) ([]Candidate, error) {
+	projection := bson.D{
+		{"ID", 1},
+		{"FirstName", 1},
+		{"LastName", 1},
+		{"Email", 1},
+		{"Address", 1},
+		{"Tags", 1},
+		{"CanCountryId", 1}}
+
+	var ret []Candidate
+	err := findAllInCollection(ctx, s.coll, projection, func(v bson.D) {
+		ret = append(ret, bsonToCandidate(v))
+	})
+	return ret, err
+}
+
+func (s mongoCandidateStore) Insert(ctx context.Context, c Candidate) error {
+	doc := bson.D{
+		{"ID", c.ID},
+		{"FirstName", c.FirstName},
+		{"LastName", c.LastName},
+		{"Email", c.Email},
+		{"Address", c.Address},
+		{"Tags", c.Tags},
+		{"CanCountryId", c.CanCountryId}}
+
+	if err := insertIntoCollection(ctx, s.coll, doc); err != nil {
+		return unavailable("Could not insert Candidate provided")
+	}
+	return nil
+}
+
+func (s mongoCandidateStore) Update(ctx context.Context, c Candidate) error {
+	update := bson.D{{"$set", bson.D{
+		{"FirstName", c.FirstName},
+		{"LastName", c.LastName},
+		{"Email", c.Email},
+		{"Address", c.Address},
+		{"Tags", c.Tags},
+		{"CanCountryId", c.CanCountryId}}}}
+
+	if err := updateInCollection(ctx, s.coll, c.ID, update); err != nil {
+		return unavailable("Could not update candidate provided")
+	}
+	return nil
+}
+
+func (s mongoCandidateStore) Delete(ctx context.Context, id int) error {
+	if err := deleteFromCollection(ctx, s.coll, id); err != nil {
+		return unavailable("Could not delete Candidate")
+	}
+	return nil
+}
+
+type mongoCountryStore struct {
+	coll *mongo.Collection
+}
+
+func (s mongoCountryStore) FindAll(ctx context.Context) ([]Country, error) {
+	projection := bson.D{
+		{"ID", 1},
+		{"Name", 1},
+		{"Code", 1}}
+
+	var ret []Country
+	err := findAllInCollection(ctx, s.coll, projection, func(v bson.D) {
+		ret = append(ret, bsonToCountry(v))
+	})
+	return ret, err
+}
+
+func (s mongoCountryStore) Insert(ctx context.Context, c Country) error {
+	doc := bson.D{
+		{"ID", c.ID},
+		{"Name", c.Name},
+		{"Code", c.Code}}
+
+	if err := insertIntoCollection(ctx, s.coll, doc); err != nil {
+		return unavailable("Could not insert Country provided")
+	}
+	return nil
+}
+
+func (s mongoCountryStore) Update(ctx context.Context, c Country) error {
+	update := bson.D{{"$set", bson.D{{"Name", c.Name}, {"Code", c.Code}}}}
+
+	if err := updateInCollection(ctx, s.coll, c.ID, update); err != nil {
+		return unavailable("Could not update country on the database")
+	}
+	return nil
+}
+
+func (s mongoCountryStore) Delete(ctx context.Context, id int) error {
+	if err := deleteFromCollection(ctx, s.coll, id); err != nil {
+		return unavailable("Could not delete Country")
+	}
+	return nil
+}
+
+type mongoJobRequisitionStore struct {
+	coll *mongo.Collection
+}
+
+func (s mongoJobRequisitionStore) FindAll(ctx context.Context) ([]JobRequisition, error) {
+	projection := bson.D{
+		{"ID", 1},
+		{"Title", 1},
+		{"JobDescription", 1},
+		{"PostingStatus", 1},
+		{"State", 1},
+		{"StateHistory", 1},
+		{"Approvals", 1},
+		{"OpeningDate", 1},
+		{"ClosingDate", 1},
+		{"PostAt", 1},
+		{"UnpostAt", 1},
+		{"HiringManager", 1},
+		{"Recruiter", 1},
+		{"JrCountryId", 1},
+		{"Headcount", 1},
+		{"FilledCount", 1},
+		{"RequiredTags", 1},
+		{"PreferredTags", 1},
+		{"YearsOfExperience", 1},
+		{"Version", 1}}
+
+	var ret []JobRequisition
+	err := findAllInCollection(ctx, s.coll, projection, func(v bson.D) {
+		ret = append(ret, bsonToJobRequisition(v))
+	})
+	return ret, err
+}
+
+func (s mongoJobRequisitionStore) Insert(ctx context.Context, jr JobRequisition) error {
+	doc := bson.D{
+		{"ID", jr.ID},
+		{"Title", jr.Title},
+		{"JobDescription", jr.JobDescription},
+		{"PostingStatus", jr.PostingStatus},
+		{"State", jr.State},
+		{"StateHistory", jr.StateHistory},
+		{"Approvals", jr.Approvals},
+		{"OpeningDate", jr.OpeningDate},
+		{"ClosingDate", jr.ClosingDate},
+		{"PostAt", jr.PostAt},
+		{"UnpostAt", jr.UnpostAt},
+		{"HiringManager", jr.HiringManager},
+		{"Recruiter", jr.Recruiter},
+		{"JrCountryId", jr.JrCountryId},
+		{"Headcount", jr.Headcount},
+		{"FilledCount", jr.FilledCount},
+		{"RequiredTags", jr.RequiredTags},
+		{"PreferredTags", jr.PreferredTags},
+		{"YearsOfExperience", jr.YearsOfExperience},
+		{"Version", jr.Version}}
+
+	if err := insertIntoCollection(ctx, s.coll, doc); err != nil {
+		return unavailable("Could not insert Job Requisition provided")
+	}
+	return nil
+}
+
+func (s mongoJobRequisitionStore) Update(ctx context.Context, jr JobRequisition) error {
+	update := bson.D{{"$set", bson.D{
+		{"Title", jr.Title},
+		{"JobDescription", jr.JobDescription},
+		{"PostingStatus", jr.PostingStatus},
+		{"State", jr.State},
+		{"StateHistory", jr.StateHistory},
+		{"Approvals", jr.Approvals},
+		{"OpeningDate", jr.OpeningDate},
+		{"ClosingDate", jr.ClosingDate},
+		{"PostAt", jr.PostAt},
+		{"UnpostAt", jr.UnpostAt},
+		{"HiringManager", jr.HiringManager},
+		{"Recruiter", jr.Recruiter},
+		{"JrCountryId", jr.JrCountryId},
+		{"Headcount", jr.Headcount},
+		{"FilledCount", jr.FilledCount},
+		{"RequiredTags", jr.RequiredTags},
+		{"PreferredTags", jr.PreferredTags},
+		{"YearsOfExperience", jr.YearsOfExperience},
+		{"Version", jr.Version}}}}
+
+	matched, err := updateVersionInCollection(ctx, s.coll, jr.ID, jr.Version, update)
+	if err != nil {
+		return unavailable("Could not update  Requisition provided")
+	}
+	if !matched {
+		return staleRecord("Job Requisition", jr.ID)
+	}
+	return nil
+}
+
+func (s mongoJobRequisitionStore) Delete(ctx context.Context, id int) error {
+	if err := deleteFromCollection(ctx, s.coll, id); err != nil {
+		return unavailable("Could not delete requisition wiht ID provided")
+	}
+	return nil
+}
+
+type mongoApplicationStore struct {
+	coll *mongo.Collection
+}
+
+func (s mongoApplicationStore) FindAll(ctx context.Context) ([]Application, error) {
+	projection := bson.D{
+		{"ID", 1},
+		{"CandidateProfileID", 1},
+		{"JobRequisitionID", 1},
+		{"SalaryExpectation", 1},
+		{"ApplicationSource", 1},
+		{"TimeOfExperience", 1},
+		{"Stage", 1},
+		{"StageHistory", 1},
+		{"Status", 1},
+		{"WithdrawnAt", 1},
+		{"WithdrawnBy", 1},
+		{"WithdrawalReason", 1}}
+
+	var ret []Application
+	err := findAllInCollection(ctx, s.coll, projection, func(v bson.D) {
+		ret = append(ret, bsonToApplicant(v))
+	})
+	return ret, err
+}
+
+func (s mongoApplicationStore) Insert(ctx context.Context, a Application) error {
+	doc := bson.D{
+		{"ID", a.ID},
+		{"CandidateProfileID", a.CandidateProfileID},
+		{"JobRequisitionID", a.JobRequisitionID},
+		{"SalaryExpectation", a.SalaryExpectation},
+		{"ApplicationSource", a.ApplicationSource},
+		{"TimeOfExperience", a.TimeOfExperience},
+		{"Stage", a.Stage},
+		{"StageHistory", a.StageHistory},
+		{"Status", a.Status},
+		{"WithdrawnAt", a.WithdrawnAt},
+		{"WithdrawnBy", a.WithdrawnBy},
+		{"WithdrawalReason", a.WithdrawalReason}}
+
+	err := insertIntoCollection(ctx, s.coll, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		return conflict("Candidate already applied to the Job Requisition")
+	}
+	if err != nil {
+		return unavailable("Could not insert application provided")
+	}
+	return nil
+}
+
+func (s mongoApplicationStore) Update(ctx context.Context, a Application) error {
+	update := bson.D{{"$set", bson.D{
+		{"CandidateProfileID", a.CandidateProfileID},
+		{"JobRequisitionID", a.JobRequisitionID},
+		{"SalaryExpectation", a.SalaryExpectation},
+		{"ApplicationSource", a.ApplicationSource},
+		{"TimeOfExperience", a.TimeOfExperience},
+		{"Stage", a.Stage},
+		{"StageHistory", a.StageHistory},
+		{"Status", a.Status},
+		{"WithdrawnAt", a.WithdrawnAt},
+		{"WithdrawnBy", a.WithdrawnBy},
+		{"WithdrawalReason", a.WithdrawalReason}}}}
+
+	err := updateInCollection(ctx, s.coll, a.ID, update)
+	if mongo.IsDuplicateKeyError(err) {
+		return conflict("Candidate already applied to the Job Requisition")
+	}
+	if err != nil {
+		return unavailable("Could not update application provided")
+	}
+	return nil
+}
+
+func (s mongoApplicationStore) Delete(ctx context.Context, id int) error {
+	if err := deleteFromCollection(ctx, s.coll, id); err != nil {
+		return unavailable("Could not delete Application with id provided")
+	}
+	return nil
+}
+
+type mongoInterviewStore struct {
+	coll *mongo.Collection
+}
+
+func (s mongoInterviewStore) FindAll(ctx context.Context) ([]Interview, error) {
+	projection := bson.D{
+		{"ID", 1},
+		{"ApplicationID", 1},
+		{"Interviewers", 1},
+		{"Start", 1},
+		{"End", 1},
+		{"TimeZone", 1},
+		{"Location", 1},
+		{"VideoLink", 1},
+		{"Outcome", 1},
+		{"Notes", 1}}
+
+	var ret []Interview
+	err := findAllInCollection(ctx, s.coll, projection, func(v bson.D) {
+		ret = append(ret, bsonToInterview(v))
+	})
+	return ret, err
+}
+
+func (s mongoInterviewStore) Insert(ctx context.Context, i Interview) error {
+	doc := bson.D{
+		{"ID", i.ID},
+		{"ApplicationID", i.ApplicationID},
+		{"Interviewers", i.Interviewers},
+		{"Start", i.Start},
+		{"End", i.End},
+		{"TimeZone", i.TimeZone},
+		{"Location", i.Location},
+		{"VideoLink", i.VideoLink},
+		{"Outcome", i.Outcome},
+		{"Notes", i.Notes}}
+
+	if err := insertIntoCollection(ctx, s.coll, doc); err != nil {
+		return unavailable("Could not insert interview provided")
+	}
+	return nil
+}
+
+func (s mongoInterviewStore) Update(ctx context.Context, i Interview) error {
+	update := bson.D{{"$set", bson.D{
+		{"ApplicationID", i.ApplicationID},
+		{"Interviewers", i.Interviewers},
+		{"Start", i.Start},
+		{"End", i.End},
+		{"TimeZone", i.TimeZone},
+		{"Location", i.Location},
+		{"VideoLink", i.VideoLink},
+		{"Outcome", i.Outcome},
+		{"Notes", i.Notes}}}}
+
+	if err := updateInCollection(ctx, s.coll, i.ID, update); err != nil {
+		return unavailable("Could not update interview provided")
+	}
+	return nil
+}
+
+func (s mongoInterviewStore) Delete(ctx context.Context, id int) error {
+	if err := deleteFromCollection(ctx, s.coll, id); err != nil {
+		return unavailable("Could not delete Interview with id provided")
+	}
+	return nil
+}
+
+type mongoOfferStore struct {
+	coll *mongo.Collection
+}
+
+func (s mongoOfferStore) FindAll(ctx context.Context) ([]Offer, error) {
+	projection := bson.D{
+		{"ID", 1},
+		{"ApplicationID", 1},
+		{"Salary", 1},
+		{"Currency", 1},
+		{"SalaryPeriod", 1},
+		{"StartDate", 1},
+		{"ExpiresAt", 1},
+		{"Status", 1},
+		{"StatusHistory", 1},
+		{"Version", 1}}
+
+	var ret []Offer
+	err := findAllInCollection(ctx, s.coll, projection, func(v bson.D) {
+		ret = append(ret, bsonToOffer(v))
+	})
+	return ret, err
+}
+
+func (s mongoOfferStore) Insert(ctx context.Context, o Offer) error {
+	doc := bson.D{
+		{"ID", o.ID},
+		{"ApplicationID", o.ApplicationID},
+		{"Salary", o.Salary},
+		{"Currency", o.Currency},
+		{"SalaryPeriod", o.SalaryPeriod},
+		{"StartDate", o.StartDate},
+		{"ExpiresAt", o.ExpiresAt},
+		{"Status", o.Status},
+		{"StatusHistory", o.StatusHistory},
+		{"Version", o.Version}}
+
+	if err := insertIntoCollection(ctx, s.coll, doc); err != nil {
+		return unavailable("Could not insert offer provided")
+	}
+	return nil
+}
+
+func (s mongoOfferStore) Update(ctx context.Context, o Offer) error {
+	update := bson.D{{"$set", bson.D{
+		{"ApplicationID", o.ApplicationID},
+		{"Salary", o.Salary},
+		{"Currency", o.Currency},
+		{"SalaryPeriod", o.SalaryPeriod},
+		{"StartDate", o.StartDate},
+		{"ExpiresAt", o.ExpiresAt},
+		{"Status", o.Status},
+		{"StatusHistory", o.StatusHistory},
+		{"Version", o.Version}}}}
+
+	matched, err := updateVersionInCollection(ctx, s.coll, o.ID, o.Version, update)
+	if err != nil {
+		return unavailable("Could not update offer provided")
+	}
+	if !matched {
+		return staleRecord("Offer", o.ID)
+	}
+	return nil
+}
+
+func (s mongoOfferStore) Delete(ctx context.Context, id int) error {
+	if err := deleteFromCollection(ctx, s.coll, id); err != nil {
+		return unavailable("Could not delete Offer with id provided")
+	}
+	return nil
+}
+
+type mongoMergeStore struct {
+	coll *mongo.Collection
+}
+
+func (s mongoMergeStore) FindAll(ctx context.Context) ([]CandidateMerge, error) {
+	projection := bson.D{
+		{"ID", 1},
+		{"TargetID", 1},
+		{"SourceID", 1},
+		{"SourceFirstName", 1},
+		{"SourceLastName", 1},
+		{"SourceEmail", 1},
+		{"SourceAddress", 1},
+		{"MovedApplications", 1},
+		{"RemovedApplications", 1},
+		{"AddedTags", 1},
+		{"Status", 1},
+		{"By", 1},
+		{"At", 1}}
+
+	var ret []CandidateMerge
+	err := findAllInCollection(ctx, s.coll, projection, func(v bson.D) {
+		ret = append(ret, bsonToMerge(v))
+	})
+	return ret, err
+}
+
+func (s mongoMergeStore) Insert(ctx context.Context, m CandidateMerge) error {
+	doc := bson.D{
+		{"ID", m.ID},
+		{"TargetID", m.TargetID},
+		{"SourceID", m.SourceID},
+		{"SourceFirstName", m.SourceFirstName},
+		{"SourceLastName", m.SourceLastName},
+		{"SourceEmail", m.SourceEmail},
+		{"SourceAddress", m.SourceAddress},
+		{"MovedApplications", m.MovedApplications},
+		{"RemovedApplications", m.RemovedApplications},
+		{"AddedTags", m.AddedTags},
+		{"Status", m.Status},
+		{"By", m.By},
+		{"At", m.At}}
+
+	if err := insertIntoCollection(ctx, s.coll, doc); err != nil {
+		return unavailable("Could not insert Candidate merge provided")
+	}
+	return nil
+}
+
+func (s mongoMergeStore) Update(ctx context.Context, m CandidateMerge) error {
+	update := bson.D{{"$set", bson.D{
+		{"MovedApplications", m.MovedApplications},
+		{"RemovedApplications", m.RemovedApplications},
+		{"AddedTags", m.AddedTags},
+		{"Status", m.Status}}}}
+
+	if err := updateInCollection(ctx, s.coll, m.ID, update); err != nil {
+		return unavailable("Could not update Candidate merge provided")
+	}
+	return nil
+}
+
+type mongoAttachmentStore struct {
+	coll *mongo.Collection
+}
+
+func (s mongoAttachmentStore) FindAll(ctx context.Context) ([]Attachment, error) {
+	projection := bson.D{
+		{"ID", 1},
+		{"CandidateID", 1},
+		{"Kind", 1},
+		{"FileName", 1},
+		{"ContentType", 1},
+		{"Size", 1},
+		{"Checksum", 1},
+		{"UploadedBy", 1},
+		{"UploadedAt", 1},
+		{"Text", 1}}
+
+	var ret []Attachment
+	err := findAllInCollection(ctx, s.coll, projection, func(v bson.D) {
+		ret = append(ret, bsonToAttachment(v))
+	})
+	return ret, err
+}
+
+func (s mongoAttachmentStore) Insert(ctx context.Context, a Attachment) error {
+	doc := bson.D{
+		{"ID", a.ID},
+		{"CandidateID", a.CandidateID},
+		{"Kind", a.Kind},
+		{"FileName", a.FileName},
+		{"ContentType", a.ContentType},
+		{"Size", a.Size},
+		{"Checksum", a.Checksum},
+		{"UploadedBy", a.UploadedBy},
+		{"UploadedAt", a.UploadedAt},
+		{"Text", a.Text}}
+
+	if err := insertIntoCollection(ctx, s.coll, doc); err != nil {
+		return unavailable("Could not insert attachment provided")
+	}
+	return nil
+}
+
+func (s mongoAttachmentStore) Update(ctx context.Context, a Attachment) error {
+	update := bson.D{{"$set", bson.D{
+		{"CandidateID", a.CandidateID},
+		{"Kind", a.Kind},
+		{"FileName", a.FileName},
+		{"ContentType", a.ContentType},
+		{"Size", a.Size},
+		{"Checksum", a.Checksum},
+		{"UploadedBy", a.UploadedBy},
+		{"UploadedAt", a.UploadedAt},
+		{"Text", a.Text}}}}
+
+	if err := updateInCollection(ctx, s.coll, a.ID, update); err != nil {
+		return unavailable("Could not update attachment provided")
+	}
+	return nil
+}
+
+func (s mongoAttachmentStore) Delete(ctx context.Context, id int) error {
+	if err := deleteFromCollection(ctx, s.coll, id); err != nil {
+		return unavailable("Could not delete Attachment with id provided")
+	}
+	return nil
+}
+
+func (s mongoAttachmentStore) HasChecksum(ctx context.Context, checksum string) (bool, error) {
+	n, err := s.coll.CountDocuments(ctx, bson.D{{"Checksum", checksum}}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, unavailable("Could not check attachments with checksum provided")
+	}
+	return n > 0, nil
+}
+
+type mongoTagStore struct {
+	coll *mongo.Collection
+}
+
+func (s mongoTagStore) FindAll(ctx context.Context) ([]Tag, error) {
+	projection := bson.D{
+		{"ID", 1},
+		{"Label", 1},
+		{"Synonyms", 1},
+		{"ParentID", 1}}
+
+	var ret []Tag
+	err := findAllInCollection(ctx, s.coll, projection, func(v bson.D) {
+		ret = append(ret, bsonToTag(v))
+	})
+	return ret, err
+}
+
+func (s mongoTagStore) Insert(ctx context.Context, t Tag) error {
+	doc := bson.D{{"ID", t.ID}, {"Label", t.Label}, {"Synonyms", t.Synonyms}, {"ParentID", t.ParentID}}
+
+	if err := insertIntoCollection(ctx, s.coll, doc); err != nil {
+		return unavailable("Could not insert Tag provided into the Database")
+	}
+	return nil
+}
+
+func (s mongoTagStore) Update(ctx context.Context, t Tag) error {
+	update := bson.D{{"$set", bson.D{{"Label", t.Label}, {"Synonyms", t.Synonyms}, {"ParentID", t.ParentID}}}}
+
+	if err := updateInCollection(ctx, s.coll, t.ID, update); err != nil {
+		return unavailable("Could not update Tag provided")
+	}
+	return nil
+}
+
+func (s mongoTagStore) Delete(ctx context.Context, id int) error {
+	if err := deleteFromCollection(ctx, s.coll, id); err != nil {
+		return unavailable("Could not delete Tag with id provided")
+	}
+	return nil
+}
+
+//Keeps one document per sequence on the Counters collection, {_id: name, Seq: last value allocated}.
+type mongoSequenceStore struct {
+	coll *mongo.Collection
+}
+
+func (s mongoSequenceStore) Seed(ctx context.Context, name string, min int) error {
+	ctx, cancel := operationContext(ctx)
+	defer cancel()
+
+	filter := bson.D{{"_id", name}}
+	update := bson.D{{"$max", bson.D{{"Seq", min}}}}
+
+	if _, err := s.coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+		return unavailable("Could not seed sequence %v", name)
+	}
+	return nil
+}
+
+func (s mongoSequenceStore) Next(ctx context.Context, name string) (int, error) {
+	ctx, cancel := operationContext(ctx)
+	defer cancel()
+
+	//findAndModify is atomic on the server, so concurrent callers always get different values
+	filter := bson.D{{"_id", name}}
+	update := bson.D{{"$inc", bson.D{{"Seq", 1}}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var counter struct {
+		Seq int
+	}
+	if err := s.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&counter); err != nil {
+		return 0, err
+	}
+	return counter.Seq, nil
+}
+
+//Follows the change stream of the database, which requires MongoDB to run as a replica set.
+type mongoChangeFeed struct {
+	database *mongo.Database
+}
+
+func (f mongoChangeFeed) Watch(ctx context.Context, apply func(Change)) error {
+	pipeline := mongo.Pipeline{{{"$match", bson.D{{"ns.coll", bson.D{{"$in", cachedCollections}}}}}}}
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+
+	stream, err := f.database.Watch(ctx, pipeline, opts)
+	if err != nil {
+		return err
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var event struct {
+			OperationType string `bson:"operationType"`
+			Ns            struct {
+				Coll string `bson:"coll"`
+			} `bson:"ns"`
+			FullDocument bson.D `bson:"fullDocument"`
+		}
+		if err = stream.Decode(&event); err != nil {
+			return err
+		}
+
+		ch := Change{Collection: event.Ns.Coll}
+		//Deleted documents only carry their _id, so the record is left empty for the collection to be resynchronized
+		if event.OperationType != "delete" && event.FullDocument != nil {
+			switch event.Ns.Coll {
+			case candidateSequence:
+				ch.Record = bsonToCandidate(event.FullDocument)
+			case countrySequence:
+				ch.Record = bsonToCountry(event.FullDocument)
+			case jobRequisitionSequence:
+				ch.Record = bsonToJobRequisition(event.FullDocument)
+			case applicationSequence:
+				ch.Record = bsonToApplicant(event.FullDocument)
+			case tagSequence:
+				ch.Record = bsonToTag(event.FullDocument)
+			case interviewSequence:
+				ch.Record = bsonToInterview(event.FullDocument)
+			case offerSequence:
+				ch.Record = bsonToOffer(event.FullDocument)
+			case mergeSequence:
+				ch.Record = bsonToMerge(event.FullDocument)
+			case attachmentSequence:
+				ch.Record = bsonToAttachment(event.FullDocument)
+			}
+		}
+		apply(ch)
+	}
+	return stream.Err()
+}
+
+//Bounds the operation by db.OperationTimeout(), unless the caller's context already has a deadline.
+func operationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, db.OperationTimeout())
+}
+
+//Reads every document of the collection, calling fn for each one of them.
+func findAllInCollection(ctx context.Context, coll *mongo.Collection, projection bson.D, fn func(bson.D)) error {
+	ctx, cancel := operationContext(ctx)
+	defer cancel()
+
+	opts := options.Find().SetProjection(projection)
+	cursor, err := coll.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return err
+	}
+
+	var results []bson.D
+	if err = cursor.All(ctx, &results); err != nil {
+		return err
+	}
+
+	for _, v := range results {
+		fn(v)
+	}
+	return nil
+}
+
+func insertIntoCollection(ctx context.Context, coll *mongo.Collection, doc bson.D) error {
+	ctx, cancel := operationContext(ctx)
+	defer cancel()
+
+	_, err := coll.InsertOne(ctx, doc)
+	return err
+}
+
+func updateInCollection(ctx context.Context, coll *mongo.Collection, id int, update bson.D) error {
+	ctx, cancel := operationContext(ctx)
+	defer cancel()
+
+	_, err := coll.UpdateOne(ctx, bson.D{{"ID", id}}, update)
+	return err
+}
+
+//Updates the document with the ID provided only while it has the previous version, version-1.
+//Documents written before they had a Version are at version 0.
+//Returns false when no document matched, the one stored having another version or no longer existing.
+func updateVersionInCollection(ctx context.Context, coll *mongo.Collection, id int, version int, update bson.D) (bool, error) {
+	ctx, cancel := operationContext(ctx)
+	defer cancel()
+
+	filter := bson.D{{"ID", id}, {"Version", version - 1}}
+	if version-1 == 0 {
+		filter = bson.D{{"ID", id}, {"Version", bson.D{{"$in", bson.A{0, nil}}}}}
+	}
+	res, err := coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+func deleteFromCollection(ctx context.Context, coll *mongo.Collection, id int) error {
+	ctx, cancel := operationContext(ctx)
+	defer cancel()
+
+	_, err := coll.DeleteOne(ctx, bson.D{{"ID", id}})
+	return err
+}
+
+//Receives a bson object to execute the conversion.
+//Returns a Candidate object.
+func bsonToCandidate(v bson.D) Candidate {
+	bsonBytes, _ := bson.Marshal(v)
+
+	var c Candidate
+	//deconvert the byarray into a struct object
+	bson.Unmarshal(bsonBytes, &c)
+
+	return c
+}
+
+//Receives a bson object to execute the conversion.
+//Returns a Country object.
+func bsonToCountry(v bson.D) Country {
+	bsonBytes, _ := bson.Marshal(v)
+
+	var c Country
+	//deconvert the byarray into a struct object
+	bson.Unmarshal(bsonBytes, &c)
+
+	return c
+}
+
+//Receives a bson object to execute the conversion.
+//Returns a JobRequisition object, with the dates in UTC.
+func bsonToJobRequisition(v bson.D) JobRequisition {
+	bsonBytes, _ := bson.Marshal(v)
+
+	var jr JobRequisition
+	//deconvert the byarray into a struct object
+	bson.Unmarshal(bsonBytes, &jr)
+
+	jr.OpeningDate = jr.OpeningDate.UTC()
+	jr.ClosingDate = jr.ClosingDate.UTC()
+	jr.PostAt = jr.PostAt.UTC()
+	jr.UnpostAt = jr.UnpostAt.UTC()
+	return jr
+}
+
+//Receives a bson object to execute the conversion.
+//Returns a Application object.
+func bsonToApplicant(v bson.D) Application {
+	bsonBytes, _ := bson.Marshal(v)
+
+	var a Application
+	//deconvert the byarray into a struct object
+	bson.Unmarshal(bsonBytes, &a)
+
+	a.WithdrawnAt = a.WithdrawnAt.UTC()
+	return a
+}
+
+//Receives a bson object to execute the conversion.
+//Returns a Tag object.
+func bsonToTag(v bson.D) Tag {
+	bsonBytes, _ := bson.Marshal(v)
+
+	var t Tag
+	//deconvert the byarray into a struct object
+	bson.Unmarshal(bsonBytes, &t)
+
+	return t
+}
+
+//Receives a bson object to execute the conversion.
+//Returns a Interview object, with the times in UTC.
+func bsonToInterview(v bson.D) Interview {
+	bsonBytes, _ := bson.Marshal(v)
+
+	var i Interview
+	//deconvert the byarray into a struct object
+	bson.Unmarshal(bsonBytes, &i)
+
+	i.Start = i.Start.UTC()
+	i.End = i.End.UTC()
+	return i
+}
+
+//Receives a bson object to execute the conversion.
+//Returns a CandidateMerge object, with the time in UTC.
+func bsonToMerge(v bson.D) CandidateMerge {
+	bsonBytes, _ := bson.Marshal(v)
+
+	var m CandidateMerge
+	//deconvert the byarray into a struct object
+	bson.Unmarshal(bsonBytes, &m)
+
+	m.At = m.At.UTC()
+	return m
+}
+
+//Receives a bson object to execute the conversion.
+//Returns a Offer object, with the times in UTC.
+func bsonToOffer(v bson.D) Offer {
+	bsonBytes, _ := bson.Marshal(v)
+
+	var o Offer
+	//deconvert the byarray into a struct object
+	bson.Unmarshal(bsonBytes, &o)
+
+	o.StartDate = o.StartDate.UTC()
+	o.ExpiresAt = o.ExpiresAt.UTC()
+	return o
+}
+
+//Receives a bson object to execute the conversion.
+//Returns a Attachment object, with the time in UTC.
+func bsonToAttachment(v bson.D) Attachment {
+	bsonBytes, _ := bson.Marshal(v)
+
+	var a Attachment
+	//deconvert the byarray into a struct object
+	bson.Unmarshal(bsonBytes, &a)
+
+	a.UploadedAt = a.UploadedAt.UTC()
+	return a
+}
@@ -0,0 +1,352 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"webservice/validate"
+)
+
+//Statuses of an Offer.
+const (
+	OfferDraft           = "Draft"
+	OfferPendingApproval = "PendingApproval"
+	OfferExtended        = "Extended"
+	OfferAccepted        = "Accepted"
+	OfferDeclined        = "Declined"
+	OfferExpired         = "Expired"
+)
+
+type Offer struct {
+	ID            int
+	ApplicationID int     `validate:"required"`
+	Salary        float64 `validate:"required,min=0"`
+	//ISO 4217 code of the currency of the Salary.
+	Currency     string    `validate:"required,iso4217"`
+	SalaryPeriod string    `validate:"required,oneof=Hour Month Year"`
+	StartDate    time.Time `validate:"required"`
+	//Offers not accepted or declined by this time are expired by the sweep.
+	ExpiresAt     time.Time `validate:"required"`
+	Status        string
+	StatusHistory []StageChange
+	//Times the Offer was written, so a change made from an Offer read before another one is rejected by the store.
+	Version int `json:"-"`
+}
+
+//Actions that move an Offer between statuses, as used on POST /application/{id}/offers/{offerID}/{action}.
+var offerActions = map[string]struct {
+	from []string
+	to   string
+}{
+	"submit":  {from: []string{OfferDraft}, to: OfferPendingApproval},
+	"approve": {from: []string{OfferPendingApproval}, to: OfferExtended},
+	"reject":  {from: []string{OfferPendingApproval}, to: OfferDraft},
+	"accept":  {from: []string{OfferExtended}, to: OfferAccepted},
+	"decline": {from: []string{OfferExtended}, to: OfferDeclined},
+}
+
+//Returns true while the Offer may still be accepted.
+func (o Offer) open() bool {
+	switch o.Status {
+	case OfferDraft, OfferPendingApproval, OfferExtended:
+		return true
+	}
+	return false
+}
+
+//In Memory: Returns the Offer of the Application with id received as parameter.
+//Returns a list of Offer object.
+func GetOffersOfApplication(id int) []Offer {
+	return records.offersOfApplication(id)
+}
+
+//In Memory: Searches for a specific Offer of the Application.
+//Returns a Offer object and an error in case it was not possible to find the record
+func GetOfferByID(applicationID int, id int) (Offer, error) {
+	if o, found := records.offer(id); found && o.ApplicationID == applicationID {
+		return o, nil
+	}
+	return Offer{}, notFound("Offer with ID '%v' not found for Application '%v'", id, applicationID)
+}
+
+//In DB: Creates a new Offer for the Application, in Draft.
+//Returns a Offer object and an error in case the Offer is invalid or the Application already has an open or accepted Offer.
+func AddOffer(ctx context.Context, o Offer) (Offer, error) {
+	a, found := records.application(o.ApplicationID)
+	if !found {
+		return Offer{}, notFound("Application with ID '%v' not found", o.ApplicationID)
+	}
+	if a.withdrawn() {
+		return Offer{}, conflict("Application '%v' was withdrawn, no offer can be made", o.ApplicationID)
+	}
+
+	o.Currency = strings.ToUpper(strings.TrimSpace(o.Currency))
+
+	var v validate.Validator
+	v.Check(o.ID == 0, "ID", "Offer must not contain ID upon creation")
+	checkOffer(&v, o)
+	if err := invalid("Offer is not valid", &v); err != nil {
+		return Offer{}, err
+	}
+
+	for _, other := range records.offersOfApplication(o.ApplicationID) {
+		if other.open() {
+			return Offer{}, conflict("Application '%v' already has the open Offer '%v'", o.ApplicationID, other.ID)
+		}
+		//Accepting a second offer would fill a second position with the same Candidate
+		if other.Status == OfferAccepted {
+			return Offer{}, conflict("Application '%v' already accepted the Offer '%v'", o.ApplicationID, other.ID)
+		}
+	}
+
+	id, err := nextID(ctx, offerSequence)
+	if err != nil {
+		return Offer{}, err
+	}
+	o.ID = id
+	o.Status = OfferDraft
+	o.StatusHistory = []StageChange{{To: OfferDraft, At: time.Now().UTC()}}
+	o.Version = 0
+
+	if err = stores.Offers.Insert(ctx, o); err != nil {
+		return Offer{}, err
+	}
+
+	records.putOffer(o)
+	return o, nil
+}
+
+//In DB: Updates the terms of an Offer, which is only possible while it is a Draft.
+//Returns a Offer object and an error in case it was not possible to update the record
+func UpdateOffer(ctx context.Context, o Offer) (Offer, error) {
+	old, err := GetOfferByID(o.ApplicationID, o.ID)
+	if err != nil {
+		return Offer{}, err
+	}
+	if old.Status != OfferDraft {
+		return Offer{}, conflict("Offer '%v' is %v, only a Draft can be changed", o.ID, old.Status)
+	}
+
+	o.Currency = strings.ToUpper(strings.TrimSpace(o.Currency))
+
+	var v validate.Validator
+	checkOffer(&v, o)
+	if err := invalid("Offer is not valid", &v); err != nil {
+		return Offer{}, err
+	}
+
+	//The status can only be changed through TransitionOffer
+	o.Status = old.Status
+	o.StatusHistory = old.StatusHistory
+	o.Version = old.Version + 1
+
+	if err := stores.Offers.Update(ctx, o); err != nil {
+		return Offer{}, err
+	}
+
+	records.putOffer(o)
+	return o, nil
+}
+
+//In DB: Removes a Draft Offer of the Application.
+//Returns error if the Offer was already submitted or if failed to complete the deletion on the DB
+func DeleteOffer(ctx context.Context, applicationID int, id int) error {
+	o, err := GetOfferByID(applicationID, id)
+	if err != nil {
+		return err
+	}
+	if o.Status != OfferDraft {
+		return conflict("Offer '%v' is %v, only a Draft can be deleted", id, o.Status)
+	}
+	return deleteOffer(ctx, id)
+}
+
+func deleteOffer(ctx context.Context, id int) error {
+	if err := stores.Offers.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	records.removeOffer(id)
+	return nil
+}
+
+//In DB: Removes all Offer from a specified Application, whatever their status.
+//Returns error on the first Offer that could not be deleted, the ones before it are already deleted
+func DeleteOffersFromApplication(ctx context.Context, id int) error {
+	for _, v := range GetOffersOfApplication(id) {
+		if err := deleteOffer(ctx, v.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//In DB: Applies the action (submit, approve, reject, accept or decline) to the Offer.
+//Accepting an Offer fills one position of the JobRequisition the Application was made to.
+//Returns the updated Offer, or an error if the action is unknown or not allowed on the current status.
+func TransitionOffer(ctx context.Context, applicationID int, id int, action string, by string, comment string) (Offer, error) {
+	move, known := offerActions[action]
+	if !known {
+		return Offer{}, notFound("Unknown action '%v' for Offer", action)
+	}
+
+	var v validate.Validator
+	v.Check(by != "", "By", "should be populated")
+	if err := invalid("Transition is not valid", &v); err != nil {
+		return Offer{}, err
+	}
+
+	o, err := GetOfferByID(applicationID, id)
+	if err != nil {
+		return Offer{}, err
+	}
+
+	allowed := false
+	for _, s := range move.from {
+		allowed = allowed || o.Status == s
+	}
+	if !allowed {
+		return Offer{}, conflict("Offer '%v' is %v and cannot be %v", id, o.Status, move.to)
+	}
+
+	now := time.Now().UTC()
+	if o.open() && !now.Before(o.ExpiresAt) {
+		return Offer{}, conflict("Offer '%v' expired at %v", id, o.ExpiresAt.Format(time.RFC3339))
+	}
+
+	if move.to == OfferAccepted {
+		return acceptOffer(ctx, o, by, comment, now)
+	}
+	return setOfferStatus(ctx, o, move.to, by, comment, now)
+}
+
+//Accepts the Offer and fills one position of the JobRequisition its Application was made to.
+//The Offer is saved first and put back as it was when the position could not be filled,
+//so a failure never leaves a position filled by an Offer that is not Accepted.
+//Both writes are rejected by the stores when the record changed since it was read, so an Offer expired
+//meanwhile is not accepted and two offers accepted at once do not fill the same position.
+func acceptOffer(ctx context.Context, o Offer, by string, comment string, at time.Time) (Offer, error) {
+	jr, err := openPosition(o.ApplicationID)
+	if err != nil {
+		return Offer{}, err
+	}
+
+	accepted, err := setOfferStatus(ctx, o, OfferAccepted, by, comment, at)
+	if err != nil {
+		return Offer{}, err
+	}
+
+	if err := fillPosition(ctx, jr, at); err != nil {
+		rollback := o
+		rollback.Version = accepted.Version + 1
+		if rollbackErr := stores.Offers.Update(ctx, rollback); rollbackErr != nil {
+			log.Printf("Could not put Offer %v back to %v after failing to fill its position: %v", o.ID, o.Status, rollbackErr)
+		} else {
+			records.putOffer(rollback)
+		}
+		return Offer{}, err
+	}
+	return accepted, nil
+}
+
+//Records the new status of the Offer on the stores and in memory.
+func setOfferStatus(ctx context.Context, o Offer, status string, by string, comment string, at time.Time) (Offer, error) {
+	change := StageChange{From: o.Status, To: status, By: by, At: at, Comment: comment}
+	o.Status = status
+	o.StatusHistory = append(append([]StageChange(nil), o.StatusHistory...), change)
+	o.Version++
+
+	if err := stores.Offers.Update(ctx, o); err != nil {
+		return Offer{}, err
+	}
+
+	records.putOffer(o)
+	return o, nil
+}
+
+//Returns the JobRequisition the Application was made to.
+//Returns a ConflictError when it is not Open or its headcount is already filled.
+func openPosition(applicationID int) (JobRequisition, error) {
+	a, found := records.application(applicationID)
+	if !found {
+		return JobRequisition{}, notFound("Application with ID '%v' not found", applicationID)
+	}
+	jr, found := records.jobRequisition(a.JobRequisitionID)
+	if !found {
+		return JobRequisition{}, notFound("Job Requisition with ID '%v' not found", a.JobRequisitionID)
+	}
+	if jr.FilledCount >= jr.Headcount {
+		return JobRequisition{}, conflict("Job Requisition '%v' has its headcount of %v already filled", jr.ID, jr.Headcount)
+	}
+	if jr.State != RequisitionOpen {
+		return JobRequisition{}, conflict("Job Requisition '%v' is %v, only an Open requisition can be filled", jr.ID, jr.State)
+	}
+	return jr, nil
+}
+
+//Increases the FilledCount of the JobRequisition.
+func fillPosition(ctx context.Context, jr JobRequisition, at time.Time) error {
+	jr.FilledCount++
+	//The requisition is filled automatically by the offer taking its last position
+	if jr.FilledCount >= jr.Headcount {
+		return setRequisitionState(ctx, &jr, RequisitionFilled, "system", "All positions filled", at)
+	}
+	jr.Version++
+	if err := stores.JobRequisitions.Update(ctx, jr); err != nil {
+		return err
+	}
+
+	records.putJobRequisition(jr)
+	return nil
+}
+
+//In DB: Moves every open Offer whose expiry is before now to Expired.
+//The offers changed since they were read, such as an Offer accepted meanwhile or expired by another replica, are left as they are.
+//Returns the number of offers expired.
+func ExpireOffers(ctx context.Context, now time.Time) (int, error) {
+	expired := 0
+	for _, o := range records.allOffers() {
+		if !o.open() || now.Before(o.ExpiresAt) {
+			continue
+		}
+		_, err := setOfferStatus(ctx, o, OfferExpired, "system", "Expired automatically", now.UTC())
+		var c *ConflictError
+		if errors.As(err, &c) {
+			continue
+		}
+		if err != nil {
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+//SweepOffers expires the offers past their expiry every interval until ctx is cancelled.
+func SweepOffers(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if n, err := ExpireOffers(ctx, now); err != nil {
+				log.Printf("Could not expire offers: %v", err)
+			} else if n > 0 {
+				log.Printf("Expired %v offers", n)
+			}
+		}
+	}
+}
+
+//Collects the violations of the rules shared by the creation and the update of an Offer.
+func checkOffer(v *validate.Validator, o Offer) {
+	v.Struct(o)
+	v.Check(o.ExpiresAt.IsZero() || o.ExpiresAt.After(time.Now()), "ExpiresAt", "must be in the future")
+	v.Check(o.StartDate.IsZero() || o.ExpiresAt.IsZero() || o.StartDate.After(o.ExpiresAt), "StartDate", "must be after ExpiresAt")
+}
@@ -0,0 +1,235 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+//Creates a Draft Offer for the Application, expiring in a week.
+func addTestOffer(t *testing.T, ctx context.Context, applicationID int) Offer {
+	t.Helper()
+	o, err := AddOffer(ctx, Offer{
+		ApplicationID: applicationID,
+		Salary:        90000,
+		Currency:      " usd",
+		SalaryPeriod:  "Year",
+		ExpiresAt:     time.Now().Add(7 * 24 * time.Hour),
+		StartDate:     time.Now().Add(30 * 24 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("AddOffer() error = %v", err)
+	}
+	return o
+}
+
+//Moves the Offer through the actions provided, failing the test on the first error.
+func transitionTestOffer(t *testing.T, ctx context.Context, o Offer, actions ...string) Offer {
+	t.Helper()
+	for _, action := range actions {
+		var err error
+		if o, err = TransitionOffer(ctx, o.ApplicationID, o.ID, action, "recruiter@corp.com", ""); err != nil {
+			t.Fatalf("TransitionOffer(%v) error = %v", action, err)
+		}
+	}
+	return o
+}
+
+func TestOfferLifecycle(t *testing.T) {
+	ctx := initMemoryStores(t)
+	jr := addTestRequisition(t, ctx, "Backend Engineer", RequisitionOpen, 1)
+	a := addTestApplication(t, ctx, addTestCandidate(t, ctx, "Jane", "Doe").ID, jr.ID)
+
+	o := addTestOffer(t, ctx, a.ID)
+	if o.Status != OfferDraft || o.Currency != "USD" {
+		t.Fatalf("AddOffer() = %v in %v, want Draft in USD", o.Status, o.Currency)
+	}
+
+	//The Application has a single open Offer at a time
+	_, err := AddOffer(ctx, Offer{ApplicationID: a.ID, Salary: 1, Currency: "USD", SalaryPeriod: "Year", ExpiresAt: o.ExpiresAt, StartDate: o.StartDate})
+	wantError[*ConflictError](t, err)
+
+	o = transitionTestOffer(t, ctx, o, "submit", "reject", "submit", "approve")
+	if o.Status != OfferExtended {
+		t.Fatalf("Status = %v, want %v", o.Status, OfferExtended)
+	}
+	_, err = TransitionOffer(ctx, a.ID, o.ID, "submit", "recruiter@corp.com", "")
+	wantError[*ConflictError](t, err)
+
+	o = transitionTestOffer(t, ctx, o, "accept")
+	var statuses []string
+	for _, sc := range o.StatusHistory {
+		statuses = append(statuses, sc.To)
+	}
+	if want := []string{OfferDraft, OfferPendingApproval, OfferDraft, OfferPendingApproval, OfferExtended, OfferAccepted}; !reflect.DeepEqual(statuses, want) {
+		t.Errorf("StatusHistory = %v, want %v", statuses, want)
+	}
+
+	filled, _ := GetJobRequisitionByID(jr.ID)
+	if filled.FilledCount != 1 || filled.State != RequisitionFilled {
+		t.Errorf("Job Requisition = %v filled and %v, want 1 filled and %v", filled.FilledCount, filled.State, RequisitionFilled)
+	}
+
+	//An accepted Offer is final, no other Offer may fill a second position
+	_, err = AddOffer(ctx, Offer{ApplicationID: a.ID, Salary: 1, Currency: "USD", SalaryPeriod: "Year", ExpiresAt: o.ExpiresAt, StartDate: o.StartDate})
+	wantError[*ConflictError](t, err)
+}
+
+func TestOfferAcceptHeadcountFilled(t *testing.T) {
+	ctx := initMemoryStores(t)
+	jr := addTestRequisition(t, ctx, "Backend Engineer", RequisitionOpen, 1)
+	first := addTestOffer(t, ctx, addTestApplication(t, ctx, addTestCandidate(t, ctx, "Jane", "Doe").ID, jr.ID).ID)
+	second := addTestOffer(t, ctx, addTestApplication(t, ctx, addTestCandidate(t, ctx, "John", "Roe").ID, jr.ID).ID)
+	transitionTestOffer(t, ctx, first, "submit", "approve", "accept")
+	second = transitionTestOffer(t, ctx, second, "submit", "approve")
+
+	_, err := TransitionOffer(ctx, second.ApplicationID, second.ID, "accept", "recruiter@corp.com", "")
+	wantError[*ConflictError](t, err)
+	if o, _ := GetOfferByID(second.ApplicationID, second.ID); o.Status != OfferExtended {
+		t.Errorf("Status = %v, want %v", o.Status, OfferExtended)
+	}
+}
+
+//JobRequisitionStore failing every update.
+type failingRequisitionStore struct {
+	JobRequisitionStore
+}
+
+func (failingRequisitionStore) Update(ctx context.Context, jr JobRequisition) error {
+	return errors.New("Storage unavailable")
+}
+
+//OfferStore failing every update.
+type failingOfferStore struct {
+	OfferStore
+}
+
+func (failingOfferStore) Update(ctx context.Context, o Offer) error {
+	return errors.New("Storage unavailable")
+}
+
+//An accept failing on either store leaves the Offer Extended and the position not filled, so it can be accepted again.
+func TestOfferAcceptFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		fail func()
+	}{
+		{"offer", func() { stores.Offers = failingOfferStore{stores.Offers} }},
+		{"requisition", func() { stores.JobRequisitions = failingRequisitionStore{stores.JobRequisitions} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := initMemoryStores(t)
+			jr := addTestRequisition(t, ctx, "Backend Engineer", RequisitionOpen, 2)
+			o := addTestOffer(t, ctx, addTestApplication(t, ctx, addTestCandidate(t, ctx, "Jane", "Doe").ID, jr.ID).ID)
+			o = transitionTestOffer(t, ctx, o, "submit", "approve")
+
+			tt.fail()
+			if _, err := TransitionOffer(ctx, o.ApplicationID, o.ID, "accept", "recruiter@corp.com", ""); err == nil {
+				t.Fatal("TransitionOffer(accept) error = nil, want the error of the store")
+			}
+
+			got, _ := GetOfferByID(o.ApplicationID, o.ID)
+			if got.Status != OfferExtended || len(got.StatusHistory) != len(o.StatusHistory) {
+				t.Errorf("Status = %v after %v changes, want %v after %v", got.Status, len(got.StatusHistory), OfferExtended, len(o.StatusHistory))
+			}
+			if stored, _ := GetJobRequisitionByID(jr.ID); stored.FilledCount != 0 {
+				t.Errorf("FilledCount = %v, want 0", stored.FilledCount)
+			}
+		})
+	}
+}
+
+//OfferStore taking a while to update, so the requests made at once all read the records before any of them writes.
+type slowOfferStore struct {
+	OfferStore
+}
+
+func (s slowOfferStore) Update(ctx context.Context, o Offer) error {
+	time.Sleep(10 * time.Millisecond)
+	return s.OfferStore.Update(ctx, o)
+}
+
+//Offers accepted at once on the same requisition fill no more positions than its headcount, and every position filled is counted.
+func TestOfferAcceptConcurrent(t *testing.T) {
+	for _, headcount := range []int{1, 3} {
+		ctx := initMemoryStores(t)
+		jr := addTestRequisition(t, ctx, "Backend Engineer", RequisitionOpen, headcount)
+		var offers []Offer
+		for i := 0; i < 8; i++ {
+			c := addTestCandidate(t, ctx, "Jane", fmt.Sprintf("Doe%v", i))
+			o := addTestOffer(t, ctx, addTestApplication(t, ctx, c.ID, jr.ID).ID)
+			offers = append(offers, transitionTestOffer(t, ctx, o, "submit", "approve"))
+		}
+		stores.Offers = slowOfferStore{stores.Offers}
+
+		var wg sync.WaitGroup
+		errs := make([]error, len(offers))
+		for i, o := range offers {
+			wg.Add(1)
+			go func(i int, o Offer) {
+				defer wg.Done()
+				_, errs[i] = TransitionOffer(ctx, o.ApplicationID, o.ID, "accept", "recruiter@corp.com", "")
+			}(i, o)
+		}
+		wg.Wait()
+
+		accepted := 0
+		for i, err := range errs {
+			var c *ConflictError
+			if err != nil && !errors.As(err, &c) {
+				t.Fatalf("TransitionOffer(accept) error = %v, want nil or a ConflictError", err)
+			}
+			o, _ := GetOfferByID(offers[i].ApplicationID, offers[i].ID)
+			if (err == nil) != (o.Status == OfferAccepted) {
+				t.Errorf("Offer %v is %v after the accept returned %v", o.ID, o.Status, err)
+			}
+			if err == nil {
+				accepted++
+			}
+		}
+		stored, _ := GetJobRequisitionByID(jr.ID)
+		if accepted == 0 || accepted > headcount || stored.FilledCount != accepted {
+			t.Errorf("headcount %v: %v offers accepted filling %v positions, want 1 to %v accepted, each filling a position", headcount, accepted, stored.FilledCount, headcount)
+		}
+	}
+}
+
+func TestOfferAcceptNotOpen(t *testing.T) {
+	ctx := initMemoryStores(t)
+	jr := addTestRequisition(t, ctx, "Backend Engineer", RequisitionOpen, 1)
+	o := addTestOffer(t, ctx, addTestApplication(t, ctx, addTestCandidate(t, ctx, "Jane", "Doe").ID, jr.ID).ID)
+	o = transitionTestOffer(t, ctx, o, "submit", "approve")
+	if _, err := TransitionJobRequisition(ctx, jr.ID, RequisitionOnHold, "recruiter@corp.com", ""); err != nil {
+		t.Fatalf("TransitionJobRequisition() error = %v", err)
+	}
+
+	_, err := TransitionOffer(ctx, o.ApplicationID, o.ID, "accept", "recruiter@corp.com", "")
+	wantError[*ConflictError](t, err)
+	if stored, _ := GetJobRequisitionByID(jr.ID); stored.FilledCount != 0 {
+		t.Errorf("FilledCount = %v, want 0", stored.FilledCount)
+	}
+}
+
+//The sweep of a replica still caching the Offer as Extended does not expire it once accepted.
+func TestExpireOffersStale(t *testing.T) {
+	ctx := initMemoryStores(t)
+	jr := addTestRequisition(t, ctx, "Backend Engineer", RequisitionOpen, 1)
+	o := addTestOffer(t, ctx, addTestApplication(t, ctx, addTestCandidate(t, ctx, "Jane", "Doe").ID, jr.ID).ID)
+	extended := transitionTestOffer(t, ctx, o, "submit", "approve")
+	transitionTestOffer(t, ctx, extended, "accept")
+	records.putOffer(extended)
+
+	n, err := ExpireOffers(ctx, extended.ExpiresAt.Add(time.Hour))
+	if err != nil || n != 0 {
+		t.Fatalf("ExpireOffers() = %v, %v, want 0 expired", n, err)
+	}
+	all, _ := stores.Offers.FindAll(ctx)
+	if len(all) != 1 || all[0].Status != OfferAccepted {
+		t.Errorf("stored Offer = %+v, want it Accepted", all)
+	}
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"webservice/validate"
+)
+
+//StageChange records an Application moving from one stage of the pipeline to another, who moved it and when.
+//The first change of every Application has an empty From, it is the stage the Application was created on.
+//It also records the statuses of an Offer and the states of a JobRequisition.
+type StageChange struct {
+	From    string
+	To      string
+	By      string
+	At      time.Time
+	Comment string
+}
+
+//StageCount is the number of Applicants of a JobRequisition on a stage of the pipeline.
+type StageCount struct {
+	Stage string
+	Count int
+}
+
+//Pipeline lists the stages an Application goes through and the moves allowed between them.
+type Pipeline struct {
+	//Stages in the order they are shown, new applications start on the first one.
+	Stages []string
+	//Stages each stage may move to, stages without transitions are final.
+	Transitions map[string][]string
+}
+
+//Pipeline used by the Application, set at startup by SetPipeline.
+var pipeline = Pipeline{Stages: []string{"Applied"}}
+
+//SetPipeline replaces the stages used by the Application.
+//Must be called before the controllers start serving requests.
+func SetPipeline(p Pipeline) {
+	pipeline = p
+}
+
+//GetPipeline returns the stages used by the Application.
+func GetPipeline() Pipeline {
+	return pipeline
+}
+
+//Stage given to new applications.
+func (p Pipeline) initial() string {
+	return p.Stages[0]
+}
+
+//Stage of the Application, applications created before the pipeline existed are on the initial stage.
+func (p Pipeline) stageOf(a Application) string {
+	if a.Stage == "" {
+		return p.initial()
+	}
+	return a.Stage
+}
+
+func (p Pipeline) hasStage(stage string) bool {
+	for _, s := range p.Stages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Pipeline) allows(from string, to string) bool {
+	for _, s := range p.Transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+//In DB: Moves the Application to the stage received, recording who moved it and when on the StageHistory.
+//Returns the updated Application, or an error if the stage is unknown or cannot be reached from the current one.
+func TransitionApplication(ctx context.Context, id int, to string, by string, comment string) (Application, error) {
+	var v validate.Validator
+	v.Check(to != "", "Stage", "should be populated")
+	v.Check(to == "" || pipeline.hasStage(to), "Stage", "'%v' is not a stage of the pipeline", to)
+	v.Check(by != "", "By", "should be populated")
+	if err := invalid("Transition is not valid", &v); err != nil {
+		return Application{}, err
+	}
+
+	a, found := records.application(id)
+	if !found {
+		return Application{}, notFound("Application with ID '%v' not found", id)
+	}
+	if a.withdrawn() {
+		return Application{}, conflict("Application '%v' was withdrawn and cannot move on the pipeline", id)
+	}
+
+	from := pipeline.stageOf(a)
+	if !pipeline.allows(from, to) {
+		return Application{}, conflict("Application cannot move from '%v' to '%v'", from, to)
+	}
+
+	change := StageChange{From: from, To: to, By: by, At: time.Now().UTC(), Comment: comment}
+	a.Stage = to
+	a.StageHistory = append(append([]StageChange(nil), a.StageHistory...), change)
+
+	if err := stores.Applications.Update(ctx, a); err != nil {
+		return Application{}, err
+	}
+
+	records.putApplication(a)
+	return a, nil
+}
+
+//Counts the Applicants on each stage of the pipeline, in the order of the pipeline.
+//Applications on stages that are no longer part of the pipeline are counted at the end.
+func countByStage(apps []Application) []StageCount {
+	counts := make([]StageCount, 0, len(pipeline.Stages))
+	index := make(map[string]int)
+	for _, s := range pipeline.Stages {
+		index[s] = len(counts)
+		counts = append(counts, StageCount{Stage: s})
+	}
+
+	for _, a := range apps {
+		stage := pipeline.stageOf(a)
+		i, found := index[stage]
+		if !found {
+			i = len(counts)
+			index[stage] = i
+			counts = append(counts, StageCount{Stage: stage})
+		}
+		counts[i].Count++
+	}
+	return counts
+}
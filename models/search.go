@@ -0,0 +1,66 @@
+package models
+
+import (
+	"webservice/search"
+	"webservice/validate"
+)
+
+//CandidateHit is a Candidate found by SearchCandidates, with the relevance of the match.
+type CandidateHit struct {
+	Candidate
+	Score float64
+}
+
+//Fields of the full text search of candidates, with the weight of the words found on each of them.
+var candidateFieldWeights = map[string]float64{
+	"first":   3,
+	"last":    3,
+	"email":   2,
+	"tag":     2.5,
+	"country": 1,
+	"address": 1,
+	"resume":  0.5,
+}
+
+//Names searching many fields at once, name:silva finds the first and last names.
+var candidateFieldScopes = map[string][]string{
+	"name": {"first", "last"},
+}
+
+func newCandidateFullText() *search.FullText[int] {
+	return search.NewFullText[int](candidateFieldWeights, candidateFieldScopes)
+}
+
+//Returns the text of the Candidate searched by SearchCandidates, including the country and the text of the attachments.
+//tags are the labels of the Tags of the Candidate with their synonyms and parents, so tag:backend finds the Candidates tagged Go.
+func candidateDocument(can Candidate, country Country, tags []string, attachments []Attachment) search.Document {
+	doc := search.Document{
+		"first":   {can.FirstName},
+		"last":    {can.LastName},
+		"email":   {can.Email},
+		"address": {can.Address},
+		"country": {country.Code, country.Name},
+		"tag":     tags,
+	}
+	for _, a := range attachments {
+		if a.Text != "" {
+			doc["resume"] = append(doc["resume"], a.Text)
+		}
+	}
+	return doc
+}
+
+//In Memory: Searches the names, email, address, country, tags and resume text of the Candidates.
+//The query is made of words and "phrases", which may be scoped to a field (tag:go country:BR name:silva),
+//combined with AND, OR, NOT and parentheses. Words without an operator must all be found.
+//Returns the Candidates found, the most relevant first, or an error in case the query is not valid
+func SearchCandidates(query string) ([]CandidateHit, error) {
+	q, err := records.candidateText.Parse(query)
+
+	var v validate.Validator
+	v.Check(err == nil, "q", "%v", err)
+	if err := invalid("Search query is not valid", &v); err != nil {
+		return nil, err
+	}
+	return records.searchCandidates(q), nil
+}
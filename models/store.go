@@ -0,0 +1,192 @@
+package models
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+//CandidateStore persists Candidate records.
+//Only the fields owned by the Candidate are persisted, CountryObj and JobsApplied are resolved in memory.
+type CandidateStore interface {
+	FindAll(ctx context.Context) ([]Candidate, error)
+	Insert(ctx context.Context, c Candidate) error
+	Update(ctx context.Context, c Candidate) error
+	Delete(ctx context.Context, id int) error
+}
+
+//CountryStore persists Country records.
+type CountryStore interface {
+	FindAll(ctx context.Context) ([]Country, error)
+	Insert(ctx context.Context, c Country) error
+	Update(ctx context.Context, c Country) error
+	Delete(ctx context.Context, id int) error
+}
+
+//JobRequisitionStore persists JobRequisition records.
+//Only the fields owned by the JobRequisition are persisted, JobReqCountry and Applicants are resolved in memory.
+type JobRequisitionStore interface {
+	FindAll(ctx context.Context) ([]JobRequisition, error)
+	Insert(ctx context.Context, jr JobRequisition) error
+	//Update writes jr over the record stored with the previous Version, jr.Version-1, or returns the error of staleRecord.
+	Update(ctx context.Context, jr JobRequisition) error
+	Delete(ctx context.Context, id int) error
+}
+
+//ApplicationStore persists Application records.
+type ApplicationStore interface {
+	FindAll(ctx context.Context) ([]Application, error)
+	Insert(ctx context.Context, a Application) error
+	Update(ctx context.Context, a Application) error
+	Delete(ctx context.Context, id int) error
+}
+
+//InterviewStore persists Interview records.
+type InterviewStore interface {
+	FindAll(ctx context.Context) ([]Interview, error)
+	Insert(ctx context.Context, i Interview) error
+	Update(ctx context.Context, i Interview) error
+	Delete(ctx context.Context, id int) error
+}
+
+//OfferStore persists Offer records.
+type OfferStore interface {
+	FindAll(ctx context.Context) ([]Offer, error)
+	Insert(ctx context.Context, o Offer) error
+	//Update writes o over the record stored with the previous Version, o.Version-1, or returns the error of staleRecord.
+	Update(ctx context.Context, o Offer) error
+	Delete(ctx context.Context, id int) error
+}
+
+//MergeStore persists the CandidateMerge audit records, which are never changed once written.
+type MergeStore interface {
+	FindAll(ctx context.Context) ([]CandidateMerge, error)
+	Insert(ctx context.Context, m CandidateMerge) error
+	Update(ctx context.Context, m CandidateMerge) error
+}
+
+//AttachmentStore persists the Attachment records, their content is kept on the ContentStore.
+type AttachmentStore interface {
+	FindAll(ctx context.Context) ([]Attachment, error)
+	Insert(ctx context.Context, a Attachment) error
+	Update(ctx context.Context, a Attachment) error
+	Delete(ctx context.Context, id int) error
+	//HasChecksum returns true while any Attachment stored has the checksum, whichever replica wrote it.
+	HasChecksum(ctx context.Context, checksum string) (bool, error)
+}
+
+//ContentStore keeps the content of the attachments, addressed by the checksum of the content.
+type ContentStore interface {
+	//Save keeps the content under key, doing nothing when the key is already kept.
+	Save(ctx context.Context, key string, content []byte) error
+	//Open returns the content kept under key, or a NotFoundError.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	//Remove discards the content kept under key, doing nothing when there is none.
+	Remove(ctx context.Context, key string) error
+}
+
+//TagStore persists Tag records.
+type TagStore interface {
+	FindAll(ctx context.Context) ([]Tag, error)
+	Insert(ctx context.Context, t Tag) error
+	Update(ctx context.Context, t Tag) error
+	Delete(ctx context.Context, id int) error
+}
+
+//SequenceStore allocates the IDs of new records.
+//Every call to Next returns a value never returned before for that sequence, even across replicas of the service.
+type SequenceStore interface {
+	//Seed makes sure the next value of the sequence is greater than min.
+	Seed(ctx context.Context, name string, min int) error
+	Next(ctx context.Context, name string) (int, error)
+}
+
+//Names of the sequences used to allocate IDs, one per entity.
+const (
+	candidateSequence      = "Candidates"
+	countrySequence        = "Countries"
+	jobRequisitionSequence = "Requisitions"
+	applicationSequence    = "Applications"
+	tagSequence            = "Tags"
+	interviewSequence      = "Interviews"
+	offerSequence          = "Offers"
+	mergeSequence          = "CandidateMerges"
+	attachmentSequence     = "Attachments"
+)
+
+//Change describes a record written on the stores, possibly by another replica of the service.
+type Change struct {
+	//Collection changed, named after its sequence.
+	Collection string
+	//Record as it was written, nil when it was deleted or when the store could not read it.
+	Record interface{}
+}
+
+//ChangeFeed is implemented by the stores able to push the changes made to them.
+type ChangeFeed interface {
+	//Watch calls apply for every change until ctx is cancelled or the feed fails.
+	Watch(ctx context.Context, apply func(Change)) error
+}
+
+//Stores groups the implementation of every store used by the models package.
+type Stores struct {
+	Candidates      CandidateStore
+	Countries       CountryStore
+	JobRequisitions JobRequisitionStore
+	Applications    ApplicationStore
+	Tags            TagStore
+	Interviews      InterviewStore
+	Offers          OfferStore
+	Merges          MergeStore
+	Attachments     AttachmentStore
+	//Chosen apart from the other stores, see NewFilesystemContentStore and NewGridFSContentStore.
+	Contents  ContentStore
+	Sequences SequenceStore
+	//Optional, when nil the cache is refreshed by polling the stores.
+	Changes ChangeFeed
+}
+
+var stores Stores
+
+//Init sets the stores used by the models package and loads every record in memory.
+//Returns an error if any of the stores could not be read.
+func Init(ctx context.Context, s Stores) error {
+	stores = s
+
+	for _, coll := range cachedCollections {
+		biggestId, err := syncCollection(ctx, coll)
+		if err != nil {
+			return err
+		}
+
+		//Records created before the sequences existed must never have their ID reused
+		if err = stores.Sequences.Seed(ctx, coll, biggestId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Returns the ConflictError of a write made from a record read before another write changed it,
+//on the stores of the records written by several requests at once, such as the JobRequisition filled by the offers.
+//The request fails rather than overwrite the other write, and may be made again on the record as it is now.
+func staleRecord(name string, id int) error {
+	return conflict("%v '%v' was changed by another request, try again", name, id)
+}
+
+//Returns the ConflictError that stops the service from starting while candidates applied more than once to the same requisition,
+//listing each of those pairs so the extra applications are merged or deleted before the unique index is created.
+func duplicateApplications(pairs []string) error {
+	return conflict("Unique index of Applications not created, %v candidates applied more than once to the same requisition, "+
+		"delete the extra applications and start again: %v", len(pairs), strings.Join(pairs, "; "))
+}
+
+//Allocates the ID of a new record from the sequence provided.
+//Returns an error if the sequence could not be incremented.
+func nextID(ctx context.Context, sequence string) (int, error) {
+	id, err := stores.Sequences.Next(ctx, sequence)
+	if err != nil {
+		return 0, unavailable("Could not allocate a new ID for %v", sequence)
+	}
+	return id, nil
+}
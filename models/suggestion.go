@@ -0,0 +1,118 @@
+package models
+
+import (
+	"context"
+
+	"webservice/resume"
+	"webservice/validate"
+)
+
+//ResumeDetails are the contact information and the skills found on the text of an Attachment.
+type ResumeDetails struct {
+	AttachmentID int
+	FileName     string
+	Emails       []string
+	Phones       []string
+	//Labels of the existing Tags written on the document.
+	Skills []string
+}
+
+//TagSuggestions are the Tags found on the attachments of a Candidate that the Candidate does not have yet.
+type TagSuggestions struct {
+	CandidateID   int
+	Attachments   []ResumeDetails
+	SuggestedTags []Tag
+}
+
+//In Memory: Parses the text of the attachments of the Candidate, matching the terms written on them against the existing Tags.
+//Returns the details found on each readable Attachment and the Tags to suggest, or an error in case the Candidate does not exist
+func GetTagSuggestions(candidateID int) (TagSuggestions, error) {
+	c, found := records.candidate(candidateID)
+	if !found {
+		return TagSuggestions{}, notFound("Candidate with id '%v' not found", candidateID)
+	}
+
+	has := make(map[int]bool)
+	for _, t := range c.Tags {
+		if _, id, err := ExistTagByLabel(t.Label); err == nil {
+			has[id] = true
+		}
+	}
+
+	ret := TagSuggestions{CandidateID: candidateID, Attachments: make([]ResumeDetails, 0), SuggestedTags: make([]Tag, 0)}
+	for _, a := range records.attachmentsOfCandidate(candidateID) {
+		if a.Text == "" {
+			continue
+		}
+
+		parsed := resume.Parse(a.Text)
+		details := ResumeDetails{AttachmentID: a.ID, FileName: a.FileName, Emails: parsed.Emails, Phones: parsed.Phones, Skills: make([]string, 0)}
+		seen := make(map[int]bool)
+		for _, term := range parsed.Terms {
+			t, found := matchTag(term)
+			if !found || seen[t.ID] {
+				continue
+			}
+			seen[t.ID] = true
+			details.Skills = append(details.Skills, t.Label)
+
+			if !has[t.ID] {
+				has[t.ID] = true
+				ret.SuggestedTags = append(ret.SuggestedTags, t)
+			}
+		}
+		ret.Attachments = append(ret.Attachments, details)
+	}
+	return ret, nil
+}
+
+//Returns the Tag labelled as the term, by its label or one of its synonyms regardless of case.
+func matchTag(term string) (Tag, bool) {
+	t, err := GetTagByLabel(term)
+	if err != nil {
+		return Tag{}, false
+	}
+	return Tag{ID: t.ID, Label: t.Label}, true
+}
+
+//In DB: Adds to the Candidate the suggested Tags with the labels provided.
+//Returns the updated Candidate, and an error in case a label is not one of the Tags suggested for the Candidate
+func AcceptSuggestedTags(ctx context.Context, candidateID int, labels []string) (Candidate, error) {
+	s, err := GetTagSuggestions(candidateID)
+	if err != nil {
+		return Candidate{}, err
+	}
+
+	suggested := make(map[string]Tag)
+	for _, t := range s.SuggestedTags {
+		suggested[t.Label] = t
+	}
+
+	var v validate.Validator
+	v.Check(len(labels) > 0, "Labels", "should not be empty")
+	for i, l := range labels {
+		_, found := suggested[l]
+		v.Check(found, "Labels", "'%v' at position %v is not a suggested Tag", l, i)
+	}
+	if err := invalid("Suggested Tags are not valid", &v); err != nil {
+		return Candidate{}, err
+	}
+
+	c, err := GetCandidateByID(candidateID)
+	if err != nil {
+		return Candidate{}, err
+	}
+	for _, l := range labels {
+		if t, found := suggested[l]; found {
+			c.Tags = append(c.Tags, t)
+			//A label sent twice is added once
+			delete(suggested, l)
+		}
+	}
+	//Only the Tags change, the rest of the Candidate is written as it is stored rather than validated again,
+	//so a Candidate saved before a rule existed can still receive its Tags
+	if err := updateCandidateTags(ctx, c); err != nil {
+		return Candidate{}, err
+	}
+	return GetCandidateByID(candidateID)
+}
@@ -0,0 +1,44 @@
+package models
+
+import "testing"
+
+//Accepting the suggested Tags only changes the Tags, a Candidate no longer valid as a whole still receives them.
+func TestAcceptSuggestedTags(t *testing.T) {
+	ctx := initMemoryStores(t)
+	goTag := addTestTag(t, ctx, "Go", 0)
+	c := addTestCandidate(t, ctx, "Jane", "Doe")
+
+	cv := Attachment{ID: 1, CandidateID: c.ID, Kind: AttachmentResume, FileName: "cv.txt", Checksum: "cv", Text: "Go developer"}
+	if err := stores.Attachments.Insert(ctx, cv); err != nil {
+		t.Fatal(err)
+	}
+	records.putAttachment(cv)
+
+	//The country of the Candidate was removed since, so UpdateCandidate would reject it
+	if err := stores.Countries.Delete(ctx, c.CanCountryId); err != nil {
+		t.Fatal(err)
+	}
+	records.removeCountry(c.CanCountryId)
+
+	_, err := AcceptSuggestedTags(ctx, c.ID, []string{"Rust"})
+	wantError[*ValidationError](t, err)
+
+	got, err := AcceptSuggestedTags(ctx, c.ID, []string{"Go", "Go"})
+	if err != nil {
+		t.Fatalf("AcceptSuggestedTags() error = %v", err)
+	}
+	if len(got.Tags) != 1 || got.Tags[0].ID != goTag.ID {
+		t.Errorf("Tags = %+v, want [%v]", got.Tags, goTag.Label)
+	}
+	if got.FirstName != c.FirstName || got.Email != c.Email || got.CanCountryId != c.CanCountryId {
+		t.Errorf("Candidate = %+v, want only the Tags changed", got)
+	}
+
+	s, err := GetTagSuggestions(c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.SuggestedTags) != 0 {
+		t.Errorf("SuggestedTags = %+v, want none once accepted", s.SuggestedTags)
+	}
+}
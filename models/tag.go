@@ -3,141 +3,381 @@ package models
 import (
 	"context"
 	"fmt"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo/options"
-	"webservice/db"
+	"strings"
+
+	"webservice/validate"
 )
 
 type Tag struct {
 	ID		int
-	Label 	string
+	Label 	string	`validate:"required"`
+	//Other labels of the same Tag, such as golang for Go, resolved to it regardless of case.
+	Synonyms []string `json:",omitempty" bson:",omitempty"`
+	//Broader Tag this one is part of, such as Backend for Go. Searching for the parent also finds the children.
+	ParentID int `json:",omitempty" bson:",omitempty"`
 }
 
-var (
-	tags 		[]*Tag
-	nextTagID 	= updateTagsInMemory()
-)
+//Returns the key a label is matched by, so "Go", "go" and " GO " are the same Tag.
+func tagKey(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+//Returns the Tag with its labels trimmed, and without the synonyms repeating the label or each other.
+func cleanTag(t Tag) Tag {
+	t.Label = strings.Join(strings.Fields(t.Label), " ")
+
+	seen := map[string]bool{tagKey(t.Label): true}
+	var synonyms []string
+	for _, s := range t.Synonyms {
+		s = strings.Join(strings.Fields(s), " ")
+		//Empty synonyms are kept for the validation to reject them
+		if s != "" && seen[tagKey(s)] {
+			continue
+		}
+		seen[tagKey(s)] = true
+		synonyms = append(synonyms, s)
+	}
+	t.Synonyms = synonyms
+	return t
+}
+
+//Returns whether the Tag a Candidate carries is the Tag t, by its ID or, for the ones stored without it, by one of its labels.
+func isTag(carried Tag, t Tag) bool {
+	if carried.ID != 0 && carried.ID == t.ID {
+		return true
+	}
+	key := tagKey(carried.Label)
+	if key == tagKey(t.Label) {
+		return true
+	}
+	for _, s := range t.Synonyms {
+		if key == tagKey(s) {
+			return true
+		}
+	}
+	return false
+}
+
+//Returns the Tags of a Candidate with the Tag old replaced by the Tag with, or removed when with is nil.
+//A Tag the Candidate would carry twice is kept once.
+func replaceTag(tags []Tag, old Tag, with *Tag) []Tag {
+	ret := make([]Tag, 0, len(tags))
+	seen := make(map[int]bool)
+	for _, t := range tags {
+		if isTag(t, old) {
+			if with == nil {
+				continue
+			}
+			t = Tag{ID: with.ID, Label: with.Label}
+		}
+		if t.ID != 0 && seen[t.ID] {
+			continue
+		}
+		seen[t.ID] = true
+		ret = append(ret, t)
+	}
+	return ret
+}
 
 //In Memory: Returns the complete list of tags that has been.
 //Returns a hashmap containing the list of tags
 func GetTags() []*Tag{
-	return tags
+	tagArr := make([]*Tag, 0)
+	for _, v := range records.allTags() {
+		v := v
+		tagArr = append(tagArr, &v)
+	}
+	return tagArr
+}
+
+//TagUsage is a Tag with the number of Candidates carrying it.
+type TagUsage struct {
+	Tag
+	CandidateCount int
+}
+
+//In Memory: Returns every Tag with the number of Candidates carrying it.
+//Returns a slice of TagUsage, in the order they were created
+func GetTagsWithUsage() []TagUsage {
+	usage := records.tagUsage()
+	ret := make([]TagUsage, 0)
+	for _, t := range records.allTags() {
+		ret = append(ret, TagUsage{Tag: t, CandidateCount: usage[t.ID]})
+	}
+	return ret
 }
 
-//In Memory: Finds the corresponding tag on the list of tags.
+//In Memory: Searches for a specific Tag, with the number of Candidates carrying it.
+//Returns a TagUsage object and an error in case it was not possible to find the record
+func GetTagByID(id int) (TagUsage, error) {
+	t, found := records.tag(id)
+	if !found {
+		return TagUsage{}, notFound("Tag with ID '%v' not found", id)
+	}
+	return TagUsage{Tag: t, CandidateCount: len(records.candidatesWithTag(id))}, nil
+}
+
+//In Memory: Finds the corresponding tag on the list of tags, by its label or one of its synonyms regardless of case.
 //Returns the specific tag found, or an error message
 func GetTagByLabel(l string) (Tag, error) {
-	b,i,e := ExistTagByLabel(l)
-	if b {
-		return *tags[i], nil
+	if t, found := records.tagByLabel(l); found {
+		return t, nil
 	}
 
-	return Tag{}, e
+	return Tag{}, notFound("Tag '%v' not found", l)
+}
+
+//In Memory: Completes the query typed on a search box, each word being the prefix of a word of the label.
+//Words are matched regardless of case and accents. Returns up to limit Tags, the closest matches first
+func SuggestTags(query string, limit int) []Tag {
+	return records.suggestTags(query, limit)
 }
 
 //In DB: Creates a new recod of Tag into the Database.
 //Returns the Tag object, and error if not possible to create
-func AddTag(t Tag) (Tag, error) {
+func AddTag(ctx context.Context, t Tag) (Tag, error) {
+	t = cleanTag(t)
+
 	//Validation
-	if t.ID != 0 {
-		return Tag{}, fmt.Errorf("Tag must not contain ID")
-	}
-	if t.Label == "" {
-		return Tag{}, fmt.Errorf("Tag label must not be empty")
+	var v validate.Validator
+	v.Check(t.ID == 0, "ID", "Tag must not contain ID")
+	v.Struct(t)
+	if err := invalid("Tag is not valid", &v); err != nil {
+		return Tag{}, err
 	}
 
 	//Test if tag already exists
-	b,i,e := ExistTagByLabel(t.Label)
-	if b {
-		return *tags[i], e
+	if existing, found := records.tagByLabel(t.Label); found {
+		return existing, nil
 	}
 
 	//Add new tag
-	t.ID = nextTagID
-	nextTagID++
-	tags = append(tags, &t)
-
-	//Validate if able to connect to MongoDB
-	client, err := db.OpenConnectionToMongo()
+	id, err := nextID(ctx, tagSequence)
 	if err != nil {
-		return Tag{}, fmt.Errorf("Could not establish connection to Database")
+		return Tag{}, err
 	}
+	t.ID = id
 
+	//Insert information into the Database
+	if err := stores.Tags.Insert(ctx, t); err != nil {
+		return Tag{}, err
+	}
 
-	coll := client.Database(db.GetDatabaseName()).Collection("Tags")
-	doc := bson.D{{"ID", nextTagID},{"Label", t.Label}}
+	records.putTag(t)
 
-	//Insert information into Mongo DB
-	_, err = coll.InsertOne(context.TODO(), doc)
-	if err != nil {
-		return Tag{}, fmt.Errorf("Could not insert Tag provided into the Database")
-	}
+	return t,nil
+}
 
-	defer db.CloseConnectionToMongo(client)
+//In DB: Creates a new Tag, unlike AddTag a label or synonym already in use is rejected.
+//Returns the Tag object, and error if the Tag is not valid or a label already exists
+func CreateTag(ctx context.Context, t Tag) (Tag, error) {
+	t = cleanTag(t)
+	if err := checkTag(t); err != nil {
+		return Tag{}, err
+	}
+	return AddTag(ctx, t)
+}
 
-	updateTagsInMemory()
+//Checks the labels and the parent of a Tag being created or updated against the other Tags.
+func checkTag(t Tag) error {
+	var v validate.Validator
+	v.Struct(t)
+	for i, s := range t.Synonyms {
+		field := fmt.Sprintf("Synonyms[%v]", i)
+		v.Check(s != "", field, "should not be empty")
+		v.Check(len(s) <= 255, field, "should have at most 255 characters")
+	}
+	if t.ParentID != 0 {
+		_, found := records.tag(t.ParentID)
+		v.Check(found, "ParentID", "Tag with ID '%v' not found", t.ParentID)
+		v.Check(t.ParentID != t.ID, "ParentID", "must not be the ID of the Tag")
+		v.Check(t.ID == 0 || !records.isTagAncestor(t.ID, t.ParentID), "ParentID", "Tag with ID '%v' is a child of the Tag", t.ParentID)
+	}
+	if err := invalid("Tag is not valid", &v); err != nil {
+		return err
+	}
 
-	return t,nil
+	for _, l := range append([]string{t.Label}, t.Synonyms...) {
+		if existing, found := records.tagByLabel(l); found && existing.ID != t.ID {
+			return conflict("Tag '%v' already exists with ID '%v'", l, existing.ID)
+		}
+	}
+	return nil
 }
 
-//Return if a tag exists on the list
-func ExistTagByLabel(l string) (bool, int, error) {
-	for i,t := range tags {
-		if t.Label == l{
-			return true,i,nil
+//In DB: Changes the label, synonyms and parent of a Tag, the new label being written on every Candidate carrying it.
+//Returns the updated Tag, and error if the Tag is not valid or a label belongs to another Tag
+func UpdateTag(ctx context.Context, t Tag) (TagUsage, error) {
+	t = cleanTag(t)
+
+	old, found := records.tag(t.ID)
+	if !found {
+		return TagUsage{}, notFound("Tag with ID '%v' not found", t.ID)
+	}
+	if err := checkTag(t); err != nil {
+		return TagUsage{}, err
+	}
+
+	//Read before the update, the candidates stored without the ID are found by the old labels
+	carrying := records.candidatesWithTag(t.ID)
+	requiring := records.jobRequisitionsWithTag(t.ID)
+
+	if err := stores.Tags.Update(ctx, t); err != nil {
+		return TagUsage{}, err
+	}
+	records.putTag(t)
+
+	for _, c := range carrying {
+		c.Tags = replaceTag(c.Tags, old, &t)
+		if err := updateCandidateTags(ctx, c); err != nil {
+			return TagUsage{}, err
 		}
 	}
-	return false, -1, fmt.Errorf("Tag '%v' not found", l)
+	if err := replaceRequisitionTag(ctx, requiring, old, &t); err != nil {
+		return TagUsage{}, err
+	}
+	return TagUsage{Tag: t, CandidateCount: len(carrying)}, nil
 }
 
-//Updates the hashmap containing all the tags to work with them in memory.
-//Return the next ID to be added into the Database
-func updateTagsInMemory() int {
-	client, err := db.OpenConnectionToMongo()
+//In DB: Merges the source Tag into the target one, the labels of the source becoming synonyms of the target.
+//The Candidates and child Tags of the source are moved to the target, and the source is removed.
+//A target under the source is moved up to the parent of the source first.
+//Returns the target Tag, and error if any of the Tags does not exist
+func MergeTags(ctx context.Context, targetID int, sourceID int) (TagUsage, error) {
+	var v validate.Validator
+	v.Check(targetID != 0, "TargetID", "should be populated")
+	v.Check(sourceID != 0, "SourceID", "should be populated")
+	v.Check(targetID != sourceID, "SourceID", "must not be the TargetID")
+	if err := invalid("Merge is not valid", &v); err != nil {
+		return TagUsage{}, err
+	}
 
-	if err != nil {
-		return -1
+	target, found := records.tag(targetID)
+	if !found {
+		return TagUsage{}, notFound("Tag with ID '%v' not found", targetID)
+	}
+	source, found := records.tag(sourceID)
+	if !found {
+		return TagUsage{}, notFound("Tag with ID '%v' not found", sourceID)
 	}
 
-	filter := bson.D{}
-	projection := bson.D{
-		{"ID", 1},
-		{"Label" ,1}}
-	opts := options.Find().SetProjection(projection)
+	//Read before the merge, the candidates stored without the ID are found by the labels of the source
+	carrying := records.candidatesWithTag(sourceID)
+	requiring := records.jobRequisitionsWithTag(sourceID)
 
-	coll := client.Database(db.GetDatabaseName()).Collection("Tags")
-	cursor, err := coll.Find(context.TODO(), filter, opts)
+	target.Synonyms = append(append(append([]string(nil), target.Synonyms...), source.Label), source.Synonyms...)
+	target = cleanTag(target)
+	//A target under the source takes its place under the parent of the source,
+	//else re-parenting the children of the source to the target would make a cycle
+	if target.ParentID != 0 && records.isTagAncestor(sourceID, target.ParentID) {
+		target.ParentID = source.ParentID
+	}
+	if err := stores.Tags.Update(ctx, target); err != nil {
+		return TagUsage{}, err
+	}
+	records.putTag(target)
 
-	var results []bson.D
+	for _, c := range carrying {
+		c.Tags = replaceTag(c.Tags, source, &target)
+		if err := updateCandidateTags(ctx, c); err != nil {
+			return TagUsage{}, err
+		}
+	}
+	if err := replaceRequisitionTag(ctx, requiring, source, &target); err != nil {
+		return TagUsage{}, err
+	}
 
-	if err = cursor.All(context.TODO(), &results); err != nil {
-		panic(err)
+	for _, child := range records.childTags(sourceID) {
+		child.ParentID = targetID
+		if err := stores.Tags.Update(ctx, child); err != nil {
+			return TagUsage{}, err
+		}
+		records.putTag(child)
 	}
 
-	defer db.CloseConnectionToMongo(client)
+	if err := stores.Tags.Delete(ctx, sourceID); err != nil {
+		return TagUsage{}, err
+	}
+	records.removeTag(sourceID)
 
-	biggestId := 1
-	tags = make([]*Tag,0)
-	for _, v := range results {
-		t := bsonToTag(v)
-		tags = append(tags, &t)
+	return GetTagByID(targetID)
+}
+
+//In DB: Removes a Tag, and removes it from every Candidate carrying it and every JobRequisition asking for it.
+//Its child Tags take its place under its parent.
+//Returns error if failed to complete the deletion on the DB
+func DeleteTag(ctx context.Context, id int) error {
+	t, found := records.tag(id)
+	if !found {
+		return notFound("Tag with ID '%v' not found", id)
+	}
 
-		if t.ID > biggestId {
-			biggestId = t.ID
+	//Candidates are updated first, so a failure leaves no Candidate pointing at a deleted Tag
+	for _, c := range records.candidatesWithTag(id) {
+		c.Tags = replaceTag(c.Tags, t, nil)
+		if err := updateCandidateTags(ctx, c); err != nil {
+			return err
 		}
 	}
+	if err := replaceRequisitionTag(ctx, records.jobRequisitionsWithTag(id), t, nil); err != nil {
+		return err
+	}
 
-	return biggestId
+	for _, child := range records.childTags(id) {
+		child.ParentID = t.ParentID
+		if err := stores.Tags.Update(ctx, child); err != nil {
+			return err
+		}
+		records.putTag(child)
+	}
+
+	if err := stores.Tags.Delete(ctx, id); err != nil {
+		return err
+	}
+	records.removeTag(id)
+	return nil
 }
 
-//Receives a bson object to execute the conversion.
-//Returns a Tag object.
-func bsonToTag(v bson.D) Tag {
-	bsonBytes, _ := bson.Marshal(v)
+//Writes the Tags of a Candidate changed by an update, a merge or a deletion, which need no validation.
+func updateCandidateTags(ctx context.Context, c Candidate) error {
+	if err := stores.Candidates.Update(ctx, c); err != nil {
+		return err
+	}
+	records.putCandidate(c)
+	return nil
+}
 
-	var t Tag
-	//deconvert the byarray into a struct object
-	bson.Unmarshal(bsonBytes, &t)
+//Replaces the Tag old on the required and preferred Tags of the requisitions, as replaceTag does on a Candidate.
+//A Tag both required and preferred afterwards is only kept as required.
+func replaceRequisitionTag(ctx context.Context, requiring []JobRequisition, old Tag, with *Tag) error {
+	for _, jr := range requiring {
+		jr.RequiredTags = replaceTag(jr.RequiredTags, old, with)
+		required := make(map[int]bool)
+		for _, t := range jr.RequiredTags {
+			required[t.ID] = true
+		}
 
-	return t
-}
\ No newline at end of file
+		preferred := replaceTag(jr.PreferredTags, old, with)
+		jr.PreferredTags = make([]Tag, 0, len(preferred))
+		for _, t := range preferred {
+			if !required[t.ID] {
+				jr.PreferredTags = append(jr.PreferredTags, t)
+			}
+		}
+		jr.Version++
+
+		if err := stores.JobRequisitions.Update(ctx, jr); err != nil {
+			return err
+		}
+		records.putJobRequisition(jr)
+	}
+	return nil
+}
+
+//Return if a tag exists on the list, along with its ID
+func ExistTagByLabel(l string) (bool, int, error) {
+	if t, found := records.tagByLabel(l); found {
+		return true, t.ID, nil
+	}
+	return false, -1, notFound("Tag '%v' not found", l)
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+//Creates a Tag under the parent provided, 0 for a root Tag.
+func addTestTag(t *testing.T, ctx context.Context, label string, parentID int) Tag {
+	t.Helper()
+	tag, err := CreateTag(ctx, Tag{Label: label, ParentID: parentID})
+	if err != nil {
+		t.Fatalf("CreateTag(%q) error = %v", label, err)
+	}
+	return tag
+}
+
+func parentOf(t *testing.T, id int) int {
+	t.Helper()
+	tag, found := records.tag(id)
+	if !found {
+		t.Fatalf("Tag %v not found", id)
+	}
+	return tag.ParentID
+}
+
+func TestMergeTags(t *testing.T) {
+	ctx := initMemoryStores(t)
+	backend := addTestTag(t, ctx, "Backend", 0)
+	golang := addTestTag(t, ctx, "Golang", backend.ID)
+	gin := addTestTag(t, ctx, "Gin", golang.ID)
+	goTag := addTestTag(t, ctx, "Go", 0)
+
+	merged, err := MergeTags(ctx, goTag.ID, golang.ID)
+	if err != nil {
+		t.Fatalf("MergeTags() error = %v", err)
+	}
+	if want := []string{"Golang"}; !reflect.DeepEqual(merged.Synonyms, want) {
+		t.Errorf("Synonyms = %q, want %q", merged.Synonyms, want)
+	}
+	if _, found := records.tag(golang.ID); found {
+		t.Errorf("source Tag %v was not removed", golang.ID)
+	}
+	if got := parentOf(t, gin.ID); got != goTag.ID {
+		t.Errorf("child ParentID = %v, want the target %v", got, goTag.ID)
+	}
+	if got, err := GetTagByLabel("golang"); err != nil || got.ID != goTag.ID {
+		t.Errorf("GetTagByLabel(golang) = %v, %v, want the target", got.ID, err)
+	}
+}
+
+func TestMergeTagsUnderSource(t *testing.T) {
+	tests := []struct {
+		name  string
+		depth int
+	}{
+		{"child", 1},
+		{"grandchild", 2},
+		{"great-grandchild", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := initMemoryStores(t)
+			root := addTestTag(t, ctx, "Engineering", 0)
+			source := addTestTag(t, ctx, "Backend", root.ID)
+			sibling := addTestTag(t, ctx, "APIs", source.ID)
+			target := source
+			for i := 0; i < tt.depth; i++ {
+				target = addTestTag(t, ctx, "Level "+string(rune('A'+i)), target.ID)
+			}
+
+			if _, err := MergeTags(ctx, target.ID, source.ID); err != nil {
+				t.Fatalf("MergeTags() error = %v", err)
+			}
+			if got := parentOf(t, target.ID); got != root.ID {
+				t.Errorf("target ParentID = %v, want the parent of the source %v", got, root.ID)
+			}
+			if got := parentOf(t, sibling.ID); got != target.ID {
+				t.Errorf("child ParentID = %v, want the target %v", got, target.ID)
+			}
+
+			//Every Tag still reaches the root, none of them is part of a cycle
+			for _, tag := range records.allTags() {
+				if !records.isTagAncestor(root.ID, tag.ID) {
+					t.Errorf("Tag %q is detached from the root", tag.Label)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeTagsInvalid(t *testing.T) {
+	ctx := initMemoryStores(t)
+	goTag := addTestTag(t, ctx, "Go", 0)
+
+	_, err := MergeTags(ctx, goTag.ID, goTag.ID)
+	wantError[*ValidationError](t, err)
+	_, err = MergeTags(ctx, goTag.ID, goTag.ID+100)
+	wantError[*NotFoundError](t, err)
+}
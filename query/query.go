@@ -0,0 +1,393 @@
+package query
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+//Parameters of the query string with a meaning of their own, every other parameter is a field filter.
+var reserved = map[string]bool{
+	"limit":  true,
+	"offset": true,
+	"cursor": true,
+	"sort":   true,
+	//Text searched by the search endpoints
+	"q": true,
+}
+
+//Query describes the page, order and filters requested on a collection endpoint.
+type Query struct {
+	Limit   int
+	Offset  int
+	Sort    []SortField
+	Filters []Filter
+}
+
+//SortField orders the results by the field on Path, which may be nested (CountryObj.Name).
+type SortField struct {
+	Path string
+	Desc bool
+}
+
+//Filter keeps the results whose field on Path matches any of the Values.
+//When the path goes through a slice (Tags.Label) it is enough for one of the elements to match.
+type Filter struct {
+	Path   string
+	Values []string
+}
+
+//Page is the response of a collection endpoint.
+type Page struct {
+	Items      interface{}
+	Total      int
+	Limit      int
+	Offset     int
+	NextCursor string `json:",omitempty"`
+	Next       string `json:",omitempty"`
+	Prev       string `json:",omitempty"`
+}
+
+//Parse reads the query from the query string:
+//limit and offset or cursor select the page, sort=Field,-Field the order and any other parameter is a field filter.
+//Returns an error describing the first invalid parameter.
+func Parse(values url.Values) (Query, error) {
+	q := Query{Limit: DefaultLimit}
+
+	if v := values.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return Query{}, fmt.Errorf("Limit must be a positive number")
+		}
+		if n > MaxLimit {
+			n = MaxLimit
+		}
+		q.Limit = n
+	}
+
+	if v := values.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return Query{}, fmt.Errorf("Offset must be zero or a positive number")
+		}
+		q.Offset = n
+	}
+
+	if v := values.Get("cursor"); v != "" {
+		n, err := decodeCursor(v)
+		if err != nil {
+			return Query{}, err
+		}
+		q.Offset = n
+	}
+
+	if v := values.Get("sort"); v != "" {
+		for _, f := range strings.Split(v, ",") {
+			f = strings.TrimSpace(f)
+			if f == "" {
+				continue
+			}
+			sf := SortField{Path: f}
+			if strings.HasPrefix(f, "-") {
+				sf = SortField{Path: f[1:], Desc: true}
+			} else if strings.HasPrefix(f, "+") {
+				sf.Path = f[1:]
+			}
+			q.Sort = append(q.Sort, sf)
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		if !reserved[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		q.Filters = append(q.Filters, Filter{Path: k, Values: values[k]})
+	}
+
+	return q, nil
+}
+
+//Apply filters, sorts and paginates items, which must be a slice of structs or of pointers to structs.
+//The links of the page are built from u, the URL of the request.
+//Returns an error if a sort field or filter does not exist on the items.
+func (q Query) Apply(items interface{}, u *url.URL) (Page, error) {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice {
+		return Page{}, fmt.Errorf("Items must be a slice")
+	}
+	elemType := v.Type().Elem()
+
+	for _, f := range q.Filters {
+		if _, err := fieldType(elemType, f.Path); err != nil {
+			return Page{}, err
+		}
+	}
+	for _, sf := range q.Sort {
+		t, err := fieldType(elemType, sf.Path)
+		if err != nil {
+			return Page{}, err
+		}
+		if !isScalar(t) {
+			return Page{}, fmt.Errorf("Cannot sort by %v", sf.Path)
+		}
+	}
+
+	matched := reflect.MakeSlice(v.Type(), 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		item := v.Index(i)
+		ok, err := q.matches(item)
+		if err != nil {
+			return Page{}, err
+		}
+		if ok {
+			matched = reflect.Append(matched, item)
+		}
+	}
+
+	if len(q.Sort) > 0 {
+		sort.SliceStable(matched.Interface(), func(i, j int) bool {
+			return q.less(matched.Index(i), matched.Index(j))
+		})
+	}
+
+	total := matched.Len()
+	start := q.Offset
+	if start > total {
+		start = total
+	}
+	end := start + q.Limit
+	if end > total {
+		end = total
+	}
+
+	page := Page{
+		Items:  matched.Slice(start, end).Interface(),
+		Total:  total,
+		Limit:  q.Limit,
+		Offset: start,
+	}
+
+	if end < total {
+		page.NextCursor = encodeCursor(end)
+		page.Next = link(u, end, q.Limit)
+	}
+	if start > 0 {
+		prev := start - q.Limit
+		if prev < 0 {
+			prev = 0
+		}
+		page.Prev = link(u, prev, q.Limit)
+	}
+	return page, nil
+}
+
+//Returns true when the item matches every filter of the query.
+func (q Query) matches(item reflect.Value) (bool, error) {
+	for _, f := range q.Filters {
+		found := false
+		for _, field := range fieldValues(item, strings.Split(f.Path, ".")) {
+			for _, want := range f.Values {
+				ok, err := equals(field, want)
+				if err != nil {
+					return false, fmt.Errorf("Invalid value for %v: %v", f.Path, err)
+				}
+				if ok {
+					found = true
+				}
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (q Query) less(a, b reflect.Value) bool {
+	for _, sf := range q.Sort {
+		path := strings.Split(sf.Path, ".")
+		av, bv := fieldValues(a, path), fieldValues(b, path)
+		if len(av) == 0 || len(bv) == 0 {
+			continue
+		}
+
+		c := compare(av[0], bv[0])
+		if c == 0 {
+			continue
+		}
+		if sf.Desc {
+			return c > 0
+		}
+		return c < 0
+	}
+	return false
+}
+
+//Returns the type of the field on path, following pointers and slices.
+func fieldType(t reflect.Type, path string) (reflect.Type, error) {
+	for _, name := range strings.Split(path, ".") {
+		for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("Unknown field %v", path)
+		}
+		f, ok := t.FieldByName(name)
+		if !ok || f.PkgPath != "" {
+			return nil, fmt.Errorf("Unknown field %v", path)
+		}
+		t = f.Type
+	}
+	return t, nil
+}
+
+//Returns every value found on path, one per element when the path goes through slices.
+func fieldValues(v reflect.Value, path []string) []reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() == reflect.Slice {
+		var ret []reflect.Value
+		for i := 0; i < v.Len(); i++ {
+			ret = append(ret, fieldValues(v.Index(i), path)...)
+		}
+		return ret
+	}
+
+	if len(path) == 0 {
+		return []reflect.Value{v}
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	return fieldValues(v.FieldByName(path[0]), path[1:])
+}
+
+func isScalar(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+//Compares the field with the value received on the query string, converted to the type of the field.
+//Strings are compared ignoring case.
+func equals(field reflect.Value, want string) (bool, error) {
+	switch field.Kind() {
+	case reflect.String:
+		return strings.EqualFold(field.String(), want), nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(want)
+		if err != nil {
+			return false, fmt.Errorf("%q is not a boolean", want)
+		}
+		return field.Bool() == b, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(want, 10, 64)
+		if err != nil {
+			return false, fmt.Errorf("%q is not a number", want)
+		}
+		return field.Int() == n, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(want, 10, 64)
+		if err != nil {
+			return false, fmt.Errorf("%q is not a positive number", want)
+		}
+		return field.Uint() == n, nil
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(want, 64)
+		if err != nil {
+			return false, fmt.Errorf("%q is not a number", want)
+		}
+		return field.Float() == n, nil
+	}
+	return fmt.Sprint(field.Interface()) == want, nil
+}
+
+//Returns -1, 0 or 1 comparing two scalar values of the same kind.
+func compare(a, b reflect.Value) int {
+	switch a.Kind() {
+	case reflect.String:
+		return strings.Compare(strings.ToLower(a.String()), strings.ToLower(b.String()))
+	case reflect.Bool:
+		if a.Bool() == b.Bool() {
+			return 0
+		}
+		if !a.Bool() {
+			return -1
+		}
+		return 1
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return compareOrdered(float64(a.Int()), float64(b.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return compareOrdered(float64(a.Uint()), float64(b.Uint()))
+	case reflect.Float32, reflect.Float64:
+		return compareOrdered(a.Float(), b.Float())
+	}
+	return 0
+}
+
+func compareOrdered(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+//Cursors are opaque to the clients, they only carry the offset of the next page.
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("o:" + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(b), "o:") {
+		return 0, fmt.Errorf("Invalid cursor")
+	}
+	n, err := strconv.Atoi(string(b[2:]))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("Invalid cursor")
+	}
+	return n, nil
+}
+
+//Returns the URL of the request pointing to another page, keeping the sort and filters.
+func link(u *url.URL, offset int, limit int) string {
+	if u == nil {
+		return ""
+	}
+	values := u.Query()
+	values.Del("offset")
+	values.Set("cursor", encodeCursor(offset))
+	values.Set("limit", strconv.Itoa(limit))
+
+	next := url.URL{Path: u.Path, RawQuery: values.Encode()}
+	return next.String()
+}
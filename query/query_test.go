@@ -0,0 +1,161 @@
+package query
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query string
+		want  Query
+	}{
+		{"", Query{Limit: DefaultLimit}},
+		{"limit=10&offset=20", Query{Limit: 10, Offset: 20}},
+		{"limit=100000", Query{Limit: MaxLimit}},
+		{"cursor=" + encodeCursor(40) + "&offset=5", Query{Limit: DefaultLimit, Offset: 40}},
+		{"sort=LastName,-ID,+FirstName,", Query{Limit: DefaultLimit, Sort: []SortField{{Path: "LastName"}, {Path: "ID", Desc: true}, {Path: "FirstName"}}}},
+		{"q=go&Tags.Label=Go&Tags.Label=Rust&CanCountryId=1", Query{Limit: DefaultLimit, Filters: []Filter{
+			{Path: "CanCountryId", Values: []string{"1"}},
+			{Path: "Tags.Label", Values: []string{"Go", "Rust"}},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			got, err := Parse(values)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"limit=0", "Limit must be a positive number"},
+		{"limit=ten", "Limit must be a positive number"},
+		{"offset=-1", "Offset must be zero or a positive number"},
+		{"cursor=bm90IGEgY3Vyc29y", "Invalid cursor"},
+		{"cursor=" + encodeCursor(-1), "Invalid cursor"},
+		{"cursor=%%%", "Invalid cursor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			values := url.Values{}
+			if k, v, found := strings.Cut(tt.query, "="); found {
+				values.Set(k, v)
+			}
+			if _, err := Parse(values); err == nil || err.Error() != tt.want {
+				t.Errorf("Parse() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+type tag struct {
+	Label string
+}
+
+type candidate struct {
+	ID       int
+	Name     string
+	Active   bool
+	Tags     []tag
+	internal string
+}
+
+func TestApply(t *testing.T) {
+	items := []*candidate{
+		{ID: 1, Name: "jane", Active: true, Tags: []tag{{"Go"}, {"Rust"}}},
+		{ID: 2, Name: "John", Active: false, Tags: []tag{{"Java"}}},
+		{ID: 3, Name: "Ana", Active: true, Tags: []tag{{"go"}}},
+	}
+	tests := []struct {
+		query string
+		want  []int
+		total int
+	}{
+		{"", []int{1, 2, 3}, 3},
+		{"sort=Name", []int{3, 1, 2}, 3},
+		{"sort=-Active,-ID", []int{3, 1, 2}, 3},
+		{"Tags.Label=GO", []int{1, 3}, 2},
+		{"Active=true&Tags.Label=rust", []int{1}, 1},
+		{"ID=2&ID=3", []int{2, 3}, 2},
+		{"limit=1&offset=1", []int{2}, 3},
+		{"offset=10", []int{}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			q, err := Parse(values)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			page, err := q.Apply(items, &url.URL{Path: "/candidate", RawQuery: tt.query})
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			got := []int{}
+			for _, c := range page.Items.([]*candidate) {
+				got = append(got, c.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) || page.Total != tt.total {
+				t.Errorf("Apply() = %v of %v, want %v of %v", got, page.Total, tt.want, tt.total)
+			}
+		})
+	}
+}
+
+func TestApplyLinks(t *testing.T) {
+	items := []candidate{{ID: 1}, {ID: 2}, {ID: 3}}
+	q := Query{Limit: 1, Offset: 1}
+	page, err := q.Apply(items, &url.URL{Path: "/candidate", RawQuery: "offset=1&limit=1&sort=ID"})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if page.NextCursor != encodeCursor(2) {
+		t.Errorf("NextCursor = %q, want %q", page.NextCursor, encodeCursor(2))
+	}
+	if want := "/candidate?cursor=" + encodeCursor(2) + "&limit=1&sort=ID"; page.Next != want {
+		t.Errorf("Next = %q, want %q", page.Next, want)
+	}
+	if want := "/candidate?cursor=" + encodeCursor(0) + "&limit=1&sort=ID"; page.Prev != want {
+		t.Errorf("Prev = %q, want %q", page.Prev, want)
+	}
+}
+
+func TestApplyInvalid(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"sort=Salary", "Unknown field Salary"},
+		{"sort=Tags", "Cannot sort by Tags"},
+		{"internal=x", "Unknown field internal"},
+		{"Tags.Name=Go", "Unknown field Tags.Name"},
+		{"ID=one", `Invalid value for ID: "one" is not a number`},
+		{"Active=maybe", `Invalid value for Active: "maybe" is not a boolean`},
+	}
+	items := []candidate{{ID: 1}}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			q, err := Parse(values)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if _, err := q.Apply(items, nil); err == nil || err.Error() != tt.want {
+				t.Errorf("Apply() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
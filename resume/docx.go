@@ -0,0 +1,92 @@
+package resume
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+//Namespace of the elements of the WordprocessingML body.
+const wordNamespace = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+
+//Returns the text of the body of a Word document, one line per paragraph.
+func extractDOCX(ctx context.Context, content []byte) (string, error) {
+	r, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return "", fmt.Errorf("Could not read DOCX: %v", err)
+	}
+
+	for _, f := range r.File {
+		if f.Name != "word/document.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", fmt.Errorf("Could not read DOCX: %v", err)
+		}
+		defer rc.Close()
+
+		//The size on the header of the file is not trusted, the part is read up to MaxDecodedBytes
+		lr := &io.LimitedReader{R: rc, N: MaxDecodedBytes + 1}
+		text, err := documentText(ctx, lr)
+		if lr.N <= 0 {
+			return "", ErrTooLarge
+		}
+		return text, err
+	}
+	return "", fmt.Errorf("Could not read DOCX: word/document.xml not found")
+}
+
+//Collects the runs of text of the document, breaking the lines on paragraphs and line breaks.
+func documentText(ctx context.Context, r io.Reader) (string, error) {
+	var b strings.Builder
+	inText := false
+
+	dec := xml.NewDecoder(r)
+	for n := 1; ; n++ {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("Could not read DOCX: %v", err)
+		}
+		if n%1024 == 0 && ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Space != wordNamespace {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			case "br", "cr":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			if t.Name.Space != wordNamespace {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+	return b.String(), nil
+}
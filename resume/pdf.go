@@ -0,0 +1,887 @@
+package resume
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/ascii85"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//A minimal reader of PDF files, enough to get the text of the documents written by word processors.
+//Every object of the file is read instead of following the cross-reference table, so files with a
+//damaged table are read as well. Fonts are decoded through their ToUnicode CMap when they have one.
+
+//Values of the objects of a PDF file: nil, bool, float64, pdfString, pdfName, []pdfObject, pdfDict, pdfRef and pdfStream.
+//Content streams also yield pdfOperator.
+type pdfObject interface{}
+
+type pdfName string
+type pdfString []byte
+type pdfDict map[pdfName]pdfObject
+type pdfOperator string
+
+type pdfRef struct {
+	num int
+}
+
+type pdfStream struct {
+	dict pdfDict
+	//Raw data, before the filters are applied.
+	data []byte
+}
+
+//Markers returned for the delimiters that close arrays and dictionaries.
+type pdfEnd byte
+
+//Reads the objects written on a PDF file or a content stream.
+type pdfLexer struct {
+	b   []byte
+	pos int
+	//Arrays and dictionaries being read, one inside the other.
+	depth int
+	//Work left to read the document the data belongs to, nil for the data that is not part of one.
+	work *pdfWork
+}
+
+//Deepest arrays and dictionaries are read, the ones nested deeper are cut short.
+const maxPDFNesting = 64
+
+//Most objects read on a document, counting every time the objects of a content stream or a page tree are read.
+//Enough for the longest resumes, so a file written to keep the reader busy is given up on instead.
+const maxPDFWork = 1 << 22
+
+//Work left to read a document, shared by every lexer reading it.
+type pdfWork struct {
+	ctx  context.Context
+	left int
+	//Set once the work ran out or ctx was done, the text read so far is discarded.
+	err error
+}
+
+//Counts an object read. Returns false once the work ran out or ctx is done.
+func (w *pdfWork) spend() bool {
+	if w == nil {
+		return true
+	}
+	if w.err != nil {
+		return false
+	}
+	if w.left--; w.left < 0 {
+		w.err = ErrTooComplex
+	} else if w.left%1024 == 0 && w.ctx.Err() != nil {
+		w.err = w.ctx.Err()
+	}
+	return w.err == nil
+}
+
+func isPDFSpace(c byte) bool {
+	return c == 0 || c == '\t' || c == '\n' || c == '\f' || c == '\r' || c == ' '
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.b) {
+		c := l.b[l.pos]
+		if c == '%' {
+			for l.pos < len(l.b) && l.b[l.pos] != '\n' && l.b[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		if !isPDFSpace(c) {
+			return
+		}
+		l.pos++
+	}
+}
+
+//Reads the characters up to the next space or delimiter.
+func (l *pdfLexer) word() string {
+	start := l.pos
+	for l.pos < len(l.b) && !isPDFSpace(l.b[l.pos]) && !isPDFDelimiter(l.b[l.pos]) {
+		l.pos++
+	}
+	return string(l.b[start:l.pos])
+}
+
+//Returns the next object, false at the end of the data or once the work to read the document ran out.
+func (l *pdfLexer) next() (pdfObject, bool) {
+	l.skipSpace()
+	if l.pos >= len(l.b) || !l.work.spend() {
+		return nil, false
+	}
+
+	c := l.b[l.pos]
+	switch {
+	case c == '/':
+		l.pos++
+		return pdfName(decodeName(l.word())), true
+	case c == '(':
+		l.pos++
+		return l.literalString(), true
+	case c == '<' && l.pos+1 < len(l.b) && l.b[l.pos+1] == '<':
+		l.pos += 2
+		return l.dict(), true
+	case c == '<':
+		l.pos++
+		return l.hexString(), true
+	case c == '>' && l.pos+1 < len(l.b) && l.b[l.pos+1] == '>':
+		l.pos += 2
+		return pdfEnd('>'), true
+	case c == '[':
+		l.pos++
+		return l.array(), true
+	case c == ']':
+		l.pos++
+		return pdfEnd(']'), true
+	case c == '{' || c == '}' || c == ')' || c == '>':
+		l.pos++
+		return l.next()
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return l.number(), true
+	}
+
+	w := l.word()
+	switch w {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	case "null":
+		return nil, true
+	}
+	return pdfOperator(w), true
+}
+
+//Reads a number, or a reference when it is followed by a generation and R.
+func (l *pdfLexer) number() pdfObject {
+	n, _ := strconv.ParseFloat(l.word(), 64)
+
+	save := l.pos
+	l.skipSpace()
+	if l.pos < len(l.b) && l.b[l.pos] >= '0' && l.b[l.pos] <= '9' {
+		l.word()
+		l.skipSpace()
+		if l.pos < len(l.b) && l.b[l.pos] == 'R' && (l.pos+1 == len(l.b) || isPDFSpace(l.b[l.pos+1]) || isPDFDelimiter(l.b[l.pos+1])) {
+			l.pos++
+			return pdfRef{int(n)}
+		}
+	}
+	l.pos = save
+	return n
+}
+
+func (l *pdfLexer) array() []pdfObject {
+	var ret []pdfObject
+	if l.depth++; l.depth > maxPDFNesting {
+		l.depth--
+		return ret
+	}
+	defer func() { l.depth-- }()
+	for {
+		o, ok := l.next()
+		if !ok || o == pdfEnd(']') {
+			return ret
+		}
+		if _, end := o.(pdfEnd); !end {
+			ret = append(ret, o)
+		}
+	}
+}
+
+func (l *pdfLexer) dict() pdfDict {
+	ret := make(pdfDict)
+	if l.depth++; l.depth > maxPDFNesting {
+		l.depth--
+		return ret
+	}
+	defer func() { l.depth-- }()
+	for {
+		k, ok := l.next()
+		if !ok || k == pdfEnd('>') {
+			return ret
+		}
+		name, isName := k.(pdfName)
+		if !isName {
+			continue
+		}
+		v, ok := l.next()
+		if !ok || v == pdfEnd('>') {
+			return ret
+		}
+		ret[name] = v
+	}
+}
+
+func (l *pdfLexer) literalString() pdfString {
+	var b []byte
+	depth := 1
+	for l.pos < len(l.b) {
+		c := l.b[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return b
+			}
+		case '\\':
+			if l.pos >= len(l.b) {
+				return b
+			}
+			c = l.b[l.pos]
+			l.pos++
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				//A backslash at the end of the line continues the string on the next one
+				if l.pos < len(l.b) && l.b[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if c >= '0' && c <= '7' {
+					n := int(c - '0')
+					for i := 0; i < 2 && l.pos < len(l.b) && l.b[l.pos] >= '0' && l.b[l.pos] <= '7'; i++ {
+						n = n*8 + int(l.b[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(n)
+				}
+			}
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+func (l *pdfLexer) hexString() pdfString {
+	var digits []byte
+	for l.pos < len(l.b) && l.b[l.pos] != '>' {
+		if c := l.b[l.pos]; !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	//The closing > is missing on a truncated file
+	if l.pos < len(l.b) {
+		l.pos++
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	b, _ := hex.DecodeString(string(digits))
+	return b
+}
+
+//Replaces the #xx escapes of a name by the characters they stand for.
+func decodeName(s string) string {
+	if !strings.Contains(s, "#") {
+		return s
+	}
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] == '#' && i+2 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b = append(b, byte(v))
+				i += 2
+				continue
+			}
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
+
+//Objects of a PDF file, by number.
+type pdfFile struct {
+	objects map[int]pdfObject
+	fonts   map[int]*pdfFont
+	//Bytes the streams of the file may still be decompressed to, see MaxDecodedBytes.
+	decodable int64
+	//Set once a stream expanded beyond what was left to decode.
+	tooLarge bool
+	work     *pdfWork
+}
+
+var pdfObjectHeader = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`)
+
+//Reads every object written on the file, including the ones compressed on object streams.
+//Each object is read up to its endobj or the next object header, so an array or a dictionary left open
+//does not read the objects after it, and the headers found on the data of a stream are skipped.
+func readPDF(ctx context.Context, content []byte) (*pdfFile, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(content, "\x00\t\n\f\r "), []byte("%PDF-")) {
+		return nil, fmt.Errorf("Could not read PDF: missing header")
+	}
+
+	f := &pdfFile{objects: make(map[int]pdfObject), fonts: make(map[int]*pdfFont), decodable: MaxDecodedBytes}
+	f.work = &pdfWork{ctx: ctx, left: maxPDFWork}
+	headers := pdfObjectHeader.FindAllSubmatchIndex(content, -1)
+	read := 0
+	for i, m := range headers {
+		if m[0] < read {
+			continue
+		}
+		end := len(content)
+		if i+1 < len(headers) {
+			end = headers[i+1][0]
+		}
+		if n := bytes.Index(content[m[1]:end], []byte("endobj")); n >= 0 {
+			end = m[1] + n
+		}
+
+		num, _ := strconv.Atoi(string(content[m[2]:m[3]]))
+		l := pdfLexer{b: content[:end], pos: m[1], work: f.work}
+		o, ok := l.next()
+		if !ok {
+			continue
+		}
+		if d, isDict := o.(pdfDict); isDict {
+			if data, streamEnd, found := streamData(content, l.pos, d); found {
+				o = pdfStream{dict: d, data: data}
+				read = streamEnd
+			}
+		}
+		//Objects written later on the file are updates of the earlier ones
+		f.objects[num] = o
+	}
+	if f.work.err != nil {
+		return nil, f.work.err
+	}
+
+	var streams []int
+	for num, o := range f.objects {
+		if s, ok := o.(pdfStream); ok && s.dict["Type"] == pdfName("ObjStm") {
+			streams = append(streams, num)
+		}
+	}
+	sort.Ints(streams)
+	for _, num := range streams {
+		f.readObjectStream(f.objects[num].(pdfStream))
+	}
+
+	if len(f.objects) == 0 {
+		return nil, fmt.Errorf("Could not read PDF: no objects found")
+	}
+	return f, nil
+}
+
+//Returns the data of the stream whose dictionary ends at pos and the position past its data, false when the dictionary has no stream.
+func streamData(content []byte, pos int, d pdfDict) ([]byte, int, bool) {
+	if pos < 0 || pos >= len(content) {
+		return nil, 0, false
+	}
+	l := pdfLexer{b: content, pos: pos}
+	l.skipSpace()
+	if l.pos >= len(content) || !bytes.HasPrefix(content[l.pos:], []byte("stream")) {
+		return nil, 0, false
+	}
+	start := l.pos + len("stream")
+	if start < len(content) && content[start] == '\r' {
+		start++
+	}
+	if start < len(content) && content[start] == '\n' {
+		start++
+	}
+
+	//The length is trusted when it is direct and the stream ends where it says
+	if n, ok := d["Length"].(float64); ok && n >= 0 && start+int(n) <= len(content) {
+		end := start + int(n)
+		rest := bytes.TrimLeft(content[end:], "\x00\t\n\f\r ")
+		if bytes.HasPrefix(rest, []byte("endstream")) {
+			return content[start:end], end, true
+		}
+	}
+
+	end := bytes.Index(content[start:], []byte("endstream"))
+	if end < 0 {
+		return content[start:], len(content), true
+	}
+	data := content[start : start+end]
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	data = bytes.TrimSuffix(data, []byte("\r"))
+	return data, start + end, true
+}
+
+//Reads the objects compressed on an object stream, keeping the ones also written directly on the file.
+func (f *pdfFile) readObjectStream(s pdfStream) {
+	data, err := f.decodeStream(s)
+	if err != nil {
+		return
+	}
+	n, _ := s.dict["N"].(float64)
+	first, _ := s.dict["First"].(float64)
+	if first < 0 || int(first) > len(data) {
+		return
+	}
+
+	header := pdfLexer{b: data[:int(first)], work: f.work}
+	for i := 0; i < int(n); i++ {
+		num, ok1 := header.next()
+		offset, ok2 := header.next()
+		numValue, isNum := num.(float64)
+		offsetValue, isOffset := offset.(float64)
+		if !ok1 || !ok2 || !isNum || !isOffset {
+			return
+		}
+		if _, found := f.objects[int(numValue)]; found {
+			continue
+		}
+		l := pdfLexer{b: data, pos: int(first) + int(offsetValue), work: f.work}
+		if offsetValue >= 0 && l.pos < len(data) {
+			if o, ok := l.next(); ok {
+				f.objects[int(numValue)] = o
+			}
+		}
+	}
+}
+
+//Follows the references until an object that is not a reference.
+func (f *pdfFile) resolve(o pdfObject) pdfObject {
+	for i := 0; i < 32; i++ {
+		ref, ok := o.(pdfRef)
+		if !ok {
+			return o
+		}
+		o = f.objects[ref.num]
+	}
+	return nil
+}
+
+//Returns the dictionary of the object, or of the stream, it refers to.
+func (f *pdfFile) dict(o pdfObject) pdfDict {
+	switch v := f.resolve(o).(type) {
+	case pdfDict:
+		return v
+	case pdfStream:
+		return v.dict
+	}
+	return nil
+}
+
+//Applies the filters of the stream to its data. Only the filters used on text are supported.
+//The streams of a file are decompressed up to MaxDecodedBytes in total, ErrTooLarge is returned past it.
+func (f *pdfFile) decodeStream(s pdfStream) ([]byte, error) {
+	var filters []pdfObject
+	switch v := s.dict["Filter"].(type) {
+	case pdfName:
+		filters = []pdfObject{v}
+	case []pdfObject:
+		filters = v
+	}
+
+	data := s.data
+	for _, filter := range filters {
+		switch filter {
+		case pdfName("FlateDecode"), pdfName("Fl"):
+			r, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			//Streams cut short still have most of the text
+			decoded, err := ioutil.ReadAll(io.LimitReader(r, f.decodable+1))
+			if int64(len(decoded)) > f.decodable {
+				f.decodable = 0
+				f.tooLarge = true
+				return nil, ErrTooLarge
+			}
+			if err != nil && len(decoded) == 0 {
+				return nil, err
+			}
+			f.decodable -= int64(len(decoded))
+			data = decoded
+		case pdfName("ASCIIHexDecode"), pdfName("AHx"):
+			data = pdfString((&pdfLexer{b: append(append([]byte(nil), data...), '>')}).hexString())
+		case pdfName("ASCII85Decode"), pdfName("A85"):
+			data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+			if end := bytes.Index(data, []byte("~>")); end >= 0 {
+				data = data[:end]
+			}
+			decoded := make([]byte, 4*len(data)/5+4)
+			n, _, err := ascii85.Decode(decoded, data, true)
+			if err != nil {
+				return nil, err
+			}
+			data = decoded[:n]
+		default:
+			return nil, fmt.Errorf("Unsupported PDF filter %v", filter)
+		}
+	}
+	return data, nil
+}
+
+//Returns the text of every page of the document, in the order of the pages.
+func extractPDF(ctx context.Context, content []byte) (string, error) {
+	f, err := readPDF(ctx, content)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, page := range f.pages() {
+		f.pageText(&b, page.dict, page.resources)
+		b.WriteByte('\n')
+	}
+	if f.tooLarge {
+		return "", ErrTooLarge
+	}
+	if f.work.err != nil {
+		return "", f.work.err
+	}
+	return cleanLines(b.String()), nil
+}
+
+type pdfPage struct {
+	dict pdfDict
+	//Resources of the page, which may be inherited from the tree of pages.
+	resources pdfDict
+}
+
+//Returns the pages by walking the tree of pages of the catalog.
+//Files without a catalog have their pages returned in the order of their numbers.
+func (f *pdfFile) pages() []pdfPage {
+	var ret []pdfPage
+	var walk func(node pdfObject, resources pdfDict, depth int)
+	walk = func(node pdfObject, resources pdfDict, depth int) {
+		//Pages may be reached through many paths, each one counts as the work of reading them again
+		d := f.dict(node)
+		if d == nil || depth > 64 || !f.work.spend() {
+			return
+		}
+		if r := f.dict(d["Resources"]); r != nil {
+			resources = r
+		}
+		if d["Type"] == pdfName("Page") {
+			ret = append(ret, pdfPage{d, resources})
+			return
+		}
+		kids, _ := f.resolve(d["Kids"]).([]pdfObject)
+		for _, kid := range kids {
+			walk(kid, resources, depth+1)
+		}
+	}
+
+	nums := make([]int, 0, len(f.objects))
+	for num := range f.objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+
+	for i := len(nums) - 1; i >= 0; i-- {
+		if d := f.dict(f.objects[nums[i]]); d["Type"] == pdfName("Catalog") {
+			walk(d["Pages"], nil, 0)
+			break
+		}
+	}
+	if len(ret) > 0 {
+		return ret
+	}
+
+	for _, num := range nums {
+		if d := f.dict(f.objects[num]); d["Type"] == pdfName("Page") {
+			ret = append(ret, pdfPage{d, f.dict(d["Resources"])})
+		}
+	}
+	return ret
+}
+
+//Writes the text shown by the content streams of the page.
+func (f *pdfFile) pageText(b *strings.Builder, page pdfDict, resources pdfDict) {
+	var data []byte
+	contents := f.resolve(page["Contents"])
+	if arr, ok := contents.([]pdfObject); ok {
+		for _, c := range arr {
+			if s, ok := f.resolve(c).(pdfStream); ok {
+				if decoded, err := f.decodeStream(s); err == nil {
+					data = append(append(data, decoded...), '\n')
+				}
+			}
+		}
+	} else if s, ok := contents.(pdfStream); ok {
+		data, _ = f.decodeStream(s)
+	}
+
+	fonts := f.dict(resources["Font"])
+	var font *pdfFont
+	var operands []pdfObject
+	lastY := 0.0
+
+	newLine := func() {
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+	}
+	space := func() {
+		if s := b.String(); len(s) > 0 && s[len(s)-1] != ' ' && s[len(s)-1] != '\n' {
+			b.WriteByte(' ')
+		}
+	}
+	show := func(o pdfObject) {
+		if s, ok := o.(pdfString); ok {
+			b.WriteString(font.decode(s))
+		}
+	}
+
+	l := pdfLexer{b: data, work: f.work}
+	for {
+		o, ok := l.next()
+		if !ok {
+			return
+		}
+		op, isOperator := o.(pdfOperator)
+		if !isOperator {
+			operands = append(operands, o)
+			continue
+		}
+
+		switch op {
+		case "Tf":
+			if len(operands) >= 2 {
+				name, _ := operands[len(operands)-2].(pdfName)
+				font = f.font(fonts[name])
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				tx, _ := operands[len(operands)-2].(float64)
+				ty, _ := operands[len(operands)-1].(float64)
+				if ty != 0 {
+					newLine()
+				} else if tx > 0 {
+					space()
+				}
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				y, _ := operands[len(operands)-1].(float64)
+				if y != lastY {
+					newLine()
+				} else {
+					space()
+				}
+				lastY = y
+			}
+		case "T*", "ET":
+			newLine()
+		case "Tj":
+			if len(operands) >= 1 {
+				show(operands[len(operands)-1])
+			}
+		case "'", "\"":
+			newLine()
+			if len(operands) >= 1 {
+				show(operands[len(operands)-1])
+			}
+		case "TJ":
+			if len(operands) >= 1 {
+				arr, _ := operands[len(operands)-1].([]pdfObject)
+				for _, item := range arr {
+					//Large negative adjustments move the next glyph as far as a space would
+					if n, isNumber := item.(float64); isNumber && n < -200 {
+						space()
+					}
+					show(item)
+				}
+			}
+		case "BI":
+			l.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+}
+
+//Skips the data of an inline image, which is not made of objects, up to its EI operator.
+func (l *pdfLexer) skipInlineImage() {
+	i := bytes.Index(l.b[l.pos:], []byte("ID"))
+	if i < 0 {
+		l.pos = len(l.b)
+		return
+	}
+	l.pos += i + 2
+	for l.pos < len(l.b) {
+		i = bytes.Index(l.b[l.pos:], []byte("EI"))
+		if i < 0 {
+			l.pos = len(l.b)
+			return
+		}
+		end := l.pos + i
+		l.pos = end + 2
+		if isPDFSpace(l.b[end-1]) && (l.pos == len(l.b) || isPDFSpace(l.b[l.pos])) {
+			return
+		}
+	}
+}
+
+//Font of the text being shown, which maps the codes of the strings to characters.
+type pdfFont struct {
+	//Bytes per code, 2 for the composite fonts.
+	codeBytes int
+	toUnicode map[uint32]string
+}
+
+//Returns the font of the object, reading it the first time it is used.
+func (f *pdfFile) font(o pdfObject) *pdfFont {
+	ref, isRef := o.(pdfRef)
+	if isRef {
+		if font, found := f.fonts[ref.num]; found {
+			return font
+		}
+	}
+
+	d := f.dict(o)
+	font := &pdfFont{codeBytes: 1}
+	if d["Subtype"] == pdfName("Type0") {
+		font.codeBytes = 2
+	}
+	if s, ok := f.resolve(d["ToUnicode"]).(pdfStream); ok {
+		if data, err := f.decodeStream(s); err == nil {
+			font.toUnicode, font.codeBytes = parseCMap(data, font.codeBytes, f.work)
+		}
+	}
+
+	if isRef {
+		f.fonts[ref.num] = font
+	}
+	return font
+}
+
+//Returns the characters of the codes of the string.
+//Simple fonts without a CMap are read as Windows-1252, the encoding most documents use.
+//Composite fonts without a CMap cannot be read, their text is left out.
+func (font *pdfFont) decode(s pdfString) string {
+	if font == nil {
+		font = &pdfFont{codeBytes: 1}
+	}
+
+	var b strings.Builder
+	for i := 0; i+font.codeBytes <= len(s); i += font.codeBytes {
+		var code uint32
+		for _, c := range s[i : i+font.codeBytes] {
+			code = code<<8 | uint32(c)
+		}
+		if text, found := font.toUnicode[code]; found {
+			b.WriteString(text)
+		} else if font.codeBytes == 1 {
+			b.WriteRune(windows1252(byte(code)))
+		}
+	}
+	return b.String()
+}
+
+//Characters Windows-1252 has where Latin-1 has control codes.
+var windows1252Extra = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡', 0x88: 'ˆ', 0x89: '‰',
+	0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž', 0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•',
+	0x96: '–', 0x97: '—', 0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›', 0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+}
+
+func windows1252(c byte) rune {
+	if r, found := windows1252Extra[c]; found {
+		return r
+	}
+	return rune(c)
+}
+
+//Reads the mappings of a ToUnicode CMap.
+//Returns the characters of each code and the number of bytes of the codes, which is codeBytes when the CMap does not say.
+//Every code mapped by a range counts on the work, as much as an object read.
+func parseCMap(data []byte, codeBytes int, work *pdfWork) (map[uint32]string, int) {
+	ret := make(map[uint32]string)
+	l := pdfLexer{b: data, work: work}
+
+	var operands []pdfObject
+	for {
+		o, ok := l.next()
+		if !ok {
+			return ret, codeBytes
+		}
+		op, isOperator := o.(pdfOperator)
+		if !isOperator {
+			operands = append(operands, o)
+			continue
+		}
+
+		switch op {
+		case "endcodespacerange":
+			if len(operands) > 0 {
+				if lo, ok := operands[0].(pdfString); ok && len(lo) > 0 {
+					codeBytes = len(lo)
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(pdfString)
+				dst, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 {
+					ret[codeOf(src)] = decodeUTF16(dst, true)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if !ok1 || !ok2 || codeOf(hi) < codeOf(lo) || codeOf(hi)-codeOf(lo) > 0xFFFF {
+					continue
+				}
+				switch dst := operands[i+2].(type) {
+				case pdfString:
+					//The last character is incremented for each code of the range
+					units := []rune(decodeUTF16(dst, true))
+					for code := codeOf(lo); code <= codeOf(hi) && len(units) > 0 && work.spend(); code++ {
+						ret[code] = string(units)
+						units[len(units)-1]++
+					}
+				case []pdfObject:
+					for j, item := range dst {
+						if s, ok := item.(pdfString); ok {
+							ret[codeOf(lo)+uint32(j)] = decodeUTF16(s, true)
+						}
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+func codeOf(s pdfString) uint32 {
+	var code uint32
+	for _, c := range s {
+		code = code<<8 | uint32(c)
+	}
+	return code
+}
+
+//Trims the spaces around every line and drops the blank lines.
+func cleanLines(text string) string {
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
@@ -0,0 +1,155 @@
+package resume
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+//Returns a PDF of one page showing the content stream, written as a word processor would.
+func buildPDF(stream []byte, filter string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	b.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	b.WriteString("2 0 obj\n<< /Type /Pages /Kids [3 0 R] /Count 1 >>\nendobj\n")
+	b.WriteString("3 0 obj\n<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 5 0 R >> >> /Contents 4 0 R >>\nendobj\n")
+	if filter != "" {
+		filter = " /Filter /" + filter
+	}
+	fmt.Fprintf(&b, "4 0 obj\n<< /Length %v%v >>\nstream\n", len(stream), filter)
+	b.Write(stream)
+	b.WriteString("\nendstream\nendobj\n")
+	b.WriteString("5 0 obj\n<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>\nendobj\n")
+	b.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+func deflate(data []byte) []byte {
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	w.Write(data)
+	w.Close()
+	return b.Bytes()
+}
+
+const pageStream = "BT /F1 12 Tf 72 712 Td (Jane Doe) Tj 0 -14 Td [(Go) -250 (and C++)] TJ ET"
+
+func TestExtractPDF(t *testing.T) {
+	tests := []struct {
+		name string
+		pdf  []byte
+	}{
+		{"plain", buildPDF([]byte(pageStream), "")},
+		{"flate", buildPDF(deflate([]byte(pageStream)), "FlateDecode")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := Extract(context.Background(), PDF, tt.pdf)
+			if err != nil {
+				t.Fatalf("Extract() error = %v", err)
+			}
+			if want := "Jane Doe\nGo and C++"; text != want {
+				t.Errorf("Extract() = %q, want %q", text, want)
+			}
+		})
+	}
+}
+
+func TestExtractPDFMissingHeader(t *testing.T) {
+	if _, err := Extract(context.Background(), PDF, []byte("not a pdf")); err == nil {
+		t.Error("Extract() error = nil, want an error")
+	}
+}
+
+//Every prefix of a file, as left by an upload cut short, is read without a panic.
+func TestExtractPDFTruncated(t *testing.T) {
+	for _, pdf := range [][]byte{buildPDF([]byte(pageStream), ""), buildPDF(deflate([]byte(pageStream)), "FlateDecode")} {
+		for n := 0; n <= len(pdf); n++ {
+			readWithoutPanic(t, pdf[:n])
+		}
+	}
+}
+
+//Every byte of a file replaced by a delimiter or a byte out of place is read without a panic.
+func TestExtractPDFCorrupted(t *testing.T) {
+	pdf := buildPDF([]byte(pageStream), "")
+	for i := range pdf {
+		for _, c := range []byte("<>()[]/%\\ 0-\xff") {
+			corrupted := append([]byte(nil), pdf...)
+			corrupted[i] = c
+			readWithoutPanic(t, corrupted)
+		}
+	}
+
+	//Unclosed strings and dictionaries at the end of the file
+	for _, tail := range []string{"6 0 obj\n<", "6 0 obj\n<<", "6 0 obj\n(", "6 0 obj\n<< /Length 10 >>", "6 0 obj\n<< /Length 10 >>\nstream"} {
+		readWithoutPanic(t, append(append([]byte(nil), pdf...), tail...))
+	}
+
+	//Arrays nested deeper than any document does
+	readWithoutPanic(t, []byte("%PDF-1.4\n1 0 obj\n"+strings.Repeat("[", 100000)))
+}
+
+func TestExtractPDFObjectStreamOutOfRange(t *testing.T) {
+	objects := "1 0 2 0 << /Type /Catalog >>"
+	for _, header := range []string{"/N 2 /First -5", "/N 2 /First 1000", "/N 2 /First 4"} {
+		var b bytes.Buffer
+		b.WriteString("%PDF-1.5\n")
+		fmt.Fprintf(&b, "7 0 obj\n<< /Type /ObjStm %v /Length %v >>\nstream\n%v\nendstream\nendobj\n", header, len(objects), objects)
+		readWithoutPanic(t, b.Bytes())
+	}
+}
+
+//Calls the reader directly, as Extract would hide the panic.
+func readWithoutPanic(t *testing.T, pdf []byte) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("extractPDF() of %q panicked: %v", pdf, r)
+		}
+	}()
+	extractPDF(context.Background(), pdf)
+}
+
+func TestExtractPDFTooLarge(t *testing.T) {
+	bomb := deflate(bytes.Repeat([]byte(" "), MaxDecodedBytes+1))
+	if _, err := Extract(context.Background(), PDF, buildPDF(bomb, "FlateDecode")); err != ErrTooLarge {
+		t.Errorf("Extract() error = %v, want %v", err, ErrTooLarge)
+	}
+}
+
+//Objects left open, each one until the end of the file, are read once each instead of once per object after them.
+func TestExtractPDFUnclosedObjects(t *testing.T) {
+	pdf := []byte("%PDF-1.4\n" + strings.Repeat("1 0 obj [ ", 20000))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := Extract(ctx, PDF, pdf); err != nil {
+		t.Errorf("Extract() error = %v, want the file read before the deadline", err)
+	}
+}
+
+func TestExtractPDFDeadline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pdf := buildPDF([]byte(strings.Repeat(pageStream+"\n", 1000)), "")
+	if _, err := Extract(ctx, PDF, pdf); err != context.Canceled {
+		t.Errorf("Extract() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+//Pages reached through every path of a tree whose nodes list the same kid twice, 2^40 paths in all.
+func TestExtractPDFTooComplex(t *testing.T) {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n1 0 obj\n<< /Type /Catalog /Pages 10 0 R >>\nendobj\n")
+	for i := 10; i < 50; i++ {
+		fmt.Fprintf(&b, "%v 0 obj\n<< /Type /Pages /Kids [%v 0 R %v 0 R] >>\nendobj\n", i, i+1, i+1)
+	}
+	b.WriteString("50 0 obj\n<< /Type /Page >>\nendobj\n")
+	if _, err := Extract(context.Background(), PDF, b.Bytes()); err != ErrTooComplex {
+		t.Errorf("Extract() error = %v, want %v", err, ErrTooComplex)
+	}
+}
@@ -0,0 +1,206 @@
+package resume
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+//MIME types of the documents Extract reads the text of.
+const (
+	PDF  = "application/pdf"
+	DOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	TXT  = "text/plain"
+)
+
+//ErrUnsupported is returned by Extract for the documents it cannot read.
+var ErrUnsupported = errors.New("Unsupported document type")
+
+//ErrTooLarge is returned by Extract for the documents whose compressed parts expand beyond MaxDecodedBytes.
+var ErrTooLarge = errors.New("Document expands beyond the size read")
+
+//ErrTooComplex is returned by Extract for the documents made of more objects than a resume would ever be.
+var ErrTooComplex = errors.New("Document has too many objects to read")
+
+//MaxDecodedBytes is the most a document is decompressed to, so a small upload cannot expand to fill the memory.
+const MaxDecodedBytes = 64 << 20
+
+//Details are the contact information and the terms found on the text of a resume.
+type Details struct {
+	Emails []string
+	Phones []string
+	//Runs of up to MaxTermWords words, in the order they first appear, to be matched against known skills.
+	Terms []string
+}
+
+//Longest run of words returned on Details.Terms, enough for skills such as "Google Cloud Platform".
+const MaxTermWords = 3
+
+//Extract returns the plain text of the document, one line per paragraph.
+//contentType is the MIME type of the document, parameters such as the charset are ignored.
+//A document the readers fail on is returned as an error, never as a panic.
+//The reading stops with the error of ctx once ctx is done, so a deadline bounds the time spent on a document.
+func Extract(ctx context.Context, contentType string, content []byte) (text string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			text, err = "", fmt.Errorf("Could not read document: %v", r)
+		}
+	}()
+
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+
+	switch strings.ToLower(strings.TrimSpace(contentType)) {
+	case PDF:
+		return extractPDF(ctx, content)
+	case DOCX:
+		return extractDOCX(ctx, content)
+	case TXT:
+		return decodeText(content), nil
+	}
+	return "", ErrUnsupported
+}
+
+//Returns the text of a plain text file, which may be UTF-8, UTF-16 with a byte order mark or Latin-1.
+func decodeText(content []byte) string {
+	switch {
+	case bytes.HasPrefix(content, []byte{0xEF, 0xBB, 0xBF}):
+		return string(content[3:])
+	case bytes.HasPrefix(content, []byte{0xFF, 0xFE}):
+		return decodeUTF16(content[2:], false)
+	case bytes.HasPrefix(content, []byte{0xFE, 0xFF}):
+		return decodeUTF16(content[2:], true)
+	case utf8.Valid(content):
+		return string(content)
+	}
+	return latin1(content)
+}
+
+func decodeUTF16(b []byte, bigEndian bool) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+		} else {
+			units = append(units, uint16(b[i+1])<<8|uint16(b[i]))
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+func latin1(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`)
+	//Digits with the separators usually written on phone numbers, the number of digits is checked apart
+	phonePattern = regexp.MustCompile(`\+?\(?\d[\d\s().\-/]{6,}\d`)
+	//Dates written with all the digits of the year, which are as long as the shortest phone numbers
+	datePattern = regexp.MustCompile(`^(?:\d{4}[\-/.]\d{1,2}[\-/.]\d{1,2}|\d{1,2}[\-/.]\d{1,2}[\-/.]\d{4})$`)
+)
+
+//Parse finds the emails, the phone numbers and the terms written on the text.
+func Parse(text string) Details {
+	var d Details
+
+	seen := make(map[string]bool)
+	for _, e := range emailPattern.FindAllString(text, -1) {
+		key := strings.ToLower(e)
+		if !seen[key] {
+			seen[key] = true
+			d.Emails = append(d.Emails, e)
+		}
+	}
+
+	//Phone numbers are searched line by line, so numbers on consecutive lines are not joined
+	for _, line := range strings.Split(text, "\n") {
+		for _, p := range phonePattern.FindAllString(line, -1) {
+			p = strings.TrimSpace(p)
+			if datePattern.MatchString(p) {
+				continue
+			}
+			digits := strings.Map(func(r rune) rune {
+				if r >= '0' && r <= '9' {
+					return r
+				}
+				return -1
+			}, p)
+			//Numbers shorter than that are dates or years, longer than E.164 allows are something else
+			if len(digits) < 8 || len(digits) > 15 || seen[digits] {
+				continue
+			}
+			seen[digits] = true
+			d.Phones = append(d.Phones, p)
+		}
+	}
+
+	d.Terms = Terms(text, MaxTermWords)
+	return d
+}
+
+//Terms returns every run of up to maxWords consecutive words of the text, once each, in the order they first appear.
+//Runs never cross the end of a line or a mark that ends a phrase, such as a comma or the dot ending a sentence.
+func Terms(text string, maxWords int) []string {
+	var ret []string
+	seen := make(map[string]bool)
+	add := func(words []string) {
+		for i := range words {
+			for n := 1; n <= maxWords && i+n <= len(words); n++ {
+				term := strings.Join(words[i:i+n], " ")
+				if !seen[term] {
+					seen[term] = true
+					ret = append(ret, term)
+				}
+			}
+		}
+	}
+
+	for _, phrase := range phrases(text) {
+		var run []string
+		for _, w := range strings.Fields(phrase) {
+			last := strings.HasSuffix(w, ".")
+			if w = cleanWord(w); w == "" {
+				add(run)
+				run = nil
+				continue
+			}
+			run = append(run, w)
+			if last {
+				add(run)
+				run = nil
+			}
+		}
+		add(run)
+	}
+	return ret
+}
+
+//Splits the text on the marks that end a phrase, keeping the characters skills are written with, such as C++, C# or Node.js.
+func phrases(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		switch r {
+		case '+', '#', '.', '-', '/', '\'', '&', ' ', '\t':
+			return false
+		}
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+//Returns the word without the marks around it, such as the dot ending a sentence or a dash used as a bullet.
+func cleanWord(w string) string {
+	return strings.TrimFunc(w, func(r rune) bool {
+		return r == '.' || r == '-' || r == '/' || r == '\'' || r == '&'
+	})
+}
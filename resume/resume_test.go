@@ -0,0 +1,119 @@
+package resume
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+//Returns a Word document with the body provided.
+func buildDOCX(t *testing.T, body string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	f, err := w.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` + body + `</w:body></w:document>`))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestExtractDOCX(t *testing.T) {
+	docx := buildDOCX(t, `<w:p><w:r><w:t>Jane</w:t></w:r><w:r><w:t xml:space="preserve"> Doe</w:t></w:r></w:p><w:p><w:r><w:t>Go</w:t><w:tab/><w:t>Kubernetes</w:t></w:r></w:p>`)
+	text, err := Extract(context.Background(), DOCX+"; charset=binary", docx)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if want := "Jane Doe\nGo\tKubernetes\n"; text != want {
+		t.Errorf("Extract() = %q, want %q", text, want)
+	}
+}
+
+func TestExtractDOCXInvalid(t *testing.T) {
+	if _, err := Extract(context.Background(), DOCX, []byte("PK not a zip")); err == nil {
+		t.Error("Extract() error = nil, want an error")
+	}
+	docx := buildDOCX(t, `<w:p><w:r><w:t>cut`)
+	if _, err := Extract(context.Background(), DOCX, docx[:len(docx)/2]); err == nil {
+		t.Error("Extract() of a truncated file error = nil, want an error")
+	}
+}
+
+func TestExtractDOCXTooLarge(t *testing.T) {
+	docx := buildDOCX(t, `<w:p><w:r><w:t>`+strings.Repeat(" ", MaxDecodedBytes)+`</w:t></w:r></w:p>`)
+	if _, err := Extract(context.Background(), DOCX, docx); err != ErrTooLarge {
+		t.Errorf("Extract() error = %v, want %v", err, ErrTooLarge)
+	}
+}
+
+func TestExtractText(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		want    string
+	}{
+		{"utf8", []byte("Conceição"), "Conceição"},
+		{"utf8 bom", []byte("\xef\xbb\xbfGo"), "Go"},
+		{"utf16 le", []byte{0xFF, 0xFE, 'G', 0, 'o', 0}, "Go"},
+		{"utf16 be", []byte{0xFE, 0xFF, 0, 'G', 0, 'o'}, "Go"},
+		{"latin1", []byte("Concei\xe7\xe3o"), "Conceição"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Extract(context.Background(), TXT, tt.content)
+			if err != nil || got != tt.want {
+				t.Errorf("Extract() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractUnsupported(t *testing.T) {
+	if _, err := Extract(context.Background(), "image/png", nil); err != ErrUnsupported {
+		t.Errorf("Extract() error = %v, want %v", err, ErrUnsupported)
+	}
+}
+
+func TestParse(t *testing.T) {
+	d := Parse("Jane Doe, jane.doe+cv@mail.com\nPhone: +55 (11) 98765-4321\nBorn 1990-01-02\nSkills: Go, Node.js and C++.")
+
+	if want := []string{"jane.doe+cv@mail.com"}; !reflect.DeepEqual(d.Emails, want) {
+		t.Errorf("Emails = %q, want %q", d.Emails, want)
+	}
+	if want := []string{"+55 (11) 98765-4321"}; !reflect.DeepEqual(d.Phones, want) {
+		t.Errorf("Phones = %q, want %q", d.Phones, want)
+	}
+	for _, term := range []string{"Jane Doe", "Go", "Node.js and C++", "C++"} {
+		if !contains(d.Terms, term) {
+			t.Errorf("Terms = %q, missing %q", d.Terms, term)
+		}
+	}
+	//Runs do not cross the commas ending the phrases
+	if contains(d.Terms, "Go Node.js") {
+		t.Errorf("Terms = %q, should not join words across a comma", d.Terms)
+	}
+}
+
+func TestTerms(t *testing.T) {
+	got := Terms("Google Cloud Platform. Go", 2)
+	want := []string{"Google", "Google Cloud", "Cloud", "Cloud Platform", "Platform", "Go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Terms() = %q, want %q", got, want)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,275 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+//Document is the text of a record to be indexed, by field. A field may have many values, such as the labels of the tags.
+type Document map[string][]string
+
+//Hit is a value found by a query, with the relevance it was scored.
+type Hit[V Ordered] struct {
+	Value V
+	Score float64
+}
+
+//FullText is an inverted index of documents, searched with the queries read by Parse.
+//Each word is scored by BM25 on the field it was found, multiplied by the weight of the field.
+//It is not safe for concurrent use.
+type FullText[V Ordered] struct {
+	weights map[string]float64
+	scopes  map[string][]string
+
+	//Positions of each word on each document, by field
+	postings map[string]map[string]map[V][]int
+	//Words indexed for each document, by field, to remove them when the document changes
+	docs map[V]map[string][]string
+	//Number of words on each field of every document, to tell the length of the average field
+	fieldWords map[string]int
+}
+
+//NewFullText returns an empty index of documents with the fields of weights.
+//scopes names groups of fields that can be searched together, as "name" for the first and last names.
+//Every field can also be searched by its own name.
+func NewFullText[V Ordered](weights map[string]float64, scopes map[string][]string) *FullText[V] {
+	x := &FullText[V]{
+		weights:    weights,
+		scopes:     make(map[string][]string),
+		postings:   make(map[string]map[string]map[V][]int),
+		docs:       make(map[V]map[string][]string),
+		fieldWords: make(map[string]int),
+	}
+	for field := range weights {
+		x.scopes[field] = []string{field}
+		//Words without a field are searched on all of them
+		x.scopes[""] = append(x.scopes[""], field)
+		x.postings[field] = make(map[string]map[V][]int)
+	}
+	sort.Strings(x.scopes[""])
+	for name, fields := range scopes {
+		x.scopes[name] = fields
+	}
+	return x
+}
+
+//Len returns the number of documents on the index.
+func (x *FullText[V]) Len() int {
+	return len(x.docs)
+}
+
+//Set indexes the document of the value, replacing the one it had. Fields without a weight are ignored.
+func (x *FullText[V]) Set(v V, doc Document) {
+	x.Delete(v)
+
+	indexed := make(map[string][]string)
+	for field, values := range doc {
+		words, found := x.postings[field]
+		if !found {
+			continue
+		}
+
+		pos := 0
+		var all []string
+		for _, value := range values {
+			for _, t := range Tokens(value) {
+				if words[t] == nil {
+					words[t] = make(map[V][]int)
+				}
+				words[t][v] = append(words[t][v], pos)
+				all = append(all, t)
+				pos++
+			}
+			//A phrase never spans two values of the field
+			pos++
+		}
+		if len(all) > 0 {
+			indexed[field] = all
+			x.fieldWords[field] += len(all)
+		}
+	}
+	x.docs[v] = indexed
+}
+
+//Delete removes the document of the value from the index.
+func (x *FullText[V]) Delete(v V) {
+	for field, all := range x.docs[v] {
+		words := x.postings[field]
+		for _, t := range all {
+			if docs := words[t]; docs != nil {
+				delete(docs, v)
+				if len(docs) == 0 {
+					delete(words, t)
+				}
+			}
+		}
+		x.fieldWords[field] -= len(all)
+	}
+	delete(x.docs, v)
+}
+
+//Search returns the values of the documents matching the query, the most relevant first.
+func (x *FullText[V]) Search(q Query) []Hit[V] {
+	scores := x.eval(q)
+
+	ret := make([]Hit[V], 0, len(scores))
+	for v, s := range scores {
+		ret = append(ret, Hit[V]{v, s})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Score != ret[j].Score {
+			return ret[i].Score > ret[j].Score
+		}
+		return ret[i].Value < ret[j].Value
+	})
+	return ret
+}
+
+//Returns the score of every document matching the query.
+func (x *FullText[V]) eval(q Query) map[V]float64 {
+	switch q := q.(type) {
+	case termQuery:
+		ret := make(map[V]float64)
+		for _, field := range x.scopes[q.Field] {
+			for v, s := range x.matchTerm(field, q.Words) {
+				ret[v] += s
+			}
+		}
+		return ret
+	case andQuery:
+		var ret map[V]float64
+		for _, sub := range q {
+			found := x.eval(sub)
+			if ret == nil {
+				ret = found
+				continue
+			}
+			for v := range ret {
+				if s, ok := found[v]; ok {
+					ret[v] += s
+				} else {
+					delete(ret, v)
+				}
+			}
+		}
+		return ret
+	case orQuery:
+		ret := make(map[V]float64)
+		for _, sub := range q {
+			for v, s := range x.eval(sub) {
+				ret[v] += s
+			}
+		}
+		return ret
+	case notQuery:
+		//Documents not matching are not more or less relevant than each other
+		excluded := x.eval(q.Query)
+		ret := make(map[V]float64)
+		for v := range x.docs {
+			if _, found := excluded[v]; !found {
+				ret[v] = 0
+			}
+		}
+		return ret
+	}
+	return nil
+}
+
+//Returns the score of the documents with the words on the field, one after the other when there are many.
+func (x *FullText[V]) matchTerm(field string, words []string) map[V]float64 {
+	postings := x.postings[field]
+	ret := make(map[V]float64)
+	if len(words) == 0 {
+		return ret
+	}
+
+	for v, first := range postings[words[0]] {
+		count := 0
+		for _, start := range first {
+			if x.phraseAt(postings, words, v, start) {
+				count++
+			}
+		}
+		if count == 0 {
+			continue
+		}
+
+		score := 0.0
+		for _, w := range words {
+			score += x.bm25(field, w, v, count)
+		}
+		ret[v] = score * x.weights[field]
+	}
+	return ret
+}
+
+//Returns whether the words are found on the document from the position start on.
+func (x *FullText[V]) phraseAt(postings map[string]map[V][]int, words []string, v V, start int) bool {
+	for i, w := range words[1:] {
+		found := false
+		for _, p := range postings[w][v] {
+			if p == start+i+1 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+//BM25 parameters, the usual ones.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+//Returns the BM25 score of the word on the field of the document, found tf times.
+func (x *FullText[V]) bm25(field string, w string, v V, tf int) float64 {
+	n := float64(len(x.docs))
+	df := float64(len(x.postings[field][w]))
+	idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+	length := float64(len(x.docs[v][field]))
+	avg := float64(x.fieldWords[field]) / n
+	if avg == 0 {
+		avg = 1
+	}
+	f := float64(tf)
+	return idf * f * (bm25K1 + 1) / (f + bm25K1*(1-bm25B+bm25B*length/avg))
+}
+
+//Tokens splits the text into the words indexed by FullText, normalized.
+//Words are made of letters and digits, and may end with the + and # of skills such as C++ and C#,
+//so an email or a name such as Node.js is split into its parts.
+func Tokens(text string) []string {
+	var ret []string
+	var b strings.Builder
+	flush := func() {
+		if b.Len() > 0 {
+			ret = append(ret, b.String())
+			b.Reset()
+		}
+	}
+
+	for _, r := range Normalize(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			//A letter after a + or # starts a new word, as in c#net
+			if s := b.String(); len(s) > 0 && (s[len(s)-1] == '+' || s[len(s)-1] == '#') {
+				flush()
+			}
+			b.WriteRune(r)
+		case (r == '+' || r == '#') && b.Len() > 0:
+			b.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return ret
+}
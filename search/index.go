@@ -0,0 +1,112 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+//Ordered are the types of the values an Index ranks, which are ordered to break the ties.
+type Ordered interface {
+	~int | ~int32 | ~int64 | ~uint | ~uint32 | ~uint64 | ~string
+}
+
+//Index finds values, such as the IDs of records, by the prefixes of the words written on them.
+//The words of a value are replaced as a whole when the record changes.
+//It is not safe for concurrent use.
+type Index[V Ordered] struct {
+	trie  *Trie[V]
+	words map[V][]string
+}
+
+//NewIndex returns an empty Index.
+func NewIndex[V Ordered]() *Index[V] {
+	return &Index[V]{trie: NewTrie[V](), words: make(map[V][]string)}
+}
+
+//Set indexes the value by the words of the texts, replacing the words it had.
+func (x *Index[V]) Set(v V, texts ...string) {
+	x.Delete(v)
+
+	var words []string
+	for _, text := range texts {
+		words = append(words, Words(text)...)
+	}
+	if len(words) == 0 {
+		return
+	}
+	for _, w := range words {
+		x.trie.Insert(w, v)
+	}
+	x.words[v] = words
+}
+
+//Delete removes the value from the Index.
+func (x *Index[V]) Delete(v V) {
+	for _, w := range x.words[v] {
+		x.trie.Remove(w, v)
+	}
+	delete(x.words, v)
+}
+
+//Prefix returns the values with a word starting with the prefix, regardless of case and accents.
+//An empty prefix matches nothing.
+func (x *Index[V]) Prefix(prefix string) []V {
+	return x.Suggest(prefix, 0)
+}
+
+//Suggest returns up to k values matching every word of the query, k <= 0 returning all of them.
+//Each word of the query is the prefix of a word of the value, so "jo sil" finds "João da Silva".
+//Values are ranked by how many runes their words have beyond the words of the query, then by value.
+func (x *Index[V]) Suggest(query string, k int) []V {
+	terms := Words(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var score map[V]int
+	for _, term := range terms {
+		found := make(map[V]int)
+		for _, c := range x.trie.Complete(term, 0) {
+			if score == nil {
+				found[c.Value] = c.Distance
+			} else if s, ok := score[c.Value]; ok {
+				found[c.Value] = s + c.Distance
+			}
+		}
+		score = found
+		if len(score) == 0 {
+			return nil
+		}
+	}
+
+	ret := make([]V, 0, len(score))
+	for v := range score {
+		ret = append(ret, v)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if score[ret[i]] != score[ret[j]] {
+			return score[ret[i]] < score[ret[j]]
+		}
+		return ret[i] < ret[j]
+	})
+	if k > 0 && len(ret) > k {
+		ret = ret[:k]
+	}
+	return ret
+}
+
+//Words splits the text on spaces, normalized, trimming the marks around each word.
+//The + and # ending skills such as C++ and C# are kept, as are the marks inside words, as on an email.
+func Words(text string) []string {
+	var ret []string
+	for _, f := range strings.FieldsFunc(Normalize(text), unicode.IsSpace) {
+		f = strings.TrimFunc(f, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '+' && r != '#'
+		})
+		if f != "" {
+			ret = append(ret, f)
+		}
+	}
+	return ret
+}
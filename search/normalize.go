@@ -0,0 +1,36 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+//Letters that do not decompose into a base letter and accents, written as they are typed without them.
+var unaccented = map[rune]string{
+	'ß': "ss",
+	'æ': "ae",
+	'œ': "oe",
+	'ø': "o",
+	'đ': "d",
+	'ł': "l",
+	'ı': "i",
+}
+
+//Normalize returns the text folded to lower case and without accents, so "Conceição" and "conceicao" are the same key.
+func Normalize(text string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(text) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		r = unicode.ToLower(r)
+		if s, found := unaccented[r]; found {
+			b.WriteString(s)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
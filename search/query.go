@@ -0,0 +1,258 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+//Query is a parsed search, made of words, phrases and the boolean operators combining them.
+type Query interface {
+	query()
+}
+
+//Words to find on the field, or on every field when Field is empty. Many words are a phrase, found one after the other.
+type termQuery struct {
+	Field string
+	Words []string
+}
+
+//Queries that must all match.
+type andQuery []Query
+
+//Queries of which at least one must match.
+type orQuery []Query
+
+//Query that must not match.
+type notQuery struct {
+	Query Query
+}
+
+func (termQuery) query() {}
+func (andQuery) query()  {}
+func (orQuery) query()   {}
+func (notQuery) query()  {}
+
+//Parse reads a search typed by a user, made of:
+//words, matched on every field; phrases between double quotes, matched as a whole;
+//field:word or field:"phrase", matched on the fields of the scope only;
+//AND, OR and NOT, in capitals, with NOT or a leading - excluding the term and parentheses grouping them.
+//Terms without an operator between them must all match, AND binds before OR.
+//Returns an error describing the first mistake found.
+func (x *FullText[V]) Parse(text string) (Query, error) {
+	tokens, err := x.tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("Query should not be empty")
+	}
+
+	p := queryParser{tokens: tokens}
+	q, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("Unexpected %v", p.tokens[p.pos])
+	}
+	return q, nil
+}
+
+type tokenKind int
+
+const (
+	tokenTerm tokenKind = iota
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+)
+
+type queryToken struct {
+	kind tokenKind
+	term termQuery
+	//Text of the token as typed, for the errors.
+	text string
+}
+
+func (t queryToken) String() string {
+	return fmt.Sprintf("'%v'", t.text)
+}
+
+//Splits the text into terms, operators and parentheses.
+func (x *FullText[V]) tokenize(text string) ([]queryToken, error) {
+	var ret []queryToken
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(':
+			ret = append(ret, queryToken{kind: tokenOpen, text: "("})
+			i++
+			continue
+		case r == ')':
+			ret = append(ret, queryToken{kind: tokenClose, text: ")"})
+			i++
+			continue
+		case r == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]):
+			ret = append(ret, queryToken{kind: tokenNot, text: "-"})
+			i++
+			continue
+		}
+
+		//A word, up to a space, a parenthesis or a quote, which may scope the term after it
+		start := i
+		for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' && runes[i] != '"' {
+			i++
+		}
+		word := string(runes[start:i])
+
+		switch word {
+		case "AND":
+			ret = append(ret, queryToken{kind: tokenAnd, text: word})
+			continue
+		case "OR":
+			ret = append(ret, queryToken{kind: tokenOr, text: word})
+			continue
+		case "NOT":
+			ret = append(ret, queryToken{kind: tokenNot, text: word})
+			continue
+		}
+
+		field := ""
+		if c := strings.Index(word, ":"); c > 0 {
+			field = strings.ToLower(word[:c])
+			if _, found := x.scopes[field]; !found {
+				return nil, fmt.Errorf("Unknown field '%v', must be one of %v", word[:c], strings.Join(x.fieldNames(), ", "))
+			}
+			word = word[c+1:]
+		}
+
+		if i < len(runes) && runes[i] == '"' && word == "" {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("Phrase starting at position %v is not closed", i+1)
+			}
+			word = string(runes[i+1 : end])
+			i = end + 1
+		}
+
+		words := Tokens(word)
+		if len(words) == 0 {
+			return nil, fmt.Errorf("Term '%v' has no words to search", string(runes[start:i]))
+		}
+		ret = append(ret, queryToken{kind: tokenTerm, term: termQuery{Field: field, Words: words}, text: string(runes[start:i])})
+	}
+	return ret, nil
+}
+
+//Returns the names of the fields and scopes, sorted.
+func (x *FullText[V]) fieldNames() []string {
+	var ret []string
+	for name := range x.scopes {
+		if name != "" {
+			ret = append(ret, name)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+//Recursive descent parser of the tokens.
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) peek() (queryToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return queryToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+//or := and (OR and)*
+func (p *queryParser) or() (Query, error) {
+	var ret orQuery
+	for {
+		q, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, q)
+
+		t, ok := p.peek()
+		if !ok || t.kind != tokenOr {
+			break
+		}
+		p.pos++
+	}
+	if len(ret) == 1 {
+		return ret[0], nil
+	}
+	return ret, nil
+}
+
+//and := unary ([AND] unary)*
+func (p *queryParser) and() (Query, error) {
+	var ret andQuery
+	for {
+		q, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, q)
+
+		t, ok := p.peek()
+		if !ok || t.kind == tokenOr || t.kind == tokenClose {
+			break
+		}
+		if t.kind == tokenAnd {
+			p.pos++
+		}
+	}
+	if len(ret) == 1 {
+		return ret[0], nil
+	}
+	return ret, nil
+}
+
+//unary := NOT unary | ( or ) | term
+func (p *queryParser) unary() (Query, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("Query ends where a term was expected")
+	}
+	p.pos++
+
+	switch t.kind {
+	case tokenNot:
+		q, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notQuery{q}, nil
+	case tokenOpen:
+		q, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if c, ok := p.peek(); !ok || c.kind != tokenClose {
+			return nil, fmt.Errorf("Parenthesis is not closed")
+		}
+		p.pos++
+		return q, nil
+	case tokenTerm:
+		return t.term, nil
+	}
+	return nil, fmt.Errorf("Unexpected %v where a term was expected", t)
+}
@@ -0,0 +1,73 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newTestIndex() *FullText[int] {
+	return NewFullText[int](map[string]float64{"first": 1, "last": 1, "skills": 1}, map[string][]string{"name": {"first", "last"}})
+}
+
+func term(field string, words ...string) termQuery {
+	return termQuery{Field: field, Words: words}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text string
+		want Query
+	}{
+		{"go", term("", "go")},
+		{"Go Kubernetes", andQuery{term("", "go"), term("", "kubernetes")}},
+		{"go AND rust", andQuery{term("", "go"), term("", "rust")}},
+		{"go OR rust java", orQuery{term("", "go"), andQuery{term("", "rust"), term("", "java")}}},
+		{"(go OR rust) java", andQuery{orQuery{term("", "go"), term("", "rust")}, term("", "java")}},
+		{"go -java", andQuery{term("", "go"), notQuery{term("", "java")}}},
+		{"NOT NOT go", notQuery{notQuery{term("", "go")}}},
+		{`"Node.js developer"`, term("", "node", "js", "developer")},
+		{`Skills:C++ name:"Conceição"`, andQuery{term("skills", "c++"), term("name", "conceicao")}},
+		{"or and", andQuery{term("", "or"), term("", "and")}},
+	}
+	x := newTestIndex()
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := x.Parse(tt.text)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"", "Query should not be empty"},
+		{"   ", "Query should not be empty"},
+		{"go AND", "Query ends where a term was expected"},
+		{"OR go", "Unexpected 'OR' where a term was expected"},
+		{"(go OR rust", "Parenthesis is not closed"},
+		{"go)", "Unexpected ')'"},
+		{"()", "Unexpected ')' where a term was expected"},
+		{`"go developer`, "Phrase starting at position 1 is not closed"},
+		{"email:jane", "Unknown field 'email', must be one of first, last, name, skills"},
+		{"skills:", "Term 'skills:' has no words to search"},
+		{"...", "Term '...' has no words to search"},
+		{"go - rust", "Term '-' has no words to search"},
+	}
+	x := newTestIndex()
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			_, err := x.Parse(tt.text)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Parse() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+//Package search keeps the indexes used to find records by the words written on them.
+package search
+
+import "sort"
+
+//Trie maps keys to values, finding every value of the keys starting with a prefix.
+//A key may have many values and a value many keys, such as the IDs of the records a word is written on.
+//Keys are indexed by rune after Normalize, so they are matched regardless of case and accents.
+//It is not safe for concurrent use.
+type Trie[V comparable] struct {
+	root *trieNode[V]
+	size int
+}
+
+type trieNode[V comparable] struct {
+	children map[rune]*trieNode[V]
+	//Values of the key ending on the node, in the order they were inserted.
+	values []V
+}
+
+//Completion is a value found by Complete, with the key it was found by.
+type Completion[V comparable] struct {
+	Value V
+	Key   string
+	//Number of runes the key has beyond the prefix, 0 when the prefix is the whole key.
+	Distance int
+}
+
+//NewTrie returns an empty Trie.
+func NewTrie[V comparable]() *Trie[V] {
+	return &Trie[V]{root: &trieNode[V]{}}
+}
+
+//Len returns the number of key and value pairs on the Trie.
+func (t *Trie[V]) Len() int {
+	return t.size
+}
+
+//Insert adds the value to the key, doing nothing if the key already has it.
+func (t *Trie[V]) Insert(key string, v V) {
+	n := t.root
+	for _, r := range Normalize(key) {
+		if n.children == nil {
+			n.children = make(map[rune]*trieNode[V])
+		}
+		child := n.children[r]
+		if child == nil {
+			child = &trieNode[V]{}
+			n.children[r] = child
+		}
+		n = child
+	}
+
+	for _, existing := range n.values {
+		if existing == v {
+			return
+		}
+	}
+	n.values = append(n.values, v)
+	t.size++
+}
+
+//Search returns the values of the key, nil when the key is not on the Trie.
+func (t *Trie[V]) Search(key string) []V {
+	n := t.find([]rune(Normalize(key)))
+	if n == nil || len(n.values) == 0 {
+		return nil
+	}
+	return append([]V(nil), n.values...)
+}
+
+//Prefix returns the values of every key starting with the prefix, once each, ranked as Complete does.
+func (t *Trie[V]) Prefix(prefix string) []V {
+	var ret []V
+	for _, c := range t.Complete(prefix, 0) {
+		ret = append(ret, c.Value)
+	}
+	return ret
+}
+
+//Complete returns up to k values of the keys starting with the prefix, k <= 0 returning all of them.
+//Values are ranked by the shortest of their keys, so an exact match comes first and the closest completions follow.
+//Keys of the same length are ranked in rune order. Each value is returned once, with the best key it was found by.
+func (t *Trie[V]) Complete(prefix string, k int) []Completion[V] {
+	start := []rune(Normalize(prefix))
+	n := t.find(start)
+	if n == nil {
+		return nil
+	}
+
+	type entry struct {
+		node *trieNode[V]
+		key  []rune
+	}
+
+	var ret []Completion[V]
+	seen := make(map[V]bool)
+	//Breadth first, so the keys are visited from the shortest
+	level := []entry{{n, start}}
+	for distance := 0; len(level) > 0; distance++ {
+		var next []entry
+		for _, e := range level {
+			for _, v := range e.node.values {
+				if seen[v] {
+					continue
+				}
+				seen[v] = true
+				ret = append(ret, Completion[V]{Value: v, Key: string(e.key), Distance: distance})
+				if k > 0 && len(ret) == k {
+					return ret
+				}
+			}
+			for _, r := range e.node.sortedChildren() {
+				key := append(append(make([]rune, 0, len(e.key)+1), e.key...), r)
+				next = append(next, entry{e.node.children[r], key})
+			}
+		}
+		level = next
+	}
+	return ret
+}
+
+//Remove takes the value out of the key, pruning the nodes left without keys.
+//Returns false if the key did not have the value.
+func (t *Trie[V]) Remove(key string, v V) bool {
+	runes := []rune(Normalize(key))
+	removed := false
+	var remove func(n *trieNode[V], depth int) bool
+	//Returns whether the node is left empty and can be pruned
+	remove = func(n *trieNode[V], depth int) bool {
+		if depth == len(runes) {
+			for i, existing := range n.values {
+				if existing == v {
+					n.values = append(n.values[:i], n.values[i+1:]...)
+					removed = true
+					break
+				}
+			}
+		} else if child := n.children[runes[depth]]; child != nil && remove(child, depth+1) {
+			delete(n.children, runes[depth])
+		}
+		return len(n.values) == 0 && len(n.children) == 0
+	}
+
+	remove(t.root, 0)
+	if removed {
+		t.size--
+	}
+	return removed
+}
+
+func (t *Trie[V]) find(key []rune) *trieNode[V] {
+	n := t.root
+	for i := 0; i < len(key) && n != nil; i++ {
+		n = n.children[key[i]]
+	}
+	return n
+}
+
+func (n *trieNode[V]) sortedChildren() []rune {
+	keys := make([]rune, 0, len(n.children))
+	for r := range n.children {
+		keys = append(keys, r)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
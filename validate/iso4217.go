@@ -0,0 +1,45 @@
+package validate
+
+import "strings"
+
+//ISO 4217 codes of the currencies in circulation.
+const currencyCodes = "" +
+	"AED AFN ALL AMD ANG AOA ARS AUD AWG AZN " +
+	"BAM BBD BDT BGN BHD BIF BMD BND BOB BRL BSD BTN BWP BYN BZD " +
+	"CAD CDF CHF CLP CNY COP CRC CUP CVE CZK " +
+	"DJF DKK DOP DZD " +
+	"EGP ERN ETB EUR " +
+	"FJD FKP " +
+	"GBP GEL GHS GIP GMD GNF GTQ GYD " +
+	"HKD HNL HTG HUF " +
+	"IDR ILS INR IQD IRR ISK " +
+	"JMD JOD JPY " +
+	"KES KGS KHR KMF KPW KRW KWD KYD KZT " +
+	"LAK LBP LKR LRD LSL LYD " +
+	"MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MYR MZN " +
+	"NAD NGN NIO NOK NPR NZD " +
+	"OMR " +
+	"PAB PEN PGK PHP PKR PLN PYG " +
+	"QAR " +
+	"RON RSD RUB RWF " +
+	"SAR SBD SCR SDG SEK SGD SHP SLE SOS SRD SSP STN SVC SYP SZL " +
+	"THB TJS TMT TND TOP TRY TTD TWD TZS " +
+	"UAH UGX USD UYU UZS " +
+	"VES VND VUV " +
+	"WST " +
+	"XAF XCD XOF XPF " +
+	"YER " +
+	"ZAR ZMW ZWL"
+
+var isoCurrencies = func() map[string]bool {
+	m := make(map[string]bool)
+	for _, c := range strings.Fields(currencyCodes) {
+		m[c] = true
+	}
+	return m
+}()
+
+//IsCurrencyCode returns true when code is an ISO 4217 currency code, in upper case.
+func IsCurrencyCode(code string) bool {
+	return isoCurrencies[code]
+}
@@ -0,0 +1,283 @@
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//FieldError describes why a single field of the record is invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+//Rule checks the value of a field against the parameter written after the rule name (max=50).
+//Returns an empty string when the value is valid, or the message describing the violation.
+type Rule func(v reflect.Value, param string) string
+
+var (
+	mu    sync.RWMutex
+	rules = map[string]Rule{
+		"required": required,
+		"email":    email,
+		"iso3166":  iso3166,
+		"iso4217":  iso4217,
+		"min":      min,
+		"max":      max,
+		"oneof":    oneOf,
+		"timezone": timezone,
+		"url":      webURL,
+	}
+)
+
+//Register adds a rule that can be used on the validate tag of any struct.
+//Registering a name that already exists replaces the rule.
+func Register(name string, r Rule) {
+	mu.Lock()
+	defer mu.Unlock()
+	rules[name] = r
+}
+
+//Validator collects every violation found on a record so they can be reported at once.
+type Validator struct {
+	errs []FieldError
+}
+
+//Struct checks every field of s, a struct or a pointer to one, against the rules on its validate tag:
+//
+//	Email string `validate:"required,email"`
+//
+//Rules are separated by commas and run in order, stopping at the first violation of each field.
+//The dive rule checks each element of a slice of structs with the rules of the element type.
+func (v *Validator) Struct(s interface{}) {
+	v.walk(reflect.ValueOf(s), "")
+}
+
+//Check records the message for the field when ok is false.
+//Used for the rules that depend on the operation or on other records, such as IDs or references.
+func (v *Validator) Check(ok bool, field string, format string, a ...interface{}) {
+	if !ok {
+		v.Add(field, fmt.Sprintf(format, a...))
+	}
+}
+
+//Add records a violation for the field.
+func (v *Validator) Add(field string, message string) {
+	v.errs = append(v.errs, FieldError{Field: field, Message: message})
+}
+
+//Errors returns every violation recorded, in the order they were found.
+func (v *Validator) Errors() []FieldError {
+	return v.errs
+}
+
+//Valid returns true when no violation was recorded.
+func (v *Validator) Valid() bool {
+	return len(v.errs) == 0
+}
+
+func (v *Validator) walk(s reflect.Value, prefix string) {
+	for s.Kind() == reflect.Ptr || s.Kind() == reflect.Interface {
+		if s.IsNil() {
+			return
+		}
+		s = s.Elem()
+	}
+	if s.Kind() != reflect.Struct {
+		return
+	}
+
+	t := s.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("validate")
+		if !ok || f.PkgPath != "" {
+			continue
+		}
+		v.field(s.Field(i), prefix+f.Name, tag)
+	}
+}
+
+func (v *Validator) field(value reflect.Value, name string, tag string) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, r := range strings.Split(tag, ",") {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		ruleName, param := r, ""
+		if i := strings.Index(r, "="); i >= 0 {
+			ruleName, param = r[:i], r[i+1:]
+		}
+
+		if ruleName == "dive" {
+			for j := 0; j < value.Len(); j++ {
+				v.walk(value.Index(j), fmt.Sprintf("%v[%v].", name, j))
+			}
+			continue
+		}
+
+		rule, found := rules[ruleName]
+		if !found {
+			panic(fmt.Sprintf("validate: unknown rule %q on field %v", ruleName, name))
+		}
+		if msg := rule(value, param); msg != "" {
+			v.Add(name, msg)
+			return
+		}
+	}
+}
+
+func required(v reflect.Value, param string) string {
+	if v.IsZero() {
+		return "should be populated"
+	}
+	return ""
+}
+
+//Accepts a bare address such as jane@example.com, without display name.
+//Empty values are left to the required rule.
+func email(v reflect.Value, param string) string {
+	s := v.String()
+	if s == "" {
+		return ""
+	}
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s || !strings.Contains(s[strings.LastIndex(s, "@"):], ".") {
+		return "is not a valid email address"
+	}
+	return ""
+}
+
+//Accepts the ISO 3166-1 alpha-2 codes, in upper case.
+//Empty values are left to the required rule.
+func iso3166(v reflect.Value, param string) string {
+	s := v.String()
+	if s == "" {
+		return ""
+	}
+	if !IsCountryCode(s) {
+		return "is not an ISO 3166-1 alpha-2 country code"
+	}
+	return ""
+}
+
+//Accepts the ISO 4217 currency codes, in upper case.
+//Empty values are left to the required rule.
+func iso4217(v reflect.Value, param string) string {
+	s := v.String()
+	if s == "" {
+		return ""
+	}
+	if !IsCurrencyCode(s) {
+		return "is not an ISO 4217 currency code"
+	}
+	return ""
+}
+
+//Accepts one of the values separated by spaces on the parameter (oneof=Pending Passed Failed).
+//Empty values are left to the required rule.
+func oneOf(v reflect.Value, param string) string {
+	s := v.String()
+	if s == "" {
+		return ""
+	}
+	for _, allowed := range strings.Fields(param) {
+		if s == allowed {
+			return ""
+		}
+	}
+	return fmt.Sprintf("must be one of %v", strings.Join(strings.Fields(param), ", "))
+}
+
+//Accepts the names of the IANA time zone database, such as America/Sao_Paulo.
+//Empty values are left to the required rule.
+func timezone(v reflect.Value, param string) string {
+	s := v.String()
+	if s == "" {
+		return ""
+	}
+	if _, err := time.LoadLocation(s); err != nil || s == "Local" {
+		return "is not a time zone of the IANA database"
+	}
+	return ""
+}
+
+//Accepts the absolute http and https URLs, such as the links of video calls.
+//Empty values are left to the required rule.
+func webURL(v reflect.Value, param string) string {
+	s := v.String()
+	if s == "" {
+		return ""
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.ContainsAny(s, " \t") {
+		return "is not a valid http or https URL"
+	}
+	return ""
+}
+
+//Minimum length of strings and slices, or minimum value of numbers.
+func min(v reflect.Value, param string) string {
+	n, ok := measure(v)
+	limit, err := strconv.ParseFloat(param, 64)
+	if !ok || err != nil {
+		panic(fmt.Sprintf("validate: min=%v cannot be used on %v", param, v.Type()))
+	}
+	if n < limit {
+		if isLength(v) {
+			return fmt.Sprintf("must have at least %v characters", param)
+		}
+		return fmt.Sprintf("must be at least %v", param)
+	}
+	return ""
+}
+
+//Maximum length of strings and slices, or maximum value of numbers.
+func max(v reflect.Value, param string) string {
+	n, ok := measure(v)
+	limit, err := strconv.ParseFloat(param, 64)
+	if !ok || err != nil {
+		panic(fmt.Sprintf("validate: max=%v cannot be used on %v", param, v.Type()))
+	}
+	if n > limit {
+		if isLength(v) {
+			return fmt.Sprintf("must have at most %v characters", param)
+		}
+		return fmt.Sprintf("must be at most %v", param)
+	}
+	return ""
+}
+
+func isLength(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return true
+	}
+	return false
+}
+
+//Returns the length of strings and collections or the value of numbers.
+func measure(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(len([]rune(v.String()))), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,90 @@
+package validate
+
+import (
+	"reflect"
+	"testing"
+)
+
+type address struct {
+	Country string `validate:"required,iso3166"`
+}
+
+type record struct {
+	Name      string    `validate:"required,max=5"`
+	Email     string    `validate:"email"`
+	Currency  string    `validate:"iso4217"`
+	Age       int       `validate:"min=18,max=99"`
+	Skills    []string  `validate:"min=1"`
+	Status    string    `validate:"oneof=Active Inactive"`
+	TimeZone  string    `validate:"timezone"`
+	Link      string    `validate:"url"`
+	Addresses []address `validate:"dive"`
+}
+
+func TestStruct(t *testing.T) {
+	valid := record{Name: "Jane", Age: 30, Skills: []string{"Go"}}
+
+	tests := []struct {
+		name   string
+		change func(r *record)
+		want   []FieldError
+	}{
+		{"valid", func(r *record) {}, nil},
+		{"optional values set", func(r *record) {
+			r.Email = "jane@mail.com"
+			r.Currency = "BRL"
+			r.Status = "Active"
+			r.TimeZone = "America/Sao_Paulo"
+			r.Link = "https://meet.example.com/abc?pwd=1"
+			r.Addresses = []address{{Country: "BR"}}
+		}, nil},
+		{"required", func(r *record) { r.Name = "" }, []FieldError{{"Name", "should be populated"}}},
+		{"max length in characters", func(r *record) { r.Name = "Joãos" }, nil},
+		{"max length", func(r *record) { r.Name = "Jane Doe" }, []FieldError{{"Name", "must have at most 5 characters"}}},
+		{"email", func(r *record) { r.Email = "jane@mail" }, []FieldError{{"Email", "is not a valid email address"}}},
+		{"email with display name", func(r *record) { r.Email = "Jane <jane@mail.com>" }, []FieldError{{"Email", "is not a valid email address"}}},
+		{"iso4217", func(r *record) { r.Currency = "brl" }, []FieldError{{"Currency", "is not an ISO 4217 currency code"}}},
+		{"min value", func(r *record) { r.Age = 17 }, []FieldError{{"Age", "must be at least 18"}}},
+		{"max value", func(r *record) { r.Age = 100 }, []FieldError{{"Age", "must be at most 99"}}},
+		{"oneof", func(r *record) { r.Status = "active" }, []FieldError{{"Status", "must be one of Active, Inactive"}}},
+		{"timezone", func(r *record) { r.TimeZone = "Mars/Olympus" }, []FieldError{{"TimeZone", "is not a time zone of the IANA database"}}},
+		{"timezone Local", func(r *record) { r.TimeZone = "Local" }, []FieldError{{"TimeZone", "is not a time zone of the IANA database"}}},
+		{"url scheme", func(r *record) { r.Link = "javascript:alert(1)" }, []FieldError{{"Link", "is not a valid http or https URL"}}},
+		{"url relative", func(r *record) { r.Link = "/meet/abc" }, []FieldError{{"Link", "is not a valid http or https URL"}}},
+		{"url line break", func(r *record) { r.Link = "https://meet.example.com/abc\r\nX-INJECTED:1" }, []FieldError{{"Link", "is not a valid http or https URL"}}},
+		{"dive", func(r *record) { r.Addresses = []address{{Country: "BR"}, {Country: "XX"}, {}} }, []FieldError{
+			{"Addresses[1].Country", "is not an ISO 3166-1 alpha-2 country code"},
+			{"Addresses[2].Country", "should be populated"},
+		}},
+		{"every field reported", func(r *record) {
+			r.Name = ""
+			r.Age = 0
+		}, []FieldError{{"Name", "should be populated"}, {"Age", "must be at least 18"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := valid
+			tt.change(&r)
+			var v Validator
+			v.Struct(&r)
+			if !reflect.DeepEqual(v.Errors(), tt.want) {
+				t.Errorf("Errors() = %+v, want %+v", v.Errors(), tt.want)
+			}
+			if v.Valid() != (len(tt.want) == 0) {
+				t.Errorf("Valid() = %v with %v errors", v.Valid(), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestUnknownRule(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Struct() did not panic on an unknown rule")
+		}
+	}()
+	var v Validator
+	v.Struct(struct {
+		Name string `validate:"nonsense"`
+	}{})
+}